@@ -0,0 +1,151 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// secretPrefix marks the values encrypted by Secret, followed by the key ID
+const secretPrefix = "enc:v1:"
+
+// Secrets : encrypts the secrets saved on db, like the secret keys of the Ranchers
+var Secrets *Secret
+
+// Secret : AES-GCM encryption with the current key, decrypting also with the
+// previous keys while they are rotated
+type Secret struct {
+	current  secretKey
+	previous []secretKey
+}
+
+type secretKey struct {
+	ID   string
+	aead cipher.AEAD
+}
+
+// NewSecret : creates a Secret with the current key and the keys replaced by it
+func NewSecret(key string, previous ...string) (*Secret, error) {
+	current, err := newSecretKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Secret{current: current}
+	for _, k := range previous {
+		if strings.TrimSpace(k) == "" {
+			continue
+		}
+
+		old, err := newSecretKey(k)
+		if err != nil {
+			return nil, err
+		}
+		s.previous = append(s.previous, old)
+	}
+
+	return s, nil
+}
+
+// newSecretKey derives the AES-256 key from any text, identified by the first
+// bytes of its hash to find the key of a value on decrypt
+func newSecretKey(key string) (secretKey, error) {
+	key = strings.TrimSpace(key)
+	if len(key) < 16 {
+		return secretKey{}, fmt.Errorf("encryption key must have at least 16 characters")
+	}
+
+	sum := sha256.Sum256([]byte(key))
+
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return secretKey{}, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return secretKey{}, err
+	}
+
+	ID := sha256.Sum256(sum[:])
+
+	return secretKey{ID: hex.EncodeToString(ID[:4]), aead: aead}, nil
+}
+
+// Encrypt : encrypts the value with the current key
+func (s *Secret) Encrypt(value string) (string, error) {
+	nonce := make([]byte, s.current.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := s.current.aead.Seal(nonce, nonce, []byte(value), nil)
+
+	return fmt.Sprintf("%s%s:%s", secretPrefix, s.current.ID, base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// Decrypt : decrypts a value with the key that encrypted it. Values that were
+// never encrypted (saved before the encryption) are returned as they are
+func (s *Secret) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, secretPrefix), ":", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+
+	key, ok := s.findKey(parts[0])
+	if !ok {
+		return "", fmt.Errorf("value encrypted with the unknown key %s, set it as a previous key", parts[0])
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil || len(sealed) < key.aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+
+	nonce := sealed[:key.aead.NonceSize()]
+	plain, err := key.aead.Open(nil, nonce, sealed[key.aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("value can't be decrypted with the key %s", key.ID)
+	}
+
+	return string(plain), nil
+}
+
+// UsesCurrentKey : checks if the value is encrypted with the current key
+func (s *Secret) UsesCurrentKey(value string) bool {
+	return strings.HasPrefix(value, fmt.Sprintf("%s%s:", secretPrefix, s.current.ID))
+}
+
+// KeyID : identifies the current key, without revealing it
+func (s *Secret) KeyID() string {
+	return s.current.ID
+}
+
+func (s *Secret) findKey(ID string) (secretKey, bool) {
+	if s.current.ID == ID {
+		return s.current, true
+	}
+
+	for _, key := range s.previous {
+		if key.ID == ID {
+			return key, true
+		}
+	}
+
+	return secretKey{}, false
+}
+
+// IsEncrypted : checks if the value was encrypted by a Secret
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, secretPrefix)
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	testOldKey = "old-key-0123456789"
+	testNewKey = "new-key-0123456789"
+)
+
+func TestSecretRotation(t *testing.T) {
+	old, err := NewSecret(testOldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := old.Encrypt("rancher-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(encrypted, secretPrefix+old.KeyID()+":") {
+		t.Fatalf("Encrypt = %q, want the prefix %s%s:", encrypted, secretPrefix, old.KeyID())
+	}
+
+	// the empty entries of SECRET_ENCRYPTION_PREVIOUS_KEYS are ignored
+	rotated, err := NewSecret(testNewKey, "", testOldKey, " ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withoutOld, err := NewSecret(testNewKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		secret  *Secret
+		value   string
+		want    string
+		current bool
+		err     bool
+	}{
+		{"current key", old, encrypted, "rancher-secret", true, false},
+		{"previous key", rotated, encrypted, "rancher-secret", false, false},
+		{"unknown key", withoutOld, encrypted, "", false, true},
+		{"not encrypted", rotated, "plain-secret", "plain-secret", false, false},
+		{"malformed", rotated, secretPrefix + "abc", "", false, true},
+		{"tampered", old, encrypted[:len(encrypted)-4] + "AAA=", "", true, true},
+	}
+
+	for _, test := range tests {
+		got, err := test.secret.Decrypt(test.value)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("%s: Decrypt = %q, want an error", test.name, got)
+		case !test.err && err != nil:
+			t.Errorf("%s: Decrypt returned %s", test.name, err)
+		case got != test.want:
+			t.Errorf("%s: Decrypt = %q, want %q", test.name, got, test.want)
+		}
+
+		if current := test.secret.UsesCurrentKey(test.value); current != test.current {
+			t.Errorf("%s: UsesCurrentKey = %t, want %t", test.name, current, test.current)
+		}
+	}
+
+	// the rotation encrypts again the values of the previous key
+	reencrypted, err := rotated.Encrypt("rancher-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !rotated.UsesCurrentKey(reencrypted) || old.UsesCurrentKey(reencrypted) {
+		t.Errorf("value encrypted after the rotation %q doesn't use only the new key", reencrypted)
+	}
+
+	if _, err := old.Decrypt(reencrypted); err == nil {
+		t.Errorf("the old key decrypted a value of the new key")
+	}
+}
+
+func TestNewSecretShortKey(t *testing.T) {
+	if _, err := NewSecret("short"); err == nil {
+		t.Errorf("NewSecret with a short key = nil error, want an error")
+	}
+
+	if _, err := NewSecret(testNewKey, "short"); err == nil {
+		t.Errorf("NewSecret with a short previous key = nil error, want an error")
+	}
+}
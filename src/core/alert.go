@@ -0,0 +1,133 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/rancher"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+// taskAlert é o alerta de uma task, enviado como uma única mensagem (a do
+// incidente) que é atualizada enquanto a task não se recupera
+type taskAlert struct {
+	task     model.Task
+	health   *model.TaskHealth
+	incident *model.Incident
+	svc      rancher.Service
+	envName  string
+}
+
+// openTaskAlert abre um incidente para a falha da task e envia a mensagem dele,
+// com os botões de acknowledge e resolve
+func (s *SlackListener) openTaskAlert(alert *taskAlert) {
+	incident := model.Incident{
+		TaskID:      alert.task.ID,
+		Service:     alert.task.Service,
+		RancherID:   alert.task.RancherID,
+		ProjectID:   alert.task.RancherProjectID,
+		Environment: alert.envName,
+	}
+
+	if err := service.OpenIncident(&incident); err != nil {
+		CheckErr(fmt.Sprintf("Error on open incident of task %d", alert.task.ID), err)
+		s.client.PostMessage(alert.task.ChannelToSendAlert, slack.MsgOptionText(taskAlertText(*alert, nil), false))
+		return
+	}
+	alert.incident = &incident
+
+	channel, ts, err := s.client.PostMessage(alert.task.ChannelToSendAlert, slack.MsgOptionText(taskAlertText(*alert, nil), false), slack.MsgOptionAttachments(incidentAttachment(incident)))
+	if err != nil {
+		CheckErr(fmt.Sprintf("Error on send alert of task %d", alert.task.ID), err)
+	}
+
+	incident.Channel = channel
+	incident.MessageTS = ts
+	CheckErr(fmt.Sprintf("Error on save incident %d", incident.ID), service.SaveIncident(&incident))
+
+	alert.health.IncidentID = incident.ID
+}
+
+// updateTaskAlert atualiza a mensagem do incidente com o estado atual da task
+func (s *SlackListener) updateTaskAlert(alert taskAlert, silence *model.Silence) {
+	// o incidente pode ter sido reconhecido pelo botão durante a verificação
+	if incident, err := service.FindIncident(alert.incident.ID); err == nil {
+		*alert.incident = incident
+	}
+
+	_, _, _, err := s.client.UpdateMessage(alert.incident.Channel, alert.incident.MessageTS, slack.MsgOptionText(taskAlertText(alert, silence), false), slack.MsgOptionAttachments(incidentAttachment(*alert.incident)))
+	CheckErr(fmt.Sprintf("Error on update alert of task %d", alert.task.ID), err)
+}
+
+// replyTaskAlert responde na thread do incidente ou, sem incidente aberto, no canal da task
+func (s *SlackListener) replyTaskAlert(alert taskAlert, text string) {
+	if alert.incident == nil || alert.incident.MessageTS == "" {
+		s.client.PostMessage(alert.task.ChannelToSendAlert, slack.MsgOptionText(text, false))
+		return
+	}
+
+	s.client.PostMessage(alert.incident.Channel, slack.MsgOptionText(text, false), slack.MsgOptionTS(alert.incident.MessageTS))
+}
+
+// closeTaskAlert resolve o incidente com a recuperação da task, marcando a
+// mensagem como resolvida e avisando na thread
+func (s *SlackListener) closeTaskAlert(alert *taskAlert) {
+	text := fmt.Sprintf("The service `%s` of environment `%s` is back! Actually is `%s`", alert.task.Service, alert.envName, alert.svc.HealthState)
+
+	if alert.incident == nil {
+		s.client.PostMessage(alert.task.ChannelToSendAlert, slack.MsgOptionText(text, false))
+		return
+	}
+
+	incident, err := service.ResolveIncident(alert.incident.ID, "", time.Now())
+	if err != nil {
+		CheckErr(fmt.Sprintf("Error on resolve incident %d", alert.incident.ID), err)
+	} else {
+		*alert.incident = incident
+		if d, ok := service.TimeToResolve(incident); ok {
+			text += fmt.Sprintf(" Incident `%d` resolved in %s", incident.ID, d.Round(time.Second))
+		}
+	}
+
+	if alert.incident.MessageTS != "" {
+		_, _, _, err = s.client.UpdateMessage(alert.incident.Channel, alert.incident.MessageTS, slack.MsgOptionText(taskAlertText(*alert, nil), false), slack.MsgOptionAttachments(incidentAttachment(*alert.incident)))
+		CheckErr(fmt.Sprintf("Error on update alert of task %d", alert.task.ID), err)
+
+		s.client.PostMessage(alert.incident.Channel, slack.MsgOptionText(text, false), slack.MsgOptionTS(alert.incident.MessageTS), slack.MsgOptionBroadcast())
+	} else {
+		s.client.PostMessage(alert.task.ChannelToSendAlert, slack.MsgOptionText(text, false))
+	}
+
+	alert.health.IncidentID = 0
+}
+
+func taskAlertText(alert taskAlert, silence *model.Silence) string {
+	icon := ":rotating_light:"
+	switch {
+	case alert.health.State == model.HealthHealthy:
+		icon = ":white_check_mark:"
+	case alert.health.State == model.HealthRecovering:
+		icon = ":hourglass_flowing_sand:"
+	}
+
+	text := fmt.Sprintf("%s Please, check the service `%s` in Environment `%s` actually is `%s`\nTask: `%d` | State: `%s` | Failed checks: %d | Last check: %s",
+		icon, alert.task.Service, alert.envName, alert.svc.HealthState, alert.task.ID, alert.health.State, alert.health.FailedChecks, time.Now().Format("2006-01-02 15:04:05"))
+
+	if alert.health.State == model.HealthHealthy {
+		text += "\n*Resolved*"
+	}
+
+	if silence != nil {
+		text += fmt.Sprintf("\n:zzz: Alerts and restarts suppressed by %s `%d` (%s)", silence.Kind, silence.ID, silenceDescription(*silence))
+		if silence.Reason != "" {
+			text += fmt.Sprintf(": %s", silence.Reason)
+		}
+	}
+
+	return text
+}
@@ -0,0 +1,87 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// commandOutput guarda as respostas e o evento de auditoria de um comando
+// executado pela API
+type commandOutput struct {
+	messages []model.CommandMessage
+	event    *model.AuditEvent
+
+	// pending são os comandos que continuam fora do slackEventsMutex
+	pending sync.WaitGroup
+}
+
+func (o *commandOutput) add(options ...slack.MsgOption) {
+	_, values, err := slack.UnsafeApplyMsgOptions("", "", "", options...)
+	if err != nil {
+		CheckErr("Error on read the reply of the command", err)
+		return
+	}
+
+	message := model.CommandMessage{Text: values.Get("text")}
+	if attachments := values.Get("attachments"); attachments != "" {
+		message.Attachments = json.RawMessage(attachments)
+	}
+	if blocks := values.Get("blocks"); blocks != "" {
+		message.Blocks = json.RawMessage(blocks)
+	}
+
+	o.messages = append(o.messages, message)
+}
+
+// ExecuteCommand executa um comando registrado como se o usuário da API o
+// chamasse no Slack, passando pelo contexto, permissões, aprovação e
+// auditoria. As respostas voltam no resultado em vez de irem para o Slack
+func (s *SlackListener) ExecuteCommand(name string, user string, execution model.CommandExecution) (model.CommandResult, error) {
+	cmd := findCommand(name)
+	if cmd == nil {
+		return model.CommandResult{}, fmt.Errorf("command `%s` not found", name)
+	}
+
+	listener := *s
+	listener.output = &commandOutput{}
+
+	ev := &slack.MessageEvent{}
+	ev.Type = "message"
+	ev.Channel = execution.Channel
+	if ev.Channel == "" {
+		ev.Channel = s.channelID
+	}
+	ev.User = model.APIUserPrefix + user
+	ev.Text = strings.TrimSpace(fmt.Sprintf("<@%s> %s %s", s.botID, cmd.Cmd, execution.Args))
+
+	slackEventsMutex.Lock()
+	listener.handleCommand(ev)
+	slackEventsMutex.Unlock()
+
+	listener.output.pending.Wait()
+
+	result := model.CommandResult{
+		Command:  cmd.Cmd,
+		User:     ev.User,
+		Messages: listener.output.messages,
+	}
+
+	// o help dos comandos não passa pela auditoria
+	if event := listener.output.event; event != nil {
+		result.Outcome = event.Outcome
+		result.Detail = event.Detail
+		result.RancherID = event.RancherID
+		result.ProjectID = event.ProjectID
+		result.AuditEventID = event.ID
+	}
+
+	return result, nil
+}
@@ -0,0 +1,332 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+const (
+	// approvalCallbackID é o callback dos botões da mensagem do pedido de aprovação
+	approvalCallbackID = "approval"
+
+	actionApprove = "approve"
+	actionReject  = "reject"
+
+	// approvalCheckInterval é o intervalo em que o BOT expira os pedidos não decididos
+	approvalCheckInterval = time.Minute
+)
+
+// approvalTimeout é quanto tempo um pedido espera a aprovação, alterado pelo APPROVAL_TIMEOUT
+var approvalTimeout = 30 * time.Minute
+
+// needsApproval verifica se a chamada do comando precisa de aprovação. Só
+// mostrar o picker não precisa, o comando escolhido nele sim, nem listar ou
+// cancelar os canary rollouts
+func needsApproval(cmd Command, args CommandArgs) bool {
+	if !cmd.NeedsApproval || opensPicker(cmd, args) {
+		return false
+	}
+
+	if cmd.Cmd == canaryRollout {
+		switch args.String("lb-id") {
+		case "list", "cancel":
+			return false
+		}
+	}
+
+	return true
+}
+
+// holdForApproval segura os comandos NeedsApproval executados em environments
+// protegidos, postando um pedido de aprovação no lugar. Retorna false quando o
+// comando pode ser executado direto
+func (s *SlackListener) holdForApproval(ev *slack.MessageEvent, cmd Command, event *model.AuditEvent) bool {
+	rancherID, projectID := s.context.rancherID, s.context.projectID
+
+	protected, err := service.EnvironmentProtected(rancherID, projectID)
+	if err != nil {
+		saveAuditEvent(event, model.AuditError, fmt.Sprintf("check of protected environment failed: %s", err))
+		s.postError(ev, "Error on check if the environment is protected", err)
+		return true
+	}
+
+	if !protected {
+		return false
+	}
+
+	// slash commands podem vir de canais e DMs onde o BOT não está, o pedido vai para o canal do BOT
+	channel := ev.Channel
+	if s.responseURL != "" {
+		channel = s.channelID
+	}
+
+	request := model.ApprovalRequest{
+		Command:   cmd.Cmd,
+		Text:      ev.Msg.Text,
+		User:      ev.User,
+		RancherID: rancherID,
+		ProjectID: projectID,
+		Channel:   channel,
+		ExpiresAt: time.Now().Add(approvalTimeout),
+	}
+
+	if err := service.OpenApprovalRequest(&request); err != nil {
+		saveAuditEvent(event, model.AuditError, fmt.Sprintf("approval request failed: %s", err))
+		s.postError(ev, "Error on create the approval request", err)
+		return true
+	}
+
+	saveAuditEvent(event, model.AuditPendingApproval, fmt.Sprintf("approval request %d", request.ID))
+
+	_, ts, err := s.client.PostMessage(channel, slack.MsgOptionText(s.approvalText(request), false), slack.MsgOptionAttachments(approvalAttachment(request)))
+	if err != nil {
+		s.postError(ev, "Error on post the approval request", err)
+		return true
+	}
+
+	request.MessageTS = ts
+	CheckErr(fmt.Sprintf("Error on save approval request %d", request.ID), service.SaveApprovalRequest(&request))
+
+	if s.responseURL != "" || s.output != nil {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("The environment is protected, approval request `%d` posted on <#%s>", request.ID, channel), false))
+	}
+
+	return true
+}
+
+// approvalText é o texto da mensagem do pedido de aprovação
+func (s *SlackListener) approvalText(request model.ApprovalRequest) string {
+	command := strings.TrimSpace(strings.TrimPrefix(request.Text, fmt.Sprintf("<@%s>", s.botID)))
+
+	return fmt.Sprintf(":lock: %s wants to run `%s` on %s, which is protected. Another authorized user must approve it", mentionUser(request.User), command, scopeName(request.RancherID, request.ProjectID))
+}
+
+// approvalAttachment é o rodapé da mensagem do pedido, com o status e os
+// botões enquanto ele está pendente
+func approvalAttachment(request model.ApprovalRequest) slack.Attachment {
+	attachment := slack.Attachment{
+		Text:       approvalStatusText(request),
+		CallbackID: approvalCallbackID,
+	}
+
+	ID := strconv.Itoa(int(request.ID))
+
+	switch request.Status {
+	case model.ApprovalPending:
+		attachment.Color = "warning"
+		attachment.Actions = []slack.AttachmentAction{
+			{
+				Name:  actionApprove,
+				Text:  "Approve",
+				Type:  "button",
+				Style: "primary",
+				Value: ID,
+				Confirm: &slack.ConfirmationField{
+					Title:       "Are you sure?",
+					Text:        fmt.Sprintf("`%s` will be executed as %s", request.Command, mentionUser(request.User)),
+					OkText:      "Approve",
+					DismissText: "No",
+				},
+			},
+			{Name: actionReject, Text: "Reject", Type: "button", Style: "danger", Value: ID},
+		}
+	case model.ApprovalApproved:
+		attachment.Color = "good"
+	case model.ApprovalRejected:
+		attachment.Color = "danger"
+	}
+
+	return attachment
+}
+
+func approvalStatusText(request model.ApprovalRequest) string {
+	text := fmt.Sprintf("Request `%d` %s", request.ID, request.Status)
+
+	switch request.Status {
+	case model.ApprovalPending:
+		text += fmt.Sprintf(" | Expires at %s", request.ExpiresAt.Format("2006-01-02 15:04:05"))
+	case model.ApprovalApproved, model.ApprovalRejected:
+		if request.DecidedAt != nil {
+			text += fmt.Sprintf(" by <@%s> at %s", request.DecidedBy, request.DecidedAt.Format("2006-01-02 15:04:05"))
+		}
+	case model.ApprovalExpired:
+		text += fmt.Sprintf(" at %s", request.ExpiresAt.Format("2006-01-02 15:04:05"))
+	}
+
+	return text
+}
+
+// handleApprovalAction trata os cliques nos botões de approve e reject dos
+// pedidos de aprovação. Só aprova quem pode executar o comando e não é quem
+// pediu, que ainda pode rejeitar para cancelar o pedido
+func (s *SlackListener) handleApprovalAction(c *gin.Context, callback slack.InteractionCallback) {
+	if len(callback.ActionCallback.AttachmentActions) == 0 {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	action := callback.ActionCallback.AttachmentActions[0]
+
+	ID, err := strconv.ParseUint(action.Value, 10, 32)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	request, err := service.FindApprovalRequest(uint(ID))
+	if err != nil {
+		approvalActionError(c, err)
+		return
+	}
+
+	user := callback.User.ID
+
+	if action.Name == actionApprove || user != request.User {
+		allowed, err := s.commandAllowed(request.Command, user, request.RancherID, request.ProjectID)
+		if err != nil {
+			approvalActionError(c, err)
+			return
+		}
+
+		if !allowed {
+			event := model.AuditEvent{
+				User:      user,
+				Channel:   request.Channel,
+				Command:   request.Command,
+				Text:      request.Text,
+				RancherID: request.RancherID,
+				ProjectID: request.ProjectID,
+			}
+			saveAuditEvent(&event, model.AuditDenied, fmt.Sprintf("%s of approval request %d without a role with `%s`", action.Name, request.ID, request.Command))
+
+			approvalActionError(c, fmt.Errorf("you are not allowed to %s `%s` on %s", action.Name, request.Command, scopeName(request.RancherID, request.ProjectID)))
+			return
+		}
+	}
+
+	now := time.Now()
+	var reply string
+
+	switch action.Name {
+	case actionApprove:
+		request, err = service.ApproveRequest(request.ID, user, now)
+		reply = fmt.Sprintf(":white_check_mark: <@%s> approved the request, running `%s`", user, request.Command)
+	case actionReject:
+		request, err = service.RejectRequest(request.ID, user, now)
+		reply = fmt.Sprintf(":no_entry: <@%s> rejected the request", user)
+	default:
+		log.Printf("[ERROR] Invalid action: %s", action.Name)
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		if request.Status == model.ApprovalExpired {
+			s.closeApprovalMessage(request, ":hourglass: The request expired without approval")
+		}
+		approvalActionError(c, err)
+		return
+	}
+
+	s.closeApprovalMessage(request, reply)
+
+	if request.Status == model.ApprovalApproved {
+		go s.executeApprovalRequest(request)
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func approvalActionError(c *gin.Context, err error) {
+	c.JSON(http.StatusOK, gin.H{
+		"response_type":    "ephemeral",
+		"replace_original": false,
+		"text":             err.Error(),
+	})
+}
+
+// closeApprovalMessage atualiza a mensagem do pedido com o status, sem os
+// botões, e avisa na thread
+func (s *SlackListener) closeApprovalMessage(request model.ApprovalRequest, reply string) {
+	if request.MessageTS == "" {
+		return
+	}
+
+	_, _, _, err := s.client.UpdateMessage(request.Channel, request.MessageTS, slack.MsgOptionText(s.approvalText(request), false), slack.MsgOptionAttachments(approvalAttachment(request)))
+	CheckErr(fmt.Sprintf("Error on update approval request %d", request.ID), err)
+
+	s.client.PostMessage(request.Channel, slack.MsgOptionText(reply, false), slack.MsgOptionTS(request.MessageTS))
+}
+
+// executeApprovalRequest executa o comando aprovado como quem o pediu,
+// respondendo na thread do pedido
+func (s *SlackListener) executeApprovalRequest(request model.ApprovalRequest) {
+	slackEventsMutex.Lock()
+	defer slackEventsMutex.Unlock()
+
+	ev := &slack.MessageEvent{}
+	ev.Type = "message"
+	ev.Channel = request.Channel
+	ev.User = request.User
+	ev.Text = request.Text
+	ev.ThreadTimestamp = request.MessageTS
+
+	listener := *s
+	listener.responseURL = ""
+	listener.responseType = ""
+
+	cmd := findCommand(request.Command)
+	if cmd == nil {
+		listener.reply(ev, slack.MsgOptionText(fmt.Sprintf("Command `%s` not found, nothing was executed", request.Command), false))
+		return
+	}
+
+	// o comando roda no Rancher e environment do pedido, mesmo que quem pediu
+	// tenha selecionado outros depois
+	ctx, err := newCommandContext(request.RancherID, request.ProjectID)
+	if err != nil {
+		listener.postError(ev, "Error on select the Rancher of the request, nothing was executed", err)
+		return
+	}
+	ctx.source = fmt.Sprintf("approval request %d", request.ID)
+
+	event := newAuditEvent(ev, *cmd)
+	event.RancherID, event.ProjectID = ctx.rancherID, ctx.projectID
+	event.Detail = fmt.Sprintf("approval request %d approved by %s", request.ID, request.DecidedBy)
+
+	args, err := parseCommandArgs(*cmd, request.Text)
+	if err != nil {
+		saveAuditEvent(&event, model.AuditInvalid, err.Error())
+		listener.reply(ev, slack.MsgOptionText(fmt.Sprintf("Command call error: %s", err), false))
+		return
+	}
+
+	setAuditArgs(&event, *cmd, args)
+	listener.withContext(ctx).runCommand(ev, *cmd, args, &event)
+
+	now := time.Now()
+	request.ExecutedAt = &now
+	CheckErr(fmt.Sprintf("Error on save approval request %d", request.ID), service.SaveApprovalRequest(&request))
+}
+
+// expireApprovalRequests expira os pedidos que ninguém decidiu a tempo
+func (s *SlackListener) expireApprovalRequests() {
+	expired, err := service.ExpireApprovalRequests(time.Now())
+	CheckErr("Error on expire approval requests", err)
+
+	for _, request := range expired {
+		s.closeApprovalMessage(request, fmt.Sprintf(":hourglass: %s, the request expired without approval", mentionUser(request.User)))
+	}
+}
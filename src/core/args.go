@@ -0,0 +1,313 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ArgType é o tipo de um argumento ou flag de comando, validado antes do handler ser chamado
+type ArgType string
+
+const (
+	// ArgString é qualquer texto, entre aspas quando tiver espaços
+	ArgString ArgType = "string"
+
+	// ArgID é um ID numérico do banco (task, silence, rollout)
+	ArgID ArgType = "id"
+
+	// ArgInt é um número inteiro
+	ArgInt ArgType = "int"
+
+	// ArgService é um serviço do Rancher no formato stackName/serviceName
+	ArgService ArgType = "service"
+
+	// ArgChannel é o ID de um canal do Slack (ex.: GHHG3S9L4) ou a menção #canal
+	ArgChannel ArgType = "channel"
+
+	// ArgUser é o ID de um usuário do Slack (ex.: U0123ABCD) ou a menção @usuario
+	ArgUser ArgType = "user"
+
+	// ArgBool é true ou false
+	ArgBool ArgType = "bool"
+
+	// ArgDuration é uma duração como 30m ou 2h
+	ArgDuration ArgType = "duration"
+
+	// ArgKeyword é a própria palavra Name, como o `for` de `silence 12 for 2h`
+	ArgKeyword ArgType = "keyword"
+
+	// ArgCommand é um programa com os seus argumentos, depois do `--`
+	// (ex.: `-- ls -la /tmp`). Precisa de Rest e é lido com Argv
+	ArgCommand ArgType = "command"
+)
+
+// Arg é um argumento posicional de um comando
+type Arg struct {
+	Name        string  `json:"name"`
+	Type        ArgType `json:"type"`
+	Description string  `json:"description"`
+	Optional    bool    `json:"optional"`
+
+	// Rest junta o argumento com todos os seguintes, como o motivo de um silence
+	Rest bool `json:"rest"`
+}
+
+// Flag é um argumento opcional `--name value` ou `--name=value` de um comando
+type Flag struct {
+	Name        string  `json:"name"`
+	Type        ArgType `json:"type"`
+	Description string  `json:"description"`
+
+	// Example é o valor mostrado no uso do comando
+	Example string `json:"example"`
+}
+
+// CommandArgs são os argumentos e flags de um comando já validados
+type CommandArgs struct {
+	values map[string]string
+}
+
+// channelMention é como o Slack envia um canal mencionado (<#C0123|nome>)
+var channelMention = regexp.MustCompile(`^<#([A-Z0-9]+)(\|[^>]*)?>$`)
+
+// userMention é como o Slack envia um usuário mencionado (<@U0123> ou <@U0123|nome>)
+var userMention = regexp.MustCompile(`^<@([A-Z0-9]+)(\|[^>]*)?>$`)
+
+// parseCommandArgs valida o texto de uma mensagem contra os argumentos e
+// flags do comando. Textos entre aspas (retas ou curvas) são um único argumento
+func parseCommandArgs(cmd Command, text string) (CommandArgs, error) {
+	args := CommandArgs{values: map[string]string{}}
+
+	positional, flags := parseFlags(splitArgs(text))
+
+	// a menção ao BOT e o nome do comando
+	if len(positional) >= 2 {
+		positional = positional[2:]
+	} else {
+		positional = nil
+	}
+
+	for name, value := range flags {
+		flag := cmd.findFlag(name)
+		if flag == nil {
+			return args, fmt.Errorf("unknown flag `--%s`", name)
+		}
+
+		if value == "" {
+			return args, fmt.Errorf("flag `--%s` needs a value", name)
+		}
+
+		normalized, err := parseArgValue(flag.Type, name, value)
+		if err != nil {
+			return args, err
+		}
+		args.values[name] = normalized
+	}
+
+	i := 0
+	for _, arg := range cmd.Args {
+		if i >= len(positional) {
+			if !arg.Optional {
+				return args, fmt.Errorf("missing `%s`", arg.Name)
+			}
+			continue
+		}
+
+		value := positional[i]
+		i++
+
+		if arg.Rest {
+			value = strings.Join(positional[i-1:], " ")
+			if arg.Type == ArgCommand {
+				// os argumentos do programa são guardados separados, os
+				// trechos entre aspas podem ter espaços
+				b, _ := json.Marshal(positional[i-1:])
+				value = string(b)
+			}
+			i = len(positional)
+		}
+
+		normalized, err := parseArgValue(arg.Type, arg.Name, value)
+		if err != nil {
+			return args, err
+		}
+		args.values[arg.Name] = normalized
+	}
+
+	if i < len(positional) {
+		return args, fmt.Errorf("unexpected `%s`", strings.Join(positional[i:], " "))
+	}
+
+	return args, nil
+}
+
+// parseArgValue valida um valor pelo tipo e o devolve normalizado
+func parseArgValue(argType ArgType, name string, value string) (string, error) {
+	switch argType {
+	case ArgID:
+		if ID, err := strconv.ParseUint(value, 10, 32); err != nil || ID == 0 {
+			return "", fmt.Errorf("`%s` is not a valid %s, use a number", value, name)
+		}
+	case ArgInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("`%s` is not a valid %s, use a number", value, name)
+		}
+	case ArgService:
+		parts := strings.Split(value, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return "", fmt.Errorf("`%s` is not a valid %s, use stackName/serviceName", value, name)
+		}
+	case ArgChannel:
+		if match := channelMention.FindStringSubmatch(value); match != nil {
+			return match[1], nil
+		}
+		if strings.ContainsAny(value, " <>#") {
+			return "", fmt.Errorf("`%s` is not a valid %s, use the channel ID (ex.: GHHG3S9L4) or #channel", value, name)
+		}
+	case ArgUser:
+		if match := userMention.FindStringSubmatch(value); match != nil {
+			return match[1], nil
+		}
+		if strings.ContainsAny(value, " <>@") {
+			return "", fmt.Errorf("`%s` is not a valid %s, use the user ID (ex.: U0123ABCD) or @user", value, name)
+		}
+	case ArgBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("`%s` is not a valid %s, use true or false", value, name)
+		}
+		return strconv.FormatBool(b), nil
+	case ArgDuration:
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return "", fmt.Errorf("`%s` is not a valid %s, use a duration like `30m` or `2h`", value, name)
+		}
+	case ArgKeyword:
+		if value != name {
+			return "", fmt.Errorf("expected `%s`, got `%s`", name, value)
+		}
+	}
+
+	return value, nil
+}
+
+// Has verifica se o argumento ou flag foi informado
+func (a CommandArgs) Has(name string) bool {
+	_, ok := a.values[name]
+	return ok
+}
+
+// String retorna o valor do argumento ou flag, vazio se não foi informado
+func (a CommandArgs) String(name string) string {
+	return a.values[name]
+}
+
+// ID retorna um argumento ArgID, 0 se não foi informado
+func (a CommandArgs) ID(name string) uint {
+	ID, _ := strconv.ParseUint(a.values[name], 10, 32)
+	return uint(ID)
+}
+
+// Int retorna um argumento ArgInt, 0 se não foi informado
+func (a CommandArgs) Int(name string) int {
+	value, _ := strconv.Atoi(a.values[name])
+	return value
+}
+
+// Bool retorna um argumento ArgBool, false se não foi informado
+func (a CommandArgs) Bool(name string) bool {
+	return a.values[name] == "true"
+}
+
+// Duration retorna um argumento ArgDuration, 0 se não foi informado
+func (a CommandArgs) Duration(name string) time.Duration {
+	d, _ := time.ParseDuration(a.values[name])
+	return d
+}
+
+// Argv retorna o programa e os argumentos de um ArgCommand, nil se não foi informado
+func (a CommandArgs) Argv(name string) []string {
+	var argv []string
+	json.Unmarshal([]byte(a.values[name]), &argv)
+	return argv
+}
+
+// Service retorna a stack e o serviço de um argumento ArgService
+func (a CommandArgs) Service(name string) (stackName string, serviceName string) {
+	parts := strings.SplitN(a.values[name], "/", 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+
+	return parts[0], parts[1]
+}
+
+// findFlag procura a flag entre as do comando e as contextFlags, aceitas por todos
+func (cmd Command) findFlag(name string) *Flag {
+	for i := range cmd.Flags {
+		if cmd.Flags[i].Name == name {
+			return &cmd.Flags[i]
+		}
+	}
+
+	for i := range contextFlags {
+		if contextFlags[i].Name == name {
+			return &contextFlags[i]
+		}
+	}
+
+	return nil
+}
+
+// usage monta o uso do comando a partir dos argumentos e flags
+func (cmd Command) usage() string {
+	usage := fmt.Sprintf("@jeremias %s", cmd.Cmd)
+
+	for _, arg := range cmd.Args {
+		switch {
+		case arg.Type == ArgKeyword:
+			usage += fmt.Sprintf(" %s", arg.Name)
+		case arg.Type == ArgCommand:
+			usage += fmt.Sprintf(" -- `%s`", arg.Name)
+		case arg.Optional:
+			usage += fmt.Sprintf(" `%s (optional)`", arg.Name)
+		default:
+			usage += fmt.Sprintf(" `%s`", arg.Name)
+		}
+	}
+
+	for _, flag := range cmd.Flags {
+		usage += fmt.Sprintf(" `--%s %s (optional)`", flag.Name, flag.Example)
+	}
+
+	return usage
+}
+
+// lint monta a explicação dos argumentos e flags, seguida do Lint do comando
+func (cmd Command) lint() string {
+	var lines []string
+
+	for _, arg := range cmd.Args {
+		if arg.Description != "" {
+			lines = append(lines, fmt.Sprintf("`%s` %s", arg.Name, arg.Description))
+		}
+	}
+
+	for _, flag := range cmd.Flags {
+		if flag.Description != "" {
+			lines = append(lines, fmt.Sprintf("`--%s` %s", flag.Name, flag.Description))
+		}
+	}
+
+	if cmd.Lint != "" {
+		lines = append(lines, cmd.Lint)
+	}
+
+	return strings.Join(lines, " | ")
+}
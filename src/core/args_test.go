@@ -0,0 +1,80 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testScaleCommand = Command{
+	Cmd: scaleService,
+	Args: []Arg{
+		{Name: "stackName/serviceName", Type: ArgService},
+		{Name: "scale", Type: ArgString},
+	},
+	Flags: []Flag{
+		{Name: "for", Type: ArgDuration},
+	},
+}
+
+var testExecCommand = Command{
+	Cmd: execContainer,
+	Args: []Arg{
+		{Name: "container-id", Type: ArgString},
+		{Name: "command", Type: ArgCommand, Rest: true},
+	},
+}
+
+func TestParseCommandArgs(t *testing.T) {
+	tests := []struct {
+		cmd  Command
+		text string
+		want map[string]string
+		err  bool
+	}{
+		{testScaleCommand, `<@BOT> service-scale web/api 3`, map[string]string{"stackName/serviceName": "web/api", "scale": "3"}, false},
+		{testScaleCommand, `<@BOT> service-scale web/api -1`, map[string]string{"stackName/serviceName": "web/api", "scale": "-1"}, false},
+		{testScaleCommand, `<@BOT> service-scale web/api +2 --for 2h`, map[string]string{"stackName/serviceName": "web/api", "scale": "+2", "for": "2h"}, false},
+		{testScaleCommand, `<@BOT> service-scale --for=30m web/api -1`, map[string]string{"stackName/serviceName": "web/api", "scale": "-1", "for": "30m"}, false},
+		{testScaleCommand, `<@BOT> service-scale "web/api" “5”`, map[string]string{"stackName/serviceName": "web/api", "scale": "5"}, false},
+		{testScaleCommand, `<@BOT> service-scale web/api`, nil, true},
+		{testScaleCommand, `<@BOT> service-scale web 3`, nil, true},
+		{testScaleCommand, `<@BOT> service-scale web/api 3 4`, nil, true},
+		{testScaleCommand, `<@BOT> service-scale web/api 3 --for`, nil, true},
+		{testScaleCommand, `<@BOT> service-scale web/api 3 --for 0s`, nil, true},
+		{testScaleCommand, `<@BOT> service-scale web/api 3 --until 2h`, nil, true},
+		{testExecCommand, `<@BOT> container-exec 1i2 -- ls -la /tmp`, map[string]string{"container-id": "1i2", "command": `["ls","-la","/tmp"]`}, false},
+		{testExecCommand, `<@BOT> container-exec 1i2 -- sh -c "echo a  b" --all`, map[string]string{"container-id": "1i2", "command": `["sh","-c","echo a  b","--all"]`}, false},
+		{testExecCommand, `<@BOT> container-exec 1i2 -- grep ""`, map[string]string{"container-id": "1i2", "command": `["grep",""]`}, false},
+	}
+
+	for _, test := range tests {
+		args, err := parseCommandArgs(test.cmd, test.text)
+		if test.err {
+			if err == nil {
+				t.Errorf("parseCommandArgs(%q) = %v, want an error", test.text, args.values)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parseCommandArgs(%q) returned %s", test.text, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(args.values, test.want) {
+			t.Errorf("parseCommandArgs(%q) = %v, want %v", test.text, args.values, test.want)
+		}
+	}
+}
+
+func TestCommandArgsArgv(t *testing.T) {
+	args, err := parseCommandArgs(testExecCommand, `<@BOT> container-exec 1i2 -- sh -c 'echo "a b"'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"sh", "-c", `echo "a b"`}
+	if got := args.Argv("command"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Argv = %q, want %q", got, want)
+	}
+}
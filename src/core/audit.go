@@ -0,0 +1,276 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+const (
+	// defaultAuditLimit é quantos eventos o audit mostra sem o --limit
+	defaultAuditLimit = 20
+
+	// maxAuditLimit é o máximo de eventos do audit, para caber em uma mensagem
+	maxAuditLimit = 100
+)
+
+const (
+	// auditSystemMonitor é o autor no audit das recuperações de containers das tasks
+	auditSystemMonitor = "system:monitor"
+
+	// auditSystemRollout é o autor no audit dos passos e rollbacks dos canary rollouts
+	auditSystemRollout = "system:rollout"
+
+	// auditSystemScale é o autor no audit das voltas dos scales com --for
+	auditSystemScale = "system:scale"
+
+	// auditSystemUpgrade é o autor no audit dos upgrades terminados pelo --auto-finish-after
+	auditSystemUpgrade = "system:upgrade"
+
+	// taskRecovery é o comando no audit dos restarts e remoções de containers das tasks
+	taskRecovery = "task-recovery"
+)
+
+// auditRecorder guarda as requisições à API e o erro de um comando durante a execução
+type auditRecorder struct {
+	requests []model.AuditRequest
+	err      error
+
+	// denied é quando o próprio handler recusou o comando, como um programa
+	// fora da exec policy do environment
+	denied bool
+
+	// detail é guardado no evento de um comando sem erros
+	detail string
+
+	// background é a parte do comando que continua fora do slackEventsMutex,
+	// o evento é gravado quando ela termina
+	background func()
+}
+
+func (r *auditRecorder) record(method string, url string, status int) {
+	r.requests = append(r.requests, model.AuditRequest{Method: method, URL: url, Status: status})
+}
+
+func (r *auditRecorder) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *auditRecorder) deny(err error) {
+	if r.err == nil {
+		r.err = err
+		r.denied = true
+	}
+}
+
+// newAuditEvent cria o evento de auditoria de um comando, o Rancher e o
+// environment são preenchidos depois do resolveContext
+func newAuditEvent(ev *slack.MessageEvent, cmd Command) model.AuditEvent {
+	return model.AuditEvent{
+		User:    ev.User,
+		Channel: ev.Channel,
+		Command: cmd.Cmd,
+		Text:    ev.Msg.Text,
+	}
+}
+
+// setAuditArgs guarda os argumentos validados. O recurso alvo é o primeiro
+// argumento do comando (lb-id, service-id, container-id, stack/service, ...)
+func setAuditArgs(event *model.AuditEvent, cmd Command, args CommandArgs) {
+	if b, err := json.Marshal(args.values); err == nil {
+		event.Args = string(b)
+	}
+
+	for _, arg := range cmd.Args {
+		if arg.Type != ArgKeyword && args.Has(arg.Name) {
+			event.Resource = args.String(arg.Name)
+			break
+		}
+	}
+}
+
+// saveAuditEvent grava o evento com o resultado, juntando o detalhe aos que o
+// evento já tiver (ex.: quem aprovou o comando)
+func saveAuditEvent(event *model.AuditEvent, outcome string, detail string) {
+	event.Outcome = outcome
+
+	if detail != "" && event.Detail != "" {
+		event.Detail += " | " + detail
+	} else if detail != "" {
+		event.Detail = detail
+	}
+
+	CheckErr(fmt.Sprintf("Error on save audit event of `%s`", event.Command), service.RecordAuditEvent(event))
+}
+
+// recordSystemAudit grava no audit uma alteração feita pelo BOT em background,
+// sem um usuário chamando um comando. O autor é o processo (system:monitor,
+// system:rollout, ...) e o detalhe diz o motivo e quem a agendou
+func recordSystemAudit(actor string, event model.AuditEvent, err error, detail string) {
+	event.User = actor
+
+	if err != nil {
+		saveAuditEvent(&event, model.AuditError, fmt.Sprintf("%s: %s", detail, err))
+		return
+	}
+
+	saveAuditEvent(&event, model.AuditSuccess, detail)
+}
+
+// runCommand executa o comando com os argumentos já validados, auditando as
+// requisições feitas à API e o resultado
+func (s *SlackListener) runCommand(ev *slack.MessageEvent, cmd Command, args CommandArgs, event *model.AuditEvent) {
+	recorder := &auditRecorder{}
+
+	listener := *s
+	listener.audit = recorder
+
+	listener.orchestrator.SetResponseHook(recorder.record)
+	defer listener.orchestrator.SetResponseHook(nil)
+
+	if !cmd.Global {
+		listener.reply(ev, slack.MsgOptionText(fmt.Sprintf("_Running on %s (%s)_", listener.context, listener.context.source), false))
+	}
+
+	cmd.Handler(&listener, ev, args)
+
+	work := recorder.background
+	if work == nil {
+		saveCommandAudit(event, recorder)
+		return
+	}
+
+	// a API espera o fim do comando para montar o resultado, depois de
+	// liberar o slackEventsMutex
+	if s.output != nil {
+		s.output.pending.Add(1)
+	}
+
+	go func() {
+		work()
+		saveCommandAudit(event, recorder)
+
+		if s.output != nil {
+			s.output.pending.Done()
+		}
+	}()
+}
+
+// saveCommandAudit grava o evento de um comando com as requisições feitas à
+// API e o resultado
+func saveCommandAudit(event *model.AuditEvent, recorder *auditRecorder) {
+	if b, err := json.Marshal(recorder.requests); err == nil && len(recorder.requests) > 0 {
+		event.Requests = string(b)
+	}
+
+	// o status da última requisição que alterou algo, ou da última consulta
+	for _, request := range recorder.requests {
+		if event.Status == 0 || request.Method != http.MethodGet {
+			event.Status = request.Status
+		}
+		if request.Method != http.MethodGet && (request.Status == 0 || request.Status >= 300) {
+			recorder.fail(fmt.Errorf("%s %s returned %d", request.Method, request.URL, request.Status))
+		}
+	}
+
+	if recorder.err != nil && recorder.denied {
+		saveAuditEvent(event, model.AuditDenied, recorder.err.Error())
+		return
+	}
+
+	if recorder.err != nil {
+		saveAuditEvent(event, model.AuditError, recorder.err.Error())
+		return
+	}
+
+	saveAuditEvent(event, model.AuditSuccess, recorder.detail)
+}
+
+// background continua o comando em uma goroutine, fora do slackEventsMutex,
+// para um comando demorado não travar os outros
+func (s *SlackListener) background(work func()) {
+	if s.audit == nil {
+		go work()
+		return
+	}
+
+	s.audit.background = work
+}
+
+// denyCommand recusa o comando por uma regra do environment, ele fica no
+// audit como denied
+func (s *SlackListener) denyCommand(ev *slack.MessageEvent, msg string) {
+	if s.audit != nil {
+		s.audit.deny(fmt.Errorf("%s", msg))
+	}
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf(":no_entry: %s", msg), false))
+}
+
+func (s *SlackListener) slackAudit(ev *slack.MessageEvent, args CommandArgs) {
+	// o audit mostra os comandos de todos os Ranchers
+	if !s.authorizeGlobal(ev, auditCommand) {
+		return
+	}
+
+	filter := model.AuditFilter{
+		User:     args.String("user"),
+		Resource: args.String("resource"),
+		Command:  args.String("command"),
+		Outcome:  args.String("outcome"),
+		Limit:    defaultAuditLimit,
+	}
+
+	if args.Has("since") {
+		filter.Since = time.Now().Add(-args.Duration("since"))
+	}
+
+	if args.Has("limit") {
+		filter.Limit = args.Int("limit")
+	}
+
+	if filter.Limit <= 0 || filter.Limit > maxAuditLimit {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("The limit must be between 1 and %d, use the REST API `/v1/audit` to export more events", maxAuditLimit), false))
+		return
+	}
+
+	events, err := service.ListAuditEvents(filter)
+	if err != nil {
+		s.postError(ev, "Error on list audit events", err)
+		return
+	}
+
+	if len(events) == 0 {
+		s.reply(ev, slack.MsgOptionText("No audit events.", false))
+		return
+	}
+
+	msg := "*Audit events:*"
+	for _, event := range events {
+		command := strings.TrimSpace(strings.TrimPrefix(event.Text, fmt.Sprintf("<@%s>", s.botID)))
+
+		msg += fmt.Sprintf("\n`%s` <@%s> `%s` | %s", event.CreatedAt.Format("2006-01-02 15:04:05"), event.User, command, event.Outcome)
+		if event.ProjectID != "" {
+			msg += fmt.Sprintf(" | environment `%s`", event.ProjectID)
+		}
+		if event.Status != 0 {
+			msg += fmt.Sprintf(" | API status %d", event.Status)
+		}
+		if event.Detail != "" {
+			msg += fmt.Sprintf(" | _%s_", event.Detail)
+		}
+	}
+
+	s.reply(ev, slack.MsgOptionText(msg, false))
+}
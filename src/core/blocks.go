@@ -0,0 +1,603 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+const (
+	// pickerSelectAction e pickerCancelAction são os action_id do select e do
+	// botão de cancelar das mensagens com picker
+	pickerSelectAction = "select"
+	pickerCancelAction = "cancel"
+
+	// pickerMaxOptions é o máximo de opções de um select do Block Kit, com
+	// mais opções o picker usa um external select, carregado em /slack/options
+	pickerMaxOptions = 100
+
+	// pickerOptionLength é o tamanho máximo do texto de uma opção
+	pickerOptionLength = 75
+
+	// upgradeModalCallbackID é o callback do modal que pede a nova imagem do service-upgrade
+	upgradeModalCallbackID = "service-upgrade"
+	upgradeImageBlockID    = "image"
+	upgradeImageAction     = "new-image"
+
+	interactionTypeViewSubmission  = slack.InteractionType("view_submission")
+	interactionTypeBlockSuggestion = slack.InteractionType("block_suggestion")
+)
+
+// pickerSource lista as opções do picker de um comando, no contexto do listener
+type pickerSource func(s *SlackListener) ([]*slack.OptionBlockObject, error)
+
+// pickerSources são os comandos que, sem o argumento, mostram um picker. O
+// valor escolhido vira o primeiro argumento do comando
+var pickerSources = map[string]pickerSource{
+	getServiceInfo:   (*SlackListener).serviceOptions,
+	upgradeService:   (*SlackListener).serviceOptions,
+	restartContainer: (*SlackListener).containerOptions,
+	logsContainer:    (*SlackListener).containerOptions,
+	canaryActivate:   (*SlackListener).lbOptions,
+	canaryDisable:    (*SlackListener).lbOptions,
+	canaryInfo:       (*SlackListener).lbOptions,
+}
+
+// opensPicker verifica se o comando foi chamado sem o argumento escolhido no picker
+func opensPicker(cmd Command, args CommandArgs) bool {
+	if _, ok := pickerSources[cmd.Cmd]; !ok || len(cmd.Args) == 0 {
+		return false
+	}
+
+	return !args.Has(cmd.Args[0].Name)
+}
+
+// picker é a mensagem com o select das opções de um comando
+type picker struct {
+	command string
+	text    string
+
+	// confirm é a confirmação mostrada antes de executar o comando escolhido
+	confirm *slack.ConfirmationBlockObject
+}
+
+// pickerRef identifica o comando e o contexto de um picker. Vai no block_id
+// da mensagem, para o comando escolhido rodar no Rancher e environment em que
+// as opções foram listadas
+type pickerRef struct {
+	Command   string `json:"command"`
+	RancherID uint   `json:"rancherId"`
+	ProjectID string `json:"projectId"`
+}
+
+func (r pickerRef) String() string {
+	return fmt.Sprintf("%s|%d|%s", r.Command, r.RancherID, r.ProjectID)
+}
+
+func parsePickerRef(blockID string) (pickerRef, error) {
+	parts := strings.SplitN(blockID, "|", 3)
+	if len(parts) != 3 {
+		return pickerRef{}, fmt.Errorf("invalid block %s", blockID)
+	}
+
+	rancherID, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return pickerRef{}, fmt.Errorf("invalid block %s", blockID)
+	}
+
+	if _, ok := pickerSources[parts[0]]; !ok {
+		return pickerRef{}, fmt.Errorf("command %s has no picker", parts[0])
+	}
+
+	return pickerRef{Command: parts[0], RancherID: uint(rancherID), ProjectID: parts[2]}, nil
+}
+
+// pickerOrigin é para onde vão as respostas do comando escolhido: o canal e a
+// thread do picker ou, se ele era efêmero, o response_url
+type pickerOrigin struct {
+	User        string `json:"user"`
+	Channel     string `json:"channel"`
+	ThreadTS    string `json:"threadTs,omitempty"`
+	ResponseURL string `json:"responseUrl,omitempty"`
+	Ephemeral   bool   `json:"ephemeral,omitempty"`
+}
+
+// upgradeModalMetadata é o private_metadata do modal do service-upgrade
+type upgradeModalMetadata struct {
+	pickerRef
+	pickerOrigin
+	ServiceID string `json:"serviceId"`
+}
+
+// blockInteraction é o payload das interações com Block Kit, com os campos
+// que o nlopes/slack ainda não conhece (container, view e block_suggestion)
+type blockInteraction struct {
+	slack.InteractionCallback
+
+	Container struct {
+		IsEphemeral bool `json:"is_ephemeral"`
+	} `json:"container"`
+
+	View struct {
+		CallbackID      string `json:"callback_id"`
+		PrivateMetadata string `json:"private_metadata"`
+		State           struct {
+			Values map[string]map[string]struct {
+				Value string `json:"value"`
+			} `json:"values"`
+		} `json:"state"`
+	} `json:"view"`
+
+	// ActionID e BlockID vêm no block_suggestion, com o texto digitado em Value
+	ActionID string `json:"action_id"`
+	BlockID  string `json:"block_id"`
+}
+
+func (callback blockInteraction) origin() pickerOrigin {
+	return pickerOrigin{
+		User:        callback.User.ID,
+		Channel:     callback.Channel.ID,
+		ThreadTS:    callback.Message.ThreadTimestamp,
+		ResponseURL: callback.ResponseURL,
+		Ephemeral:   callback.Container.IsEphemeral,
+	}
+}
+
+// modalView é um modal do Block Kit, aberto com o views.open
+type modalView struct {
+	Type            string                 `json:"type"`
+	CallbackID      string                 `json:"callback_id"`
+	Title           *slack.TextBlockObject `json:"title"`
+	Submit          *slack.TextBlockObject `json:"submit,omitempty"`
+	Close           *slack.TextBlockObject `json:"close,omitempty"`
+	Blocks          []slack.Block          `json:"blocks"`
+	PrivateMetadata string                 `json:"private_metadata,omitempty"`
+}
+
+// inputBlock é o bloco de input dos modais, que o nlopes/slack não tem
+type inputBlock struct {
+	Type    slack.MessageBlockType `json:"type"`
+	BlockID string                 `json:"block_id"`
+	Label   *slack.TextBlockObject `json:"label"`
+	Hint    *slack.TextBlockObject `json:"hint,omitempty"`
+	Element plainTextInput         `json:"element"`
+}
+
+// BlockType : implementação de slack.Block
+func (b inputBlock) BlockType() slack.MessageBlockType {
+	return b.Type
+}
+
+type plainTextInput struct {
+	Type         string `json:"type"`
+	ActionID     string `json:"action_id"`
+	InitialValue string `json:"initial_value,omitempty"`
+}
+
+func plainText(text string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.PlainTextType, text, false, false)
+}
+
+func markdownText(text string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.MarkdownType, text, false, false)
+}
+
+// confirmation é o diálogo de confirmação do Block Kit com Yes e No
+func confirmation(text string) *slack.ConfirmationBlockObject {
+	return slack.NewConfirmationBlockObject(plainText("Are you sure?"), markdownText(text), plainText("Yes"), plainText("No"))
+}
+
+// sendPicker responde com o select das opções do comando e um botão de
+// cancelar. O comando escolhido é executado por quem escolheu, no contexto
+// em que as opções foram listadas
+func (s *SlackListener) sendPicker(ev *slack.MessageEvent, p picker) {
+	options, err := pickerSources[p.command](s)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on list the options of `%s`", p.command), err)
+		return
+	}
+
+	if len(options) == 0 {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Nothing to select on %s", s.context), false))
+		return
+	}
+
+	ref := pickerRef{Command: p.command, RancherID: s.context.rancherID, ProjectID: s.context.projectID}
+
+	var menu *slack.SelectBlockElement
+	if len(options) > pickerMaxOptions {
+		menu = slack.NewOptionsSelectBlockElement(slack.OptTypeExternal, plainText("Type to search"), pickerSelectAction)
+		menu.MinQueryLength = 1
+	} else {
+		menu = slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, plainText("Select"), pickerSelectAction, options...)
+	}
+	menu.Confirm = p.confirm
+
+	cancel := slack.NewButtonBlockElement(pickerCancelAction, "", plainText("Cancel"))
+	cancel.WithStyle(slack.StyleDanger)
+
+	s.reply(ev,
+		slack.MsgOptionText(p.text, false),
+		slack.MsgOptionBlocks(
+			slack.NewSectionBlock(markdownText(p.text), nil, nil),
+			slack.NewActionBlock(ref.String(), menu, cancel),
+		),
+	)
+}
+
+func (s *SlackListener) serviceOptions() ([]*slack.OptionBlockObject, error) {
+	workloads, err := s.orchestrator.ListWorkloads()
+	if err != nil {
+		return nil, err
+	}
+
+	var options []*slack.OptionBlockObject
+	for _, workload := range workloads {
+		options = append(options, pickerOption(workload.ID, fmt.Sprintf("%s/%s", workload.Group, workload.Name)))
+	}
+
+	return options, nil
+}
+
+func (s *SlackListener) containerOptions() ([]*slack.OptionBlockObject, error) {
+	workloads, err := s.orchestrator.ListWorkloads()
+	if err != nil {
+		return nil, err
+	}
+
+	var options []*slack.OptionBlockObject
+	for _, workload := range workloads {
+		instances, err := s.orchestrator.ListInstances(workload.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, instance := range instances {
+			options = append(options, pickerOption(instance.ID, fmt.Sprintf("%s | %s", instance.Name, instance.State)))
+		}
+	}
+
+	return options, nil
+}
+
+func (s *SlackListener) lbOptions() ([]*slack.OptionBlockObject, error) {
+	if s.rancherListener == nil {
+		return nil, fmt.Errorf("Load Balancers are only available for Rancher 1.6")
+	}
+
+	loadBalancers, err := s.rancherListener.GetLoadBalancers()
+	if err != nil {
+		return nil, err
+	}
+
+	var options []*slack.OptionBlockObject
+	for _, lb := range loadBalancers {
+		options = append(options, pickerOption(lb.ID, lb.Name))
+	}
+
+	return options, nil
+}
+
+// pickerOption cria a opção com o ID e o nome, cortando o texto no tamanho
+// máximo do Block Kit
+func pickerOption(ID string, name string) *slack.OptionBlockObject {
+	text := fmt.Sprintf("%s | %s", ID, name)
+	if len(text) > pickerOptionLength {
+		text = text[:pickerOptionLength-3] + "..."
+	}
+
+	return slack.NewOptionBlockObject(ID, plainText(text))
+}
+
+// handleBlockAction recebe os cliques nos selects e botões dos pickers e nos
+// botões do upgrade
+func (s *SlackListener) handleBlockAction(c *gin.Context, callback blockInteraction) {
+	if len(callback.ActionCallback.BlockActions) == 0 {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	action := callback.ActionCallback.BlockActions[0]
+
+	ref, err := parsePickerRef(action.BlockID)
+	if err != nil {
+		log.Printf("[ERROR] Invalid block action: %s", err)
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	user := callback.User.ID
+
+	switch action.ActionID {
+	case pickerCancelAction:
+		closePicker(callback.ResponseURL, fmt.Sprintf(":x: %s canceled the request", mentionUser(user)))
+	case pickerSelectAction:
+		value := action.SelectedOption.Value
+
+		// o service-upgrade ainda precisa da nova imagem, pedida em um modal
+		if ref.Command == upgradeService {
+			if err := s.openUpgradeModal(callback, ref, value); err != nil {
+				log.Printf("[ERROR] Failed to open the upgrade modal of %s: %s", value, err)
+				closePicker(callback.ResponseURL, fmt.Sprintf(":warning: Error on open the upgrade of `%s`: %s", value, err))
+			}
+			break
+		}
+
+		closePicker(callback.ResponseURL, fmt.Sprintf(":white_check_mark: %s selected `%s`, running `%s`", mentionUser(user), value, ref.Command))
+		go s.runPickedCommand(callback.origin(), ref, value)
+	case finishUpgrade, rollbackUpgrade:
+		// os botões do upgrade, o action_id é o comando executado no serviço
+		ref.Command = action.ActionID
+
+		// os botões só saem quando o comando funciona, negado ou com erro eles
+		// continuam para outra tentativa ou para outro usuário
+		text := fmt.Sprintf("%s\n:point_right: %s clicked %s, `%s` done", callback.Message.Text, mentionUser(user), action.Text.Text, ref.Command)
+		go func() {
+			if s.runPickedCommand(callback.origin(), ref, action.Value) == model.AuditSuccess {
+				closePicker(callback.ResponseURL, text)
+			}
+		}()
+	default:
+		log.Printf("[ERROR] Invalid action: %s", action.ActionID)
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// handleViewSubmission recebe o envio dos modais
+func (s *SlackListener) handleViewSubmission(c *gin.Context, callback blockInteraction) {
+	switch callback.View.CallbackID {
+	case upgradeModalCallbackID:
+		var metadata upgradeModalMetadata
+		if err := json.Unmarshal([]byte(callback.View.PrivateMetadata), &metadata); err != nil {
+			log.Printf("[ERROR] Invalid metadata of the upgrade modal: %s", err)
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		image := strings.TrimSpace(callback.View.State.Values[upgradeImageBlockID][upgradeImageAction].Value)
+		if !strings.HasPrefix(image, "docker:") || strings.ContainsAny(image, " \t") {
+			c.JSON(http.StatusOK, gin.H{
+				"response_action": "errors",
+				"errors": gin.H{
+					upgradeImageBlockID: "Image name needs to start with 'docker:'. Ex.: docker:ubuntu:14.04",
+				},
+			})
+			return
+		}
+
+		// quem envia o modal é quem executa o upgrade
+		metadata.User = callback.User.ID
+
+		closePicker(metadata.ResponseURL, fmt.Sprintf(":white_check_mark: %s selected `%s`, upgrading to `%s`", mentionUser(metadata.User), metadata.ServiceID, image))
+		go s.runPickedCommand(metadata.pickerOrigin, metadata.pickerRef, metadata.ServiceID, image)
+	default:
+		log.Printf("[ERROR] Invalid view: %s", callback.View.CallbackID)
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// handleOptions carrega as opções dos external selects, filtradas pelo que
+// foi digitado
+func (s *SlackListener) handleOptions(c *gin.Context) {
+	var suggestion blockInteraction
+	if err := json.Unmarshal([]byte(c.PostForm("payload")), &suggestion); err != nil {
+		log.Printf("[ERROR] Failed to decode json message from slack: %s", err)
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	if suggestion.Type != interactionTypeBlockSuggestion {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	ref, err := parsePickerRef(suggestion.BlockID)
+	if err != nil {
+		log.Printf("[ERROR] Invalid block suggestion: %s", err)
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	options := []*slack.OptionBlockObject{}
+
+	ctx, err := newCommandContext(ref.RancherID, ref.ProjectID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load the options of %s: %s", ref, err)
+		c.JSON(http.StatusOK, gin.H{"options": options})
+		return
+	}
+
+	all, err := pickerSources[ref.Command](s.withContext(ctx))
+	if err != nil {
+		log.Printf("[ERROR] Failed to load the options of %s: %s", ref, err)
+	}
+
+	query := strings.ToLower(suggestion.Value)
+	for _, option := range all {
+		if len(options) == pickerMaxOptions {
+			break
+		}
+		if strings.Contains(strings.ToLower(option.Text.Text), query) {
+			options = append(options, option)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"options": options})
+}
+
+// openUpgradeModal abre o modal que pede a nova imagem do serviço escolhido
+func (s *SlackListener) openUpgradeModal(callback blockInteraction, ref pickerRef, serviceID string) error {
+	ctx, err := newCommandContext(ref.RancherID, ref.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	workload, err := findWorkload(ctx.orchestrator, serviceID)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := json.Marshal(upgradeModalMetadata{
+		pickerRef:    ref,
+		pickerOrigin: callback.origin(),
+		ServiceID:    serviceID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return openView(callback.TriggerID, modalView{
+		Type:            "modal",
+		CallbackID:      upgradeModalCallbackID,
+		Title:           plainText("Upgrade service"),
+		Submit:          plainText("Upgrade"),
+		Close:           plainText("Cancel"),
+		PrivateMetadata: string(metadata),
+		Blocks: []slack.Block{
+			slack.NewSectionBlock(markdownText(fmt.Sprintf("Service `%s/%s` on %s\nCurrent image: `%s`", workload.Group, workload.Name, ctx, workload.Image)), nil, nil),
+			inputBlock{
+				Type:    "input",
+				BlockID: upgradeImageBlockID,
+				Label:   plainText("New image"),
+				Hint:    plainText("Starting with docker:, ex.: docker:ubuntu:14.04"),
+				Element: plainTextInput{
+					Type:         "plain_text_input",
+					ActionID:     upgradeImageAction,
+					InitialValue: workload.Image,
+				},
+			},
+		},
+	})
+}
+
+// runPickedCommand executa o comando escolhido em um picker como quem
+// escolheu, passando pelas permissões, aprovação e auditoria. Retorna o
+// resultado do comando no audit
+func (s *SlackListener) runPickedCommand(origin pickerOrigin, ref pickerRef, values ...string) string {
+	slackEventsMutex.Lock()
+	defer slackEventsMutex.Unlock()
+
+	ev := &slack.MessageEvent{}
+	ev.Type = "message"
+	ev.Channel = origin.Channel
+	ev.User = origin.User
+	ev.Text = fmt.Sprintf("<@%s> %s %s", s.botID, ref.Command, strings.Join(values, " "))
+	ev.ThreadTimestamp = origin.ThreadTS
+
+	listener := *s
+	listener.responseURL = ""
+	listener.responseType = ""
+	if origin.Ephemeral {
+		listener.responseURL = origin.ResponseURL
+		listener.responseType = slashResponseEphemeral
+	}
+
+	cmd := findCommand(ref.Command)
+	if cmd == nil {
+		listener.reply(ev, slack.MsgOptionText(fmt.Sprintf("Command `%s` not found, nothing was executed", ref.Command), false))
+		return model.AuditInvalid
+	}
+
+	event := newAuditEvent(ev, *cmd)
+
+	args, err := parseCommandArgs(*cmd, ev.Text)
+	if err != nil {
+		saveAuditEvent(&event, model.AuditInvalid, err.Error())
+		listener.reply(ev, slack.MsgOptionText(fmt.Sprintf("Command call error: %s", err), false))
+		return model.AuditInvalid
+	}
+
+	setAuditArgs(&event, *cmd, args)
+
+	ctx, err := newCommandContext(ref.RancherID, ref.ProjectID)
+	if err != nil {
+		saveAuditEvent(&event, model.AuditInvalid, err.Error())
+		listener.postError(ev, "Error on select the Rancher and environment of the selection, nothing was executed", err)
+		return model.AuditInvalid
+	}
+	ctx.source = "interactive message"
+
+	listener.dispatchCommand(ev, *cmd, args, ctx, &event)
+
+	return event.Outcome
+}
+
+// closePicker troca a mensagem do picker pelo resultado, tirando o select e os botões
+func closePicker(responseURL string, text string) {
+	if responseURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(slack.Msg{Text: text, ReplaceOriginal: true})
+	if err != nil {
+		CheckErr("Error on close the picker", err)
+		return
+	}
+
+	resp, err := http.Post(responseURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		CheckErr("Error on close the picker", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("[ERROR] Error on close the picker: response_url returned %d", resp.StatusCode)
+	}
+}
+
+// openView abre um modal com o trigger_id da interação. O nlopes/slack ainda
+// não tem o views.open
+func openView(triggerID string, view modalView) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"trigger_id": triggerID,
+		"view":       view,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, slack.APIURL+"views.open", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+SlackBotToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Ok    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+
+	if !result.Ok {
+		return fmt.Errorf("views.open returned %s", result.Error)
+	}
+
+	return nil
+}
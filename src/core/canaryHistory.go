@@ -0,0 +1,103 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/haproxy"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+// canaryHistoryLimit é o número de versões mostradas pelo canary-history
+const canaryHistoryLimit = 10
+
+func (s *SlackListener) slackCanaryHistory(ev *slack.MessageEvent, args CommandArgs) {
+	lb := args.String("lb-id")
+
+	versions, err := service.ListLbConfigVersions(s.rancherListener.ID, s.rancherListener.projectID, lb, canaryHistoryLimit)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on list versions of Load Balancer `%s`", lb), err)
+		return
+	}
+
+	if len(versions) == 0 {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Load Balancer `%s` has no versions, the history starts on the first change made by the BOT.", lb), false))
+		return
+	}
+
+	version := versions[0].Version
+	if args.Has("version") {
+		version = args.Int("version")
+	}
+
+	msg := fmt.Sprintf("*Versions of Load Balancer `%s`:*", lb)
+	for _, v := range versions {
+		msg += "\n" + lbConfigVersionLine(v)
+	}
+
+	diff, err := lbConfigVersionDiff(s.rancherListener, lb, version)
+	if err != nil {
+		s.postError(ev, msg, err)
+		return
+	}
+
+	msg += fmt.Sprintf("\n\n*Changes of version `%d`:*\n```%s```", version, diff)
+
+	s.reply(ev, slack.MsgOptionText(msg, false))
+}
+
+func (s *SlackListener) slackCanaryRollback(ev *slack.MessageEvent, args CommandArgs) {
+	lb := args.String("lb-id")
+	version := args.Int("version")
+
+	target, resp, err := s.rancherListener.RollbackLbConfig(lb, version, ev.User)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on rollback haproxy.cfg of Load Balancer `%s`", lb), err)
+		return
+	}
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("File 'haproxy.cfg' of Load Balancer `%s` rolled back to version `%d` (%s)\n```%s```", lb, target.Version, target.NewWeights, resp), false))
+}
+
+// lbConfigVersionLine descreve uma versão em uma linha
+func lbConfigVersionLine(v model.LbConfigVersion) string {
+	user := "-"
+	if v.User != "" {
+		user = fmt.Sprintf("<@%s>", v.User)
+	}
+
+	if v.Action == model.LbConfigInitial {
+		return fmt.Sprintf("`%d` %s | %s | %s", v.Version, v.CreatedAt.Format("2006-01-02 15:04:05"), v.Action, v.NewWeights)
+	}
+
+	return fmt.Sprintf("`%d` %s | %s by %s | %s -> %s", v.Version, v.CreatedAt.Format("2006-01-02 15:04:05"), v.Action, user, v.OldWeights, v.NewWeights)
+}
+
+// lbConfigVersionDiff retorna as linhas alteradas entre a versão e a anterior a ela
+func lbConfigVersionDiff(rancherListener *RancherListener, lb string, version int) (string, error) {
+	current, err := service.FindLbConfigVersion(rancherListener.ID, rancherListener.projectID, lb, version)
+	if err != nil {
+		return "", err
+	}
+
+	if version <= 1 {
+		return current.Config, nil
+	}
+
+	previous, err := service.FindLbConfigVersion(rancherListener.ID, rancherListener.projectID, lb, version-1)
+	if err != nil {
+		return "", err
+	}
+
+	changed := haproxy.ChangedLines(haproxy.Diff(previous.Config, current.Config))
+	if len(changed) == 0 {
+		return "no changes", nil
+	}
+
+	return strings.Join(changed, "\n"), nil
+}
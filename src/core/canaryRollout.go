@@ -0,0 +1,407 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+const (
+	// defaultRolloutSteps são os pesos da nova versão usados quando o --steps não é enviado
+	defaultRolloutSteps = "10,25,50,100"
+
+	// defaultRolloutInterval é o tempo entre os passos quando o --interval não é enviado
+	defaultRolloutInterval = 10 * time.Minute
+
+	// rolloutCheckInterval é o intervalo em que o BOT procura passos de rollouts para executar
+	rolloutCheckInterval = 30 * time.Second
+
+	// maxRolloutFailures é o número de erros seguidos na API do Rancher até o rollout ser abortado
+	maxRolloutFailures = 3
+)
+
+// rolloutMutex evita que o loop e um canary-rollout recém criado executem o mesmo passo
+var rolloutMutex sync.Mutex
+
+// executeCanaryRollouts executa os passos dos rollouts que estão no horário.
+// Como o estado fica no banco, rollouts iniciados antes de um restart continuam
+// de onde pararam
+func (s *SlackListener) executeCanaryRollouts() {
+	rolloutMutex.Lock()
+	defer rolloutMutex.Unlock()
+
+	var rollouts []model.CanaryRollout
+
+	if err := repository.ListDueCanaryRollouts(&rollouts, time.Now()); err != nil {
+		CheckErr("Error on list canary rollouts", err)
+		return
+	}
+
+	for i := range rollouts {
+		s.stepCanaryRollout(&rollouts[i])
+	}
+}
+
+// stepCanaryRollout verifica a saúde da nova versão após o último passo e,
+// estando saudável, aplica o próximo peso. Caso a nova versão fique unhealthy,
+// os pesos voltam para os de antes do rollout
+func (s *SlackListener) stepCanaryRollout(rollout *model.CanaryRollout) {
+	listener, err := taskRancherListener(rollout.RancherID, rollout.RancherProjectID)
+	if err != nil {
+		s.canaryRolloutError(rollout, err)
+		return
+	}
+
+	interval := time.Duration(rollout.IntervalSeconds) * time.Second
+
+	steps, err := service.ParseRolloutSteps(rollout.Steps)
+	if err != nil {
+		s.finishCanaryRollout(rollout, model.RolloutFailed, err.Error())
+		return
+	}
+
+	if rollout.CurrentStep > 0 {
+		ready, problem, err := canaryServiceHealth(listener, rollout.ServiceID)
+		if err != nil {
+			s.canaryRolloutError(rollout, err)
+			return
+		}
+
+		if problem == "" && !ready && time.Since(rollout.NextStepAt) > interval {
+			problem = fmt.Sprintf("new version did not become healthy in %s", interval)
+		}
+
+		if problem != "" {
+			s.rollbackCanaryRollout(rollout, listener, problem)
+			return
+		}
+
+		if !ready {
+			return
+		}
+	}
+
+	if !canaryRolloutRunning(rollout.ID) {
+		return
+	}
+
+	if rollout.CurrentStep >= len(steps) {
+		s.finishCanaryRollout(rollout, model.RolloutCompleted, "")
+		return
+	}
+
+	weight := steps[rollout.CurrentStep]
+	_, err = listener.UpdateCustomHaproxyCfg(rollout.LoadBalancerID, []CanaryWeight{
+		{Ref: canaryNewRef, Weight: weight},
+		{Ref: canaryOldRef, Weight: 100 - weight},
+	}, rollout.User)
+	recordRolloutAudit(rollout, err, fmt.Sprintf("step %d/%d: new=%d old=%d", rollout.CurrentStep+1, len(steps), weight, 100-weight))
+	if err != nil {
+		s.canaryRolloutError(rollout, err)
+		return
+	}
+
+	rollout.CurrentStep++
+	rollout.Failures = 0
+	rollout.Message = ""
+	rollout.NextStepAt = time.Now().Add(interval)
+
+	if err := repository.SaveCanaryRollout(rollout); err != nil {
+		CheckErr(fmt.Sprintf("Error on save canary rollout %d", rollout.ID), err)
+	}
+
+	log.Printf("[INFO] Canary rollout %d: step %d/%d applied on Load Balancer %s\n", rollout.ID, rollout.CurrentStep, len(steps), rollout.LoadBalancerID)
+
+	s.client.PostMessage(rollout.Channel, slack.MsgOptionText(fmt.Sprintf("Canary rollout `%d` of Load Balancer `%s`: step %d/%d applied.\nNew version: `%d`\nOld version: `%d`\nNext health check at %s", rollout.ID, rollout.LoadBalancerID, rollout.CurrentStep, len(steps), weight, 100-weight, rollout.NextStepAt.Format("15:04:05")), false))
+}
+
+// rollbackCanaryRollout volta o haproxy.cfg do LB para o de antes do rollout e
+// avisa o canal. O config inteiro é restaurado, então um canary que estava
+// desabilitado continua desabilitado
+func (s *SlackListener) rollbackCanaryRollout(rollout *model.CanaryRollout, listener *RancherListener, problem string) {
+	if rollout.PreviousConfig == "" {
+		s.finishCanaryRollout(rollout, model.RolloutFailed, fmt.Sprintf("%s, and the rollback failed: the haproxy.cfg from before the rollout wasn't saved", problem))
+		return
+	}
+
+	_, err := listener.RestoreLbConfig(rollout.LoadBalancerID, rollout.PreviousConfig, rollout.User)
+	recordRolloutAudit(rollout, err, fmt.Sprintf("rollback to %s: %s", canaryWeightsSummary(rollout.PreviousConfig), problem))
+	if err != nil {
+		s.finishCanaryRollout(rollout, model.RolloutFailed, fmt.Sprintf("%s, and the rollback failed: %s", problem, err))
+		return
+	}
+
+	s.finishCanaryRollout(rollout, model.RolloutRolledBack, problem)
+}
+
+// recordRolloutAudit grava no audit uma alteração do LB feita pelo rollout
+func recordRolloutAudit(rollout *model.CanaryRollout, err error, detail string) {
+	recordSystemAudit(auditSystemRollout, model.AuditEvent{
+		Channel:   rollout.Channel,
+		Command:   canaryRollout,
+		RancherID: rollout.RancherID,
+		ProjectID: rollout.RancherProjectID,
+		Resource:  rollout.LoadBalancerID,
+	}, err, fmt.Sprintf("rollout %d by %s, %s", rollout.ID, rollout.User, detail))
+}
+
+// canaryRolloutError conta os erros seguidos na API do Rancher, abortando o
+// rollout (e mantendo os pesos atuais) quando eles passam de maxRolloutFailures
+func (s *SlackListener) canaryRolloutError(rollout *model.CanaryRollout, err error) {
+	CheckErr(fmt.Sprintf("Error on canary rollout %d", rollout.ID), err)
+
+	rollout.Failures++
+	rollout.Message = err.Error()
+
+	if rollout.Failures >= maxRolloutFailures {
+		s.finishCanaryRollout(rollout, model.RolloutFailed, fmt.Sprintf("%d errors in a row on Rancher API, weights kept as they are: %s", rollout.Failures, err))
+		return
+	}
+
+	if err := repository.SaveCanaryRollout(rollout); err != nil {
+		CheckErr(fmt.Sprintf("Error on save canary rollout %d", rollout.ID), err)
+	}
+}
+
+// finishCanaryRollout salva o status final do rollout e avisa o canal
+func (s *SlackListener) finishCanaryRollout(rollout *model.CanaryRollout, status string, message string) {
+	rollout.Status = status
+	rollout.Message = message
+
+	if err := repository.SaveCanaryRollout(rollout); err != nil {
+		CheckErr(fmt.Sprintf("Error on save canary rollout %d", rollout.ID), err)
+	}
+
+	var msg string
+	switch status {
+	case model.RolloutCompleted:
+		msg = fmt.Sprintf(":white_check_mark: Canary rollout `%d` of Load Balancer `%s` completed, the new version is healthy.", rollout.ID, rollout.LoadBalancerID)
+	case model.RolloutRolledBack:
+		msg = fmt.Sprintf(":rotating_light: Canary rollout `%d` of Load Balancer `%s` rolled back to the haproxy.cfg from before the rollout (%s).\nReason: %s", rollout.ID, rollout.LoadBalancerID, canaryWeightsSummary(rollout.PreviousConfig), message)
+	default:
+		msg = fmt.Sprintf(":rotating_light: Canary rollout `%d` of Load Balancer `%s` stopped (%s).\nReason: %s", rollout.ID, rollout.LoadBalancerID, status, message)
+	}
+
+	log.Printf("[INFO] Canary rollout %d finished: %s %s\n", rollout.ID, status, message)
+
+	s.client.PostMessage(rollout.Channel, slack.MsgOptionText(msg, false))
+}
+
+// canaryRolloutRunning verifica no banco se o rollout não foi cancelado enquanto o passo rodava
+func canaryRolloutRunning(ID uint) bool {
+	var rollout model.CanaryRollout
+
+	if err := repository.FindCanaryRolloutByID(&rollout, ID); err != nil {
+		CheckErr(fmt.Sprintf("Error on find canary rollout %d", ID), err)
+		return false
+	}
+
+	return rollout.Status == model.RolloutRunning
+}
+
+// canaryServiceHealth verifica o serviço da nova versão e seus containers.
+// Retorna ready=false enquanto o serviço ainda está subindo e um problema
+// quando ele ou algum container está unhealthy, parado ou com erro
+func canaryServiceHealth(listener *RancherListener, serviceID string) (ready bool, problem string, err error) {
+	svc, err := listener.GetService(serviceID)
+	if err != nil {
+		return false, "", err
+	}
+
+	switch svc.HealthState {
+	case "unhealthy", "degraded":
+		return false, fmt.Sprintf("service `%s` is %s", svc.Name, svc.HealthState), nil
+	case "healthy", "started-once":
+	default:
+		return false, "", nil
+	}
+
+	if svc.State != "active" {
+		return false, fmt.Sprintf("service `%s` is %s", svc.Name, svc.State), nil
+	}
+
+	containers, err := listener.GetInstances(serviceID)
+	if err != nil {
+		return false, "", err
+	}
+
+	for _, container := range containers {
+		switch {
+		case container.HealthState == "unhealthy":
+			return false, fmt.Sprintf("container `%s` is unhealthy", container.Name), nil
+		case container.State == "stopped" || container.State == "error":
+			return false, fmt.Sprintf("container `%s` is %s", container.Name, container.State), nil
+		case container.State != "running":
+			return false, "", nil
+		}
+	}
+
+	return true, "", nil
+}
+
+// findCanaryService procura, nas port rules do LB, o serviço da nova versão
+// (o que tem canaryNewRef no nome)
+func findCanaryService(listener *RancherListener, lbID string) (string, error) {
+	lb, err := listener.GetHaproxyCfg(lbID)
+	if err != nil {
+		return "", err
+	}
+
+	if lb.LbConfig != nil {
+		for _, rule := range lb.LbConfig.PortRules {
+			serviceID, _ := rule["serviceId"].(string)
+			if serviceID == "" {
+				continue
+			}
+
+			svc, err := listener.GetService(serviceID)
+			if err != nil {
+				return "", err
+			}
+
+			if strings.Contains(svc.Name, canaryNewRef) {
+				return svc.ID, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no service with `%s` on name in port rules of Load Balancer `%s`, send it with --service", canaryNewRef, lbID)
+}
+
+func (s *SlackListener) slackCanaryRollout(ev *slack.MessageEvent, args CommandArgs) {
+	switch args.String("lb-id") {
+	case "list":
+		s.listCanaryRollouts(ev)
+		return
+	case "cancel":
+		s.cancelCanaryRollout(ev, args)
+		return
+	}
+
+	if s.rancherListener.projectID == "" {
+		s.reply(ev, slack.MsgOptionText("Please select environment.", false))
+		return
+	}
+
+	lb := args.String("lb-id")
+
+	steps := defaultRolloutSteps
+	if args.Has("steps") {
+		steps = args.String("steps")
+	}
+
+	interval := defaultRolloutInterval
+	if args.Has("interval") {
+		interval = args.Duration("interval")
+	}
+
+	serviceID := args.String("service")
+	if serviceID == "" {
+		var err error
+		if serviceID, err = findCanaryService(s.rancherListener, lb); err != nil {
+			s.postError(ev, fmt.Sprintf("Error on find the new version service of Load Balancer `%s`", lb), err)
+			return
+		}
+	}
+
+	new, old, err := s.rancherListener.SearchForLbPercent(lb)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on get weights of Load Balancer `%s`", lb), err)
+		return
+	}
+
+	// o config atual é o que volta se a nova versão ficar unhealthy
+	current, err := s.rancherListener.GetHaproxyCfg(lb)
+	if err == nil && (current.LbConfig == nil || current.LbConfig.Config == "") {
+		err = fmt.Errorf("haproxy.cfg of load balancer %s is empty", lb)
+	}
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on get haproxy.cfg of Load Balancer `%s`", lb), err)
+		return
+	}
+
+	rollout := model.CanaryRollout{
+		LoadBalancerID:    lb,
+		ServiceID:         serviceID,
+		Steps:             steps,
+		IntervalSeconds:   int64(interval / time.Second),
+		NextStepAt:        time.Now(),
+		PreviousNewWeight: new,
+		PreviousOldWeight: old,
+		PreviousConfig:    current.LbConfig.Config,
+		Channel:           ev.Channel,
+		User:              ev.User,
+		RancherID:         s.context.rancherID,
+		RancherProjectID:  s.rancherListener.projectID,
+	}
+
+	if err := service.AddCanaryRollout(&rollout); err != nil {
+		s.postError(ev, "Error on create canary rollout", err)
+		return
+	}
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Canary rollout `%d` of Load Balancer `%s` started.\nSteps: `%s` every `%s`\nHealth gate: service `%s`\nOn failure the haproxy.cfg goes back to the current one (new: `%d` / old: `%d`)", rollout.ID, lb, steps, interval, serviceID, new, old), false))
+
+	go s.executeCanaryRollouts()
+}
+
+func (s *SlackListener) listCanaryRollouts(ev *slack.MessageEvent) {
+	rollouts, err := service.ListCanaryRollouts(model.RolloutRunning)
+	if err != nil {
+		s.postError(ev, "Error on list canary rollouts", err)
+		return
+	}
+
+	if len(rollouts) == 0 {
+		s.reply(ev, slack.MsgOptionText("No canary rollout running.", false))
+		return
+	}
+
+	msg := "*Canary rollouts running:*"
+	for _, rollout := range rollouts {
+		msg += fmt.Sprintf("\n`%d` LB: `%s` | Steps: `%s` | Applied: %d | Next: %s", rollout.ID, rollout.LoadBalancerID, rollout.Steps, rollout.CurrentStep, rollout.NextStepAt.Format("15:04:05"))
+		if rollout.Message != "" {
+			msg += fmt.Sprintf(" | Last error: %s", rollout.Message)
+		}
+	}
+
+	s.reply(ev, slack.MsgOptionText(msg, false))
+}
+
+func (s *SlackListener) cancelCanaryRollout(ev *slack.MessageEvent, args CommandArgs) {
+	if !args.Has("rollout-id") {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Command call error, correct syntax: @jeremias %s cancel rollout-id", canaryRollout), false))
+		return
+	}
+
+	ID := args.ID("rollout-id")
+
+	// o rollout pode ser de outro Rancher ou environment que o selecionado
+	rollout, err := service.FindCanaryRollout(ID)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on find canary rollout `%d`", ID), err)
+		return
+	}
+
+	if !s.authorizeObject(ev, canaryRollout, fmt.Sprintf("canary rollout `%d`", rollout.ID), rollout.RancherID, rollout.RancherProjectID) {
+		return
+	}
+
+	rollout, err = service.CancelCanaryRollout(ID, ev.User)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on cancel canary rollout `%d`", ID), err)
+		return
+	}
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Canary rollout `%d` of Load Balancer `%s` canceled, weights kept as they are.", rollout.ID, rollout.LoadBalancerID), false))
+}
@@ -1,5 +1,10 @@
 package core
 
+import (
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
 // Command é a struct responsável por guardar informações referentes a um comando
 type Command struct {
 	Cmd         string `json:"command"`
@@ -7,8 +12,30 @@ type Command struct {
 	Usage       string `json:"usage"`
 	Lint        string `json:"lint"`
 	IsActive    bool   `json:"isActive"`
+	RancherOnly bool   `json:"rancherOnly"`
+
+	// Restricted são os comandos que só podem ser executados por quem tem um
+	// Role com eles no Rancher e environment selecionados
+	Restricted bool `json:"restricted"`
+
+	// NeedsApproval são os comandos que, em environments protegidos, só são
+	// executados depois da aprovação de outro usuário autorizado
+	NeedsApproval bool `json:"needsApproval"`
+
+	// Global são os comandos que não usam o Rancher e o environment do
+	// usuário, então não mostram em qual contexto foram executados
+	Global bool `json:"global"`
+
+	Args  []Arg  `json:"args"`
+	Flags []Flag `json:"flags"`
+
+	// Handler é chamado com os argumentos já validados por parseCommandArgs
+	Handler commandHandler `json:"-"`
 }
 
+// commandHandler é a função que executa um comando
+type commandHandler func(s *SlackListener, ev *slack.MessageEvent, args CommandArgs)
+
 // Commands é a variável que guarda todos os comandos do BOT
 var Commands []Command
 
@@ -17,200 +44,545 @@ func CreateCommands() {
 	Commands = append(Commands, Command{
 		Cmd:         canaryUpdate,
 		Description: "Command that changes weights in Canary Deployment",
-		Usage:       "@jeremias command `lb-id` `new-version-weight` `old-version-weight` `channel-to-send-alert (optional)`",
-		Lint:        "`lb-id` LoadBalancer ID to be edited | `new-version-weight` Weight to new version on canary | `old-version-weight` Weight to old version on canary | `channel-to-send-alert` Channel code to send non-technical alert. Ex.: GHHG3S9L4",
-		IsActive:    true,
+		Args: []Arg{
+			{Name: "lb-id", Type: ArgString, Description: "LoadBalancer ID to be edited"},
+			{Name: "new-version-weight", Type: ArgString, Description: "Weight to new version on canary"},
+			{Name: "old-version-weight", Type: ArgString, Description: "Weight to old version on canary"},
+			{Name: "channel-to-send-alert", Type: ArgChannel, Description: "Channel code to send non-technical alert. Ex.: GHHG3S9L4", Optional: true},
+		},
+		Lint:          "By default the weights go to the servers with `new` and `old` on backend or server name, to use other servers send `backend=weight` or `backend/server=weight`",
+		IsActive:      true,
+		Restricted:    true,
+		NeedsApproval: true,
+		RancherOnly:   true,
+		Handler:       (*SlackListener).slackUpdateCanary,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         canaryUpTen,
 		Description: "Command to add 10% to canary release of a load balancer",
-		Usage:       "@jeremias command `lb-id` `channel-to-send-alert (optional)`",
-		Lint:        "",
+		Args: []Arg{
+			{Name: "lb-id", Type: ArgString},
+			{Name: "channel-to-send-alert", Type: ArgChannel, Optional: true},
+		},
+		Lint:          "",
+		IsActive:      true,
+		Restricted:    true,
+		NeedsApproval: true,
+		RancherOnly:   true,
+		Handler:       (*SlackListener).slackCanaryUpTen,
+	})
+
+	Commands = append(Commands, Command{
+		Cmd:         canaryRollout,
+		Description: "Command that raises the canary of a load balancer step by step, checking the health of the new version between the steps",
+		Args: []Arg{
+			{Name: "lb-id", Type: ArgString, Description: "Load Balancer of the rollout, or `list` to show the running rollouts and `cancel rollout-id` to stop one"},
+			{Name: "rollout-id", Type: ArgID, Optional: true},
+		},
+		Flags: []Flag{
+			{Name: "steps", Type: ArgString, Example: defaultRolloutSteps, Description: "Weights of the new version on each step"},
+			{Name: "interval", Type: ArgDuration, Example: "10m", Description: "Time between the steps"},
+			{Name: "service", Type: ArgString, Example: "service-id", Description: "Service of the new version, by default the service with `new` on name in the port rules of the LB"},
+		},
+		Lint:          "If the service or one of its containers turns unhealthy the weights go back to the ones before the rollout | Rollouts continue after a restart of the BOT",
+		IsActive:      true,
+		Restricted:    true,
+		NeedsApproval: true,
+		RancherOnly:   true,
+		Handler:       (*SlackListener).slackCanaryRollout,
+	})
+
+	Commands = append(Commands, Command{
+		Cmd:         canaryHistory,
+		Description: "Command that lists the versions of the haproxy.cfg of a load balancer changed by the BOT",
+		Args: []Arg{
+			{Name: "lb-id", Type: ArgString},
+			{Name: "version", Type: ArgInt, Description: "Version to show the diff with the previous one, by default the last", Optional: true},
+		},
+		Lint:        "Shows who changed, when and the weights before and after of the last versions",
 		IsActive:    true,
+		RancherOnly: true,
+		Handler:     (*SlackListener).slackCanaryHistory,
+	})
+
+	Commands = append(Commands, Command{
+		Cmd:         canaryRollback,
+		Description: "Command that puts back a previous version of the haproxy.cfg of a load balancer",
+		Args: []Arg{
+			{Name: "lb-id", Type: ArgString},
+			{Name: "version", Type: ArgInt, Description: "Version listed by canary-history, by default the version before the last change", Optional: true},
+		},
+		Lint:          "The rollback is saved as a new version",
+		IsActive:      true,
+		Restricted:    true,
+		NeedsApproval: true,
+		RancherOnly:   true,
+		Handler:       (*SlackListener).slackCanaryRollback,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         canaryActivate,
 		Description: "Command that actives the Canary Deployment in a specified Load Balancer",
-		Usage:       "@jeremias command `*lb-id*`",
-		Lint:        "The command removes all '#' of haproxy.cfg file | Will appear a select to you select a Load Balancer to enable canary",
-		IsActive:    true,
+		Args: []Arg{
+			{Name: "lb-id", Type: ArgString, Optional: true},
+		},
+		Lint:          "The command restores the lines of haproxy.cfg file commented by canary-disable | Without `lb-id` will appear a select to you select a Load Balancer to enable canary",
+		IsActive:      true,
+		Restricted:    true,
+		NeedsApproval: true,
+		RancherOnly:   true,
+		Handler:       (*SlackListener).slackCanaryEnable,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         canaryDisable,
 		Description: "Command that disable the Canary Deployment in a specified Load Balancer",
-		Usage:       "@jeremias command `*lb-id*`",
-		Lint:        "The command add '#' on start of all lines of the haproxy.cfg file | Will appear a select to you select a Load Balancer to enable canary",
-		IsActive:    true,
+		Args: []Arg{
+			{Name: "lb-id", Type: ArgString, Optional: true},
+		},
+		Lint:          "The command comments all directives of the haproxy.cfg file, keeping the comments that already exist | Without `lb-id` will appear a select to you select a Load Balancer to disable canary",
+		IsActive:      true,
+		Restricted:    true,
+		NeedsApproval: true,
+		RancherOnly:   true,
+		Handler:       (*SlackListener).slackCanaryDisable,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         canaryInfo,
 		Description: "Command that returns a haproxy.cfg of a specified Load Balancer",
-		Usage:       "@jeremias command",
-		Lint:        "The command get haproxy.cfg body and send for message | Will appear a select to you select a Load Balancer to get info",
+		Args: []Arg{
+			{Name: "lb-id", Type: ArgString, Optional: true},
+		},
+		Lint:        "The command get haproxy.cfg body and send for message | Without `lb-id` will appear a select to you select a Load Balancer",
 		IsActive:    true,
+		RancherOnly: true,
+		Handler:     (*SlackListener).slackCanaryInfo,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         haproxyList,
 		Description: "Command that brings ID list Environment Load Balancers Name",
-		Usage:       "@jeremias command",
 		Lint:        "",
 		IsActive:    true,
+		RancherOnly: true,
+		Handler:     (*SlackListener).slackListLoadBalancers,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         logsContainer,
-		Description: "Command responsible for returning the logs of the specified container until the action is triggered",
-		Usage:       "@jeremias command",
-		Lint:        "Will appear a select, where be selected the container to get logs",
-		IsActive:    true,
+		Description: "Command responsible for returning the logs of the specified container, or streaming them into a thread",
+		Args: []Arg{
+			{Name: "container-id", Type: ArgString, Description: "ID listed by container-list", Optional: true},
+		},
+		Flags: []Flag{
+			{Name: "lines", Type: ArgInt, Example: "200", Description: "Lines from the end of the logs, 100 by default (at most 5000)"},
+			{Name: "since", Type: ArgDuration, Example: "10m", Description: "Only the lines written in this last period"},
+			{Name: "follow", Type: ArgDuration, Example: "2m", Description: "Keeps sending the new lines into a thread for this long (at most 15m)"},
+			{Name: "grep", Type: ArgString, Example: "ERROR", Description: "Only the lines matching the regular expression"},
+		},
+		Lint:     "Small logs are sent on the message and large ones as a file, at most 512KB are read | Without `container-id` will appear a select, where be selected the container to get logs",
+		IsActive: true,
+		Handler:  (*SlackListener).slackLogsContainer,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         restartContainer,
 		Description: "Command responsible for restarting specified container",
-		Usage:       "@jeremias command",
-		Lint:        "Will appear a select, where be selected the container to be restarted",
+		Args: []Arg{
+			{Name: "container-id", Type: ArgString, Description: "ID listed by container-list", Optional: true},
+		},
+		Lint:       "Without `container-id` will appear a select, where be selected the container to restart",
+		IsActive:   true,
+		Restricted: true,
+		Handler:    (*SlackListener).slackRestartContainer,
+	})
+
+	Commands = append(Commands, Command{
+		Cmd:         execContainer,
+		Description: "Command responsible for running a one-off command on the specified container and returning its output and exit status",
+		Args: []Arg{
+			{Name: "container-id", Type: ArgString, Description: "ID listed by container-list"},
+			{Name: "command", Type: ArgCommand, Description: "The program and its arguments, after `--` (ex.: `-- ls -la /tmp`)", Rest: true},
+		},
+		Lint:        "Only the programs of the exec policy of the environment can run, registered on the REST API `/v1/exec-policies` | The program runs without a shell and the BOT waits for it until the timeout of the policy, 30s by default | Small outputs are sent on the message and large ones as a file, at most 64KB are read",
 		IsActive:    true,
+		RancherOnly: true,
+		Restricted:  true,
+		Handler:     (*SlackListener).slackContainerExec,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         getServiceInfo,
 		Description: "Command that brings information about a service that will be specified",
-		Usage:       "@jeremias command",
-		Lint:        "Will appear a select, where be selected the container",
-		IsActive:    true,
+		Args: []Arg{
+			{Name: "service-id", Type: ArgString, Description: "ID listed by service-list", Optional: true},
+		},
+		Lint:     "Without `service-id` will appear a select, where be selected the service",
+		IsActive: true,
+		Handler:  (*SlackListener).slackServiceInfo,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         upgradeService,
 		Description: "Command that will make an upgrade of a service, changing its image according to which it is passed as parameter",
-		Usage:       "@jeremias command `service-id` `new-image`",
-		Lint:        "In `service-id` put the id of the service which you need to send a new image and in `new-image` put the name of image to be sended",
+		Args: []Arg{
+			{Name: "service-id", Type: ArgString, Description: "ID of the service which you need to send a new image", Optional: true},
+			{Name: "new-image", Type: ArgString, Description: "Name of image to be sended, starting with `docker:`", Optional: true},
+		},
+		Flags: []Flag{
+			{Name: "batch-size", Type: ArgInt, Example: "2", Description: "Containers upgraded at a time, 1 by default (Rancher 1.6)"},
+			{Name: "interval", Type: ArgDuration, Example: "30s", Description: "Wait between the batches, 2s by default (Rancher 1.6)"},
+			{Name: "auto-finish-after", Type: ArgDuration, Example: "10m", Description: "Finishes the upgrade after the service stays healthy for this long (at most 2h, Rancher 1.6)"},
+		},
+		Lint:          "Without the arguments will appear a select, where be selected the service, and then a form to type the new image | On Rancher 1.6 the progress is sent on a thread and, when all containers are upgraded, the buttons to finish or roll back the upgrade",
+		IsActive:      true,
+		Restricted:    true,
+		NeedsApproval: true,
+		Handler:       (*SlackListener).slackServiceUpgrade,
+	})
+
+	Commands = append(Commands, Command{
+		Cmd:         finishUpgrade,
+		Description: "Command that finishes the upgrade of a service, removing the containers of the previous version",
+		Args: []Arg{
+			{Name: "service-id", Type: ArgString, Description: "ID of the upgraded service"},
+		},
+		Lint:        "The same as the Finish button of the upgrade",
 		IsActive:    true,
+		Restricted:  true,
+		RancherOnly: true,
+		Handler:     (*SlackListener).slackFinishUpgrade,
+	})
+
+	Commands = append(Commands, Command{
+		Cmd:         rollbackUpgrade,
+		Description: "Command that rolls back the upgrade of a service to the previous version",
+		Args: []Arg{
+			{Name: "service-id", Type: ArgString, Description: "ID of the upgraded service"},
+		},
+		Lint:        "The same as the Rollback button of the upgrade",
+		IsActive:    true,
+		Restricted:  true,
+		RancherOnly: true,
+		Handler:     (*SlackListener).slackRollbackUpgrade,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         listService,
 		Description: "Command that brings an ID list | Environment Services Name",
-		Usage:       "@jeremias command",
 		Lint:        "The returned format is something like ID: service-id | Name: service-name",
 		IsActive:    true,
+		Handler:     (*SlackListener).slackServicesList,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         startService,
 		Description: "Command to activate services",
-		Usage:       "@jeremias command `service-id`",
-		Lint:        "",
-		IsActive:    true,
+		Args: []Arg{
+			{Name: "service-id", Type: ArgString},
+		},
+		Lint:       "",
+		IsActive:   true,
+		Restricted: true,
+		Handler:    (*SlackListener).slackStartService,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         stopService,
 		Description: "Command to deactivate a services",
-		Usage:       "@jeremias command `service-id`",
-		Lint:        "",
+		Args: []Arg{
+			{Name: "service-id", Type: ArgString},
+		},
+		Lint:          "",
+		IsActive:      true,
+		Restricted:    true,
+		NeedsApproval: true,
+		Handler:       (*SlackListener).slackStopService,
+	})
+
+	Commands = append(Commands, Command{
+		Cmd:         scaleService,
+		Description: "Command responsible for changing the scale of a service and waiting for its containers",
+		Args: []Arg{
+			{Name: "stackName/serviceName", Type: ArgService, Description: "Rancher Stack Name and Service Name, don't forget the '/'"},
+			{Name: "scale", Type: ArgString, Description: "The new scale (`5`) or a change of the current one (`+2`, `-1`)"},
+		},
+		Flags: []Flag{
+			{Name: "for", Type: ArgDuration, Example: "2h", Description: "Goes back to the previous scale after this period (at most 24h)"},
+		},
+		Lint:        "The scale must be between the minimum and maximum of the environment, registered on the REST API `/v1/scale-limits` (1 and 10 by default) | The progress of the containers is sent on a thread until all are running and healthy, for at most 5m | Scaling again cancels a pending `--for`, a new `--for` replaces it and still goes back to the scale before the first one",
 		IsActive:    true,
+		Restricted:  true,
+		RancherOnly: true,
+		Handler:     (*SlackListener).slackScaleService,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         checkServiceHealth,
 		Description: "Command used to check health of one service",
-		Usage:       "@jeremias command `stackName/serviceName` `channel-to-send-alert`",
-		Lint:        "Put the Rancher Stack Name and Service Name on parameters, don't forget the '/'",
+		Args: []Arg{
+			{Name: "stackName/serviceName", Type: ArgService, Description: "Rancher Stack Name and Service Name, don't forget the '/'"},
+			{Name: "channel-to-send-alert", Type: ArgChannel},
+			{Name: "restart", Type: ArgBool, Description: "`true` to restart, and then delete, the unhealthy containers", Optional: true},
+		},
+		Flags: []Flag{
+			{Name: "every", Type: ArgDuration, Example: "5m", Description: "Interval of the polling of the task"},
+			{Name: "cron", Type: ArgString, Example: "\"*/10 * * * *\"", Description: "Cron expression (minute hour day-of-month month day-of-week) of the polling of the task"},
+			{Name: "failing-after", Type: ArgInt, Example: "3", Description: "Unhealthy checks in a row to alert"},
+			{Name: "recovered-after", Type: ArgInt, Example: "2", Description: "Healthy checks in a row to send the recovered message"},
+			{Name: "flap-threshold", Type: ArgInt, Example: "3", Description: "Failures inside the window that mark the service as flapping and suppress the alerts"},
+			{Name: "flap-window", Type: ArgDuration, Example: "60m", Description: "Window of the flap threshold"},
+		},
+		Lint:        "The service goes healthy -> degraded -> failing -> recovering | By default the task is checked on Rancher events with polling as fallback | Checks of the same Rancher run at most `maxConcurrency` at a time",
 		IsActive:    true,
+		Restricted:  true,
+		RancherOnly: true,
+		Handler:     (*SlackListener).slackCheckServiceHealth,
 	})
 
 	Commands = append(Commands, Command{
-		Cmd:         taskAddByKeyword,
-		Description: "Command used to add tasks using a keyword",
-		Usage:       "@jeremias command `keyword1,keyword2` `channelToSendAlert` `deleteContainer?`",
+		Cmd:         silenceCommand,
+		Description: "Command that suppresses the alerts and auto restarts of tasks for a while, health keeps being checked",
+		Args: []Arg{
+			{Name: silenceTargetArg, Type: ArgString, Description: "ID listed by task-list, or the tasks of the service, or all tasks, in the selected environment"},
+			{Name: "for", Type: ArgKeyword},
+			{Name: "duration", Type: ArgDuration, Description: "How long the silence lasts, like `30m` or `2h`"},
+			{Name: "reason", Type: ArgString, Optional: true, Rest: true},
+		},
+		Lint:       "Alerts still failing when the silence ends are sent",
+		IsActive:   true,
+		Restricted: true,
+		Handler:    (*SlackListener).slackSilence,
+	})
+
+	Commands = append(Commands, Command{
+		Cmd:         maintenanceCommand,
+		Description: "Command that schedules a maintenance window, suppressing the alerts and auto restarts of tasks",
+		Args: []Arg{
+			{Name: silenceTargetArg, Type: ArgString},
+			{Name: "reason", Type: ArgString, Optional: true, Rest: true},
+		},
+		Flags: []Flag{
+			{Name: "at", Type: ArgString, Example: "\"" + maintenanceTimeLayout + "\"", Description: "Start of a single window, on the BOT time zone"},
+			{Name: "cron", Type: ArgString, Example: "\"0 22 * * SAT\"", Description: "Start of a recurring window (minute hour day-of-month month day-of-week)"},
+			{Name: "for", Type: ArgDuration, Example: "2h", Description: "Duration of the window, required"},
+		},
+		Lint:       "One of `--at` or `--cron` is required",
+		IsActive:   true,
+		Restricted: true,
+		Handler:    (*SlackListener).slackMaintenance,
+	})
+
+	Commands = append(Commands, Command{
+		Cmd:         silenceList,
+		Description: "Command that lists the silences and maintenance windows active or scheduled",
 		Lint:        "",
 		IsActive:    true,
+		Global:      true,
+		Handler:     (*SlackListener).slackSilenceList,
+	})
+
+	Commands = append(Commands, Command{
+		Cmd:         silenceRemove,
+		Description: "Command that removes a silence or maintenance window",
+		Args: []Arg{
+			{Name: "silence-id", Type: ArgID, Description: "ID listed by silence-list"},
+		},
+		Lint:       "",
+		IsActive:   true,
+		Global:     true,
+		Restricted: true,
+		Handler:    (*SlackListener).slackSilenceRemove,
+	})
+
+	Commands = append(Commands, Command{
+		Cmd:         incidentList,
+		Description: "Command that lists the incidents of the tasks, with the time to acknowledge and to resolve",
+		Args: []Arg{
+			{Name: incidentStatusArg, Type: ArgString, Description: "By default lists the incidents not resolved yet", Optional: true},
+		},
+		Lint:     "Incidents are opened by the alerts of the tasks and acknowledged or resolved with the buttons of the alert",
+		IsActive: true,
+		Global:   true,
+		Handler:  (*SlackListener).slackIncidentList,
+	})
+
+	Commands = append(Commands, Command{
+		Cmd:         auditCommand,
+		Description: "Command that lists the last commands called on the BOT, with who called, the result and the status of the requests to the Rancher API",
+		Flags: []Flag{
+			{Name: "user", Type: ArgUser, Example: "@user", Description: "Only the commands of the user"},
+			{Name: "resource", Type: ArgString, Example: "1s234", Description: "Only the commands on the resource (first argument of the command)"},
+			{Name: "command", Type: ArgString, Example: stopService, Description: "Only the calls of the command"},
+			{Name: "outcome", Type: ArgString, Example: model.AuditDenied, Description: "Only the calls with the result: success, error, denied, invalid or pending_approval"},
+			{Name: "since", Type: ArgDuration, Example: "24h", Description: "Only the commands of the last period"},
+			{Name: "limit", Type: ArgInt, Example: "20", Description: "How many events to show, at most 100"},
+		},
+		Lint:       "To export the events as CSV or JSON use the REST API `/v1/audit`",
+		IsActive:   true,
+		Global:     true,
+		Restricted: true,
+		Handler:    (*SlackListener).slackAudit,
+	})
+
+	Commands = append(Commands, Command{
+		Cmd:         taskAddByKeyword,
+		Description: "Command used to add tasks using a keyword",
+		Args: []Arg{
+			{Name: "keyword1,keyword2", Type: ArgString, Description: "Adds a task for each service with one of the keywords on the stack and service names, in all environments of all Ranchers 1.6"},
+			{Name: "channel-to-send-alert", Type: ArgChannel},
+			{Name: "restart", Type: ArgBool, Optional: true},
+		},
+		Lint:       "Tasks are added only on the environments where you are allowed to run this command",
+		IsActive:   true,
+		Global:     true,
+		Restricted: true,
+		Handler:    (*SlackListener).slackCheckServiceByKeyword,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         statusService,
 		Description: "Command used to check status of service",
-		Usage:       "@jeremias command `stackName/serviceName` `channel-to-send-alert`",
-		Lint:        "Put the Rancher Stack Name and Service Name on parameters, don't forget the '/'",
+		Args: []Arg{
+			{Name: "stackName/serviceName", Type: ArgService, Description: "Rancher Stack Name and Service Name, don't forget the '/'"},
+			{Name: "channel-to-send-alert", Type: ArgChannel},
+		},
+		Flags: []Flag{
+			{Name: "every", Type: ArgDuration, Example: "1h", Description: "Interval of the status report, by default 1h"},
+			{Name: "cron", Type: ArgString, Example: "\"0 9 * * MON-FRI\"", Description: "Cron expression (minute hour day-of-month month day-of-week) of the status report"},
+		},
+		Lint:        "",
 		IsActive:    true,
+		Restricted:  true,
+		RancherOnly: true,
+		Handler:     (*SlackListener).serviceCheck,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         removeServiceCheck,
 		Description: "Command remove automatic service check",
-		Usage:       "@jeremias command `task-ID`",
-		Lint:        "Put the stackName and serviceName that you have informed on call to check. Task ID can be recovered with `list-task` command",
-		IsActive:    true,
+		Args: []Arg{
+			{Name: "task-ID", Type: ArgString, Description: "Task ID recovered with `task-list`, a list like `1,2,3` or `all`"},
+		},
+		Lint:       "",
+		IsActive:   true,
+		Global:     true,
+		Restricted: true,
+		Handler:    (*SlackListener).stopServiceCheck,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         listAllRunningTasks,
 		Description: "Command list all running tasks at the moment of called",
-		Usage:       "@jeremias command",
 		Lint:        "Return a list with ID of running tasks",
 		IsActive:    true,
+		Global:      true,
+		Handler:     (*SlackListener).listAllRunningTasks,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         listAllEnvironments,
 		Description: "Command list all environments of selected Rancher",
-		Usage:       "@jeremias command",
 		Lint:        "Return a list of all environments",
 		IsActive:    true,
+		Handler:     (*SlackListener).listAllEnvironments,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         selectEnvironment,
 		Description: "Command to set a environment to next requests on Rancher",
-		Usage:       "@jeremias command `environment-name`",
-		Lint:        "The environment name can be recovered with environment-list command",
-		IsActive:    true,
+		Args: []Arg{
+			{Name: "environment-name", Type: ArgString, Description: "Name listed by env-list, with quotes or `_` in place of spaces"},
+		},
+		Flags: []Flag{
+			{Name: "scope", Type: ArgString, Example: contextScopeChannel, Description: "`channel` keeps the selection only for you in this channel, by default it is for you in every channel"},
+		},
+		Lint:     "The environment is selected only for you, on the Rancher you selected with rancher-set",
+		Global:   true,
+		IsActive: true,
+		Handler:  (*SlackListener).selectEnvironment,
 	})
 
 	Commands = append(Commands, Command{
-		Cmd:         envCleanupMachines,
-		Description: "Command to cleanup machines of one environment on Rancher",
-		Usage:       "@jeremias command",
-		Lint:        "This command cleans disconnected machines from environment",
-		IsActive:    true,
+		Cmd:           envCleanupMachines,
+		Description:   "Command to cleanup machines of one environment on Rancher",
+		Lint:          "This command cleans disconnected machines from environment",
+		IsActive:      true,
+		Restricted:    true,
+		NeedsApproval: true,
+		RancherOnly:   true,
+		Handler:       (*SlackListener).envCleanupMachinesFunc,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         selectRancher,
 		Description: "Command sets the selected Rancher, to next requests",
-		Usage:       "@jeremias command `rancher-name`",
-		Lint:        "Receives Rancher name that has registered on database",
-		IsActive:    true,
+		Args: []Arg{
+			{Name: "rancher-name", Type: ArgString, Description: "Rancher name that has registered on database, or `default` for the Rancher of the environment variables of the BOT"},
+		},
+		Flags: []Flag{
+			{Name: "scope", Type: ArgString, Example: contextScopeChannel, Description: "`channel` keeps the selection only for you in this channel, by default it is for you in every channel"},
+		},
+		Lint:     "Rancher 1.6 has type `rancher` and Rancher 2.x/Kubernetes clusters have type `kubernetes`, where some commands are not available | The Rancher is selected only for you",
+		Global:   true,
+		IsActive: true,
+		Handler:  (*SlackListener).selectRancher,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         listRancher,
 		Description: "Command to list all registered Ranchers on database",
-		Usage:       "@jeremias command",
-		Lint:        "Returns `name, url and access key of all ranchers` (not returns secret key for security)",
+		Lint:        "Returns `name, type, url and access key of all ranchers` (not returns secret key for security)",
+		IsActive:    true,
+		Global:      true,
+		Handler:     (*SlackListener).listAllRanchers,
+	})
+
+	Commands = append(Commands, Command{
+		Cmd:         secretRotate,
+		Description: "Command that encrypts again the secret keys of the Ranchers with the current encryption key",
+		Lint:        "Set the new key in SECRET_ENCRYPTION_KEY and the old one in SECRET_ENCRYPTION_PREVIOUS_KEYS, restart the BOT and call this command, then the old key can be removed",
 		IsActive:    true,
+		Global:      true,
+		Restricted:  true,
+		Handler:     (*SlackListener).slackSecretRotate,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         containerList,
 		Description: "Command to list containers",
-		Usage:       "@jeremias command",
-		Lint:        "Returns `host, ID and name of all containers`",
+		Args: []Arg{
+			{Name: "keyword", Type: ArgString, Description: "Part of the name of the containers"},
+		},
+		Lint:        "Returns `host, ID and name of the containers`",
 		IsActive:    true,
+		RancherOnly: true,
+		Handler:     (*SlackListener).containersList,
 	})
 
 	Commands = append(Commands, Command{
 		Cmd:         commands,
 		Description: "Command responsible for displaying the commands that are available in BOT",
-		Usage:       "@jeremias command",
 		Lint:        "",
 		IsActive:    true,
+		Global:      true,
+		Handler:     (*SlackListener).slackHelper,
 	})
+
+	for i := range Commands {
+		Commands[i].Usage = Commands[i].usage()
+	}
+}
+
+// findCommand retorna o comando ativo com o nome informado, ou nil se ele não existir
+func findCommand(name string) *Command {
+	for i := range Commands {
+		if Commands[i].Cmd == name && Commands[i].IsActive {
+			return &Commands[i]
+		}
+	}
+
+	return nil
 }
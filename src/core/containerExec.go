@@ -0,0 +1,205 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/rancher"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+const (
+	// execExitMarker é escrito depois do comando junto com o exit status, o
+	// websocket do Rancher 1.6 fecha sem dizer como o comando terminou
+	execExitMarker = "__jeremias_exit_status__:"
+
+	// execMaxBytes é o limite da saída lida de um comando
+	execMaxBytes = 64 * 1024
+
+	// execTimeoutStatus é o exit status do programa morto pelo timeout do container
+	execTimeoutStatus = 124
+
+	// execTimeoutGrace é quanto o BOT espera além do timeout, para ler o exit
+	// status do programa morto pelo timeout do container
+	execTimeoutGrace = 5 * time.Second
+)
+
+// execResult é a saída de um comando executado no container
+type execResult struct {
+	output []byte
+
+	// exitCode é -1 quando o comando não terminou até o timeout ou o
+	// container não tem sh para informar o exit status
+	exitCode int
+	timedOut bool
+	capped   bool
+}
+
+func (s *SlackListener) slackContainerExec(ev *slack.MessageEvent, args CommandArgs) {
+	container := args.String("container-id")
+	argv := args.Argv("command")
+	environment := s.orchestrator.EnvironmentID()
+
+	if len(argv) == 0 {
+		s.reply(ev, slack.MsgOptionText("Missing `command`, use `@jeremias container-exec <container-id> -- <command>`", false))
+		return
+	}
+
+	policy, found, err := service.FindEnvironmentExecPolicy(s.orchestrator.RancherID(), environment)
+	if err != nil {
+		s.postError(ev, "Error on get the exec policy of the environment", err)
+		return
+	}
+
+	if !found {
+		s.denyCommand(ev, fmt.Sprintf("`%s` is not allowed on environment `%s`, it has no exec policy", execContainer, environment))
+		return
+	}
+
+	if !service.ExecAllows(policy, argv[0]) {
+		s.denyCommand(ev, fmt.Sprintf("`%s` is not allowed on environment `%s`, the allowed programs are: `%s`", argv[0], environment, policy.Commands))
+		return
+	}
+
+	timeout := service.ExecTimeout(policy)
+
+	stream, err := s.rancherListener.ExecContainer(container, execWithStatus(argv, timeout))
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on exec on container `%s`", container), err)
+		return
+	}
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf(":hourglass_flowing_sand: Running `%s` on container `%s`, waiting up to %s", strings.Join(argv, " "), container, timeout), false))
+
+	s.background(func() {
+		result, err := readExec(stream, timeout+execTimeoutGrace)
+		if err != nil && len(result.output) == 0 {
+			s.postError(ev, fmt.Sprintf("Error on read the output of `%s` on container `%s`", argv[0], container), err)
+			return
+		}
+
+		status := describeExecStatus(result, timeout)
+		if s.audit != nil {
+			s.audit.detail = status
+		}
+
+		s.replyExec(ev, container, argv, status, result)
+	})
+}
+
+// execWithStatus executa o programa pelo sh só para escrever o exit status no
+// fim da saída. Os argumentos são passados como $@, sem serem interpretados
+// pelo shell. Nos containers com o timeout (coreutils, ou o -t dos busybox
+// antigos) o programa é morto no fim do timeout, nos outros ele continua
+// rodando depois que o BOT para de esperar
+func execWithStatus(argv []string, timeout time.Duration) []string {
+	seconds := int(timeout / time.Second)
+	script := fmt.Sprintf(`if timeout 1 true >/dev/null 2>&1; then timeout %d "$@"; elif timeout -t 1 true >/dev/null 2>&1; then timeout -t %d "$@"; else "$@"; fi; echo "%s$?"`, seconds, seconds, execExitMarker)
+
+	return append([]string{"sh", "-c", script, "sh"}, argv...)
+}
+
+// describeExecStatus descreve como o comando terminou
+func describeExecStatus(result execResult, timeout time.Duration) string {
+	switch {
+	case result.timedOut:
+		return fmt.Sprintf("timed out after %s", timeout)
+	case result.exitCode < 0:
+		return "unknown exit status"
+	case result.exitCode == execTimeoutStatus:
+		return fmt.Sprintf("exit status %d, killed by the timeout of %s if the container has the `timeout` program", result.exitCode, timeout)
+	default:
+		return fmt.Sprintf("exit status %d", result.exitCode)
+	}
+}
+
+// replyExec responde com a saída do comando, em uma mensagem ou, se for
+// grande, como um arquivo
+func (s *SlackListener) replyExec(ev *slack.MessageEvent, container string, argv []string, status string, result execResult) {
+	icon := ":white_check_mark:"
+	if result.exitCode != 0 {
+		icon = ":x:"
+	}
+
+	description := fmt.Sprintf("%s `%s` on container `%s` finished with %s", icon, strings.Join(argv, " "), container, status)
+
+	var note string
+	switch {
+	case result.timedOut:
+		note = "\n_The BOT stopped waiting, the command may still be running on the container_"
+	case result.capped:
+		note = fmt.Sprintf("\n_Only the first %dKB were read_", execMaxBytes/1024)
+	}
+
+	text := strings.TrimRight(string(result.output), "\r\n")
+
+	if text == "" {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("%s, without output%s", description, note), false))
+		return
+	}
+
+	s.replyOutput(ev, description, text, note, fmt.Sprintf("%s-exec.txt", container))
+}
+
+// readExec lê a saída até o comando terminar ou o timeout, e sempre fecha o
+// stream. O exit status escrito por execWithStatus é retirado da saída
+func readExec(stream *rancher.ExecStream, timeout time.Duration) (result execResult, err error) {
+	defer stream.Close()
+
+	result.exitCode = -1
+
+	done := make(chan struct{})
+	defer close(done)
+
+	received, failed := readChunks(stream.Next, done)
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	// tail guarda o fim da saída, onde fica o exit status mesmo quando ela
+	// passou do limite
+	var tail []byte
+	tailSize := len(execExitMarker) + 8
+
+	for {
+		select {
+		case data := <-received:
+			if room := execMaxBytes - len(result.output); len(data) > room {
+				result.output = append(result.output, data[:room]...)
+				result.capped = true
+			} else {
+				result.output = append(result.output, data...)
+			}
+
+			if tail = append(tail, data...); len(tail) > tailSize {
+				tail = tail[len(tail)-tailSize:]
+			}
+		case err := <-failed:
+			if err != io.EOF {
+				return result, err
+			}
+
+			if i := bytes.LastIndex(tail, []byte(execExitMarker)); i >= 0 {
+				if code, err := strconv.Atoi(strings.TrimSpace(string(tail[i+len(execExitMarker):]))); err == nil {
+					result.exitCode = code
+				}
+			}
+			if i := bytes.LastIndex(result.output, []byte(execExitMarker)); i >= 0 {
+				result.output = result.output[:i]
+			}
+
+			return result, nil
+		case <-deadline.C:
+			result.timedOut = true
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,324 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+const (
+	// logsDefaultLines são as linhas do fim dos logs lidas sem --lines e --since
+	logsDefaultLines = 100
+	logsMaxLines     = 5000
+
+	// logsIncidentLines são as linhas enviadas na thread do alerta de uma task
+	logsIncidentLines = 1000
+
+	// logsMaxFollow é o maior --follow aceito
+	logsMaxFollow = 15 * time.Minute
+
+	// logsMaxBytes é o limite de bytes lidos de um comando, depois dele o
+	// stream é fechado
+	logsMaxBytes = 512 * 1024
+
+	// logsInlineBytes é até quanto os logs vão em uma mensagem, com mais eles
+	// são enviados como arquivo
+	logsInlineBytes = 3500
+
+	// logsBatchInterval é de quanto em quanto tempo as novas linhas do
+	// --follow são enviadas na thread
+	logsBatchInterval = 5 * time.Second
+
+	// logsReadTimeout é quanto tempo os logs sem --follow podem levar
+	logsReadTimeout = 30 * time.Second
+)
+
+func (s *SlackListener) slackLogsContainer(ev *slack.MessageEvent, args CommandArgs) {
+	if !args.Has("container-id") {
+		s.sendPicker(ev, picker{
+			command: logsContainer,
+			text:    "Which container you need to download logs? :yum:",
+		})
+		return
+	}
+
+	container := args.String("container-id")
+	follow := args.Duration("follow")
+
+	opts := LogsOptions{Lines: logsDefaultLines, Since: args.Duration("since"), Follow: follow > 0}
+	if opts.Since > 0 {
+		opts.Lines = logsMaxLines
+	}
+	if args.Has("lines") {
+		opts.Lines = args.Int("lines")
+	}
+
+	if opts.Lines <= 0 || opts.Lines > logsMaxLines {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("`--lines` must be between 1 and %d", logsMaxLines), false))
+		return
+	}
+
+	if follow > logsMaxFollow {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("`--follow` must be at most %s", logsMaxFollow), false))
+		return
+	}
+
+	var grep *regexp.Regexp
+	if args.Has("grep") {
+		var err error
+		if grep, err = regexp.Compile(args.String("grep")); err != nil {
+			s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Invalid `--grep` pattern: %s", err), false))
+			return
+		}
+	}
+
+	// o --follow responde em uma thread, que não existe nas respostas
+	// efêmeras dos slash commands nem na API
+	if opts.Follow && (s.output != nil || s.responseURL != "") {
+		s.reply(ev, slack.MsgOptionText("`--follow` streams the logs into a thread, mention @jeremias on a channel to use it", false))
+		return
+	}
+
+	description := describeLogs(container, opts, grep)
+
+	stream, err := s.orchestrator.InstanceLogs(container, opts)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on get logs of container `%s`", container), err)
+		return
+	}
+
+	if !opts.Follow {
+		lines, capped, err := readLogs(stream, grep, logsReadTimeout, nil)
+		s.replyLogs(ev, container, description, lines, capped, err)
+		return
+	}
+
+	_, ts, err := s.client.PostMessage(ev.Channel, threadOptions(ev.ThreadTimestamp, slack.MsgOptionText(fmt.Sprintf(":eyes: Following %s for %s, the new lines are sent on this thread", description, follow), false))...)
+	if err != nil {
+		stream.Close()
+		s.postError(ev, fmt.Sprintf("Error on follow logs of container `%s`", container), err)
+		return
+	}
+
+	thread := ev.ThreadTimestamp
+	if thread == "" {
+		thread = ts
+	}
+
+	go s.followLogs(ev.Channel, thread, container, stream, grep, follow)
+}
+
+// describeLogs descreve quais linhas dos logs foram pedidas
+func describeLogs(container string, opts LogsOptions, grep *regexp.Regexp) string {
+	filters := []string{fmt.Sprintf("last %d lines", opts.Lines)}
+	if opts.Since > 0 {
+		filters = append(filters, fmt.Sprintf("since %s", opts.Since))
+	}
+	if grep != nil {
+		filters = append(filters, fmt.Sprintf("matching `%s`", grep))
+	}
+
+	return fmt.Sprintf("the logs of container `%s` (%s)", container, strings.Join(filters, ", "))
+}
+
+// replyLogs responde com os logs lidos, em uma mensagem ou, se forem
+// grandes, como um arquivo
+func (s *SlackListener) replyLogs(ev *slack.MessageEvent, container string, description string, lines []string, capped bool, err error) {
+	if err != nil && len(lines) == 0 {
+		s.postError(ev, fmt.Sprintf("Error on read logs of container `%s`", container), err)
+		return
+	}
+
+	if len(lines) == 0 {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("No lines on %s", description), false))
+		return
+	}
+
+	var note string
+	if capped {
+		note = fmt.Sprintf("\n_Only the first %dKB were read_", logsMaxBytes/1024)
+	}
+
+	s.replyOutput(ev, fmt.Sprintf("Here are %s", description), strings.Join(lines, "\n"), note, fmt.Sprintf("%s.log", container))
+}
+
+// replyOutput responde com a saída de um comando em uma mensagem ou, se for
+// grande, como o arquivo filename. A API recebe a saída inteira, já limitada
+// pelo comando
+func (s *SlackListener) replyOutput(ev *slack.MessageEvent, description string, text string, note string, filename string) {
+	if len(text) <= logsInlineBytes || s.output != nil {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("%s:\n```%s```%s", description, text, note), false))
+		return
+	}
+
+	// o arquivo seria visto por todos no canal, a resposta efêmera mostra só o fim
+	if s.responseType == slashResponseEphemeral {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("%s, here is the end:\n```%s```\n_Mention @jeremias on the channel to get the whole file_", description, text[len(text)-logsInlineBytes:]), false))
+		return
+	}
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("%s, sent as a file%s", description, note), false))
+
+	if err := s.postText(ev.Channel, ev.ThreadTimestamp, text, filename, filename); err != nil {
+		s.postError(ev, fmt.Sprintf("Error on upload `%s`", filename), err)
+	}
+}
+
+// followLogs envia as novas linhas na thread a cada logsBatchInterval, até o
+// fim do --follow, dos logs ou do limite de bytes
+func (s *SlackListener) followLogs(channel string, thread string, container string, stream LogStream, grep *regexp.Regexp, follow time.Duration) {
+	_, capped, err := readLogs(stream, grep, follow, func(lines []string) {
+		CheckErr(fmt.Sprintf("Error on send logs of container %s", container), s.postLogs(channel, thread, container, lines))
+	})
+
+	msg := fmt.Sprintf(":checkered_flag: Stopped following the logs of container `%s`", container)
+	switch {
+	case capped:
+		msg += fmt.Sprintf(", the limit of %dKB was reached", logsMaxBytes/1024)
+	case err != nil:
+		msg += fmt.Sprintf(", the logs were closed: %s", err)
+	}
+
+	s.client.PostMessage(channel, slack.MsgOptionText(msg, false), slack.MsgOptionTS(thread))
+}
+
+// uploadContainerLogs envia as últimas linhas dos logs do container para o
+// canal, na thread quando ela existe
+func (s *SlackListener) uploadContainerLogs(channel string, threadTS string, rancherListener *RancherListener, containerID string) {
+	stream, err := rancherListener.InstanceLogs(containerID, LogsOptions{Lines: logsIncidentLines})
+	if err != nil {
+		CheckErr(fmt.Sprintf("Error on get logs of container %s", containerID), err)
+		return
+	}
+
+	lines, _, err := readLogs(stream, nil, logsReadTimeout, nil)
+	CheckErr(fmt.Sprintf("Error on read logs of container %s", containerID), err)
+
+	if len(lines) > 0 {
+		CheckErr("Upload logs container error", s.postLogs(channel, threadTS, containerID, lines))
+	}
+}
+
+// postLogs envia as linhas no canal, na thread quando ela existe, em um bloco
+// de código ou, se forem grandes, como um arquivo
+func (s *SlackListener) postLogs(channel string, threadTS string, container string, lines []string) error {
+	return s.postText(channel, threadTS, strings.Join(lines, "\n"), fmt.Sprintf("%s.log", container), fmt.Sprintf("Logs of container: %s", container))
+}
+
+// postText envia o texto no canal, na thread quando ela existe, em um bloco
+// de código ou, se for grande, como o arquivo filename
+func (s *SlackListener) postText(channel string, threadTS string, text string, filename string, title string) error {
+	if len(text) <= logsInlineBytes {
+		_, _, err := s.client.PostMessage(channel, threadOptions(threadTS, slack.MsgOptionText(fmt.Sprintf("```%s```", text), false))...)
+		return err
+	}
+
+	_, err := s.client.UploadFile(slack.FileUploadParameters{
+		Content:         text,
+		Filename:        filename,
+		Filetype:        "text",
+		Title:           title,
+		Channels:        []string{channel},
+		ThreadTimestamp: threadTS,
+	})
+
+	return err
+}
+
+// threadOptions adiciona a thread às opções da mensagem, quando ela existe
+func threadOptions(threadTS string, options ...slack.MsgOption) []slack.MsgOption {
+	if threadTS != "" {
+		options = append(options, slack.MsgOptionTS(threadTS))
+	}
+
+	return options
+}
+
+// readLogs lê as linhas do stream que passam pelo grep até o fim dos logs, o
+// timeout ou o limite de bytes, e sempre fecha o stream. Com batch as linhas
+// são entregues a cada logsBatchInterval, sem ele todas são retornadas no fim
+func readLogs(stream LogStream, grep *regexp.Regexp, timeout time.Duration, batch func(lines []string)) (lines []string, capped bool, err error) {
+	defer stream.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	received, failed := readChunks(func() ([]byte, error) {
+		line, err := stream.Next()
+		return []byte(line), err
+	}, done)
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	ticker := time.NewTicker(logsBatchInterval)
+	defer ticker.Stop()
+
+	flush := func() {
+		if batch != nil && len(lines) > 0 {
+			batch(lines)
+			lines = nil
+		}
+	}
+	defer flush()
+
+	var size int
+	for {
+		select {
+		case data := <-received:
+			line := string(data)
+			if grep != nil && !grep.MatchString(line) {
+				continue
+			}
+
+			size += len(line) + 1
+			if size > logsMaxBytes {
+				return lines, true, nil
+			}
+
+			lines = append(lines, line)
+		case <-ticker.C:
+			flush()
+		case err := <-failed:
+			if err == io.EOF {
+				err = nil
+			}
+			return lines, false, err
+		case <-deadline.C:
+			return lines, false, nil
+		}
+	}
+}
+
+// readChunks lê o stream em uma goroutine, entregando os pedaços lidos por
+// next em received e o erro que encerrou a leitura (io.EOF no fim) em failed.
+// A goroutine para quando done é fechado
+func readChunks(next func() ([]byte, error), done <-chan struct{}) (<-chan []byte, <-chan error) {
+	received := make(chan []byte)
+	failed := make(chan error, 1)
+
+	go func() {
+		for {
+			data, err := next()
+			if err != nil {
+				failed <- err
+				return
+			}
+
+			select {
+			case received <- data:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return received, failed
+}
@@ -0,0 +1,260 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+const (
+	// defaultRancherName é como o Rancher das variáveis de ambiente é chamado no
+	// rancher-set e no --rancher, quando nenhum Rancher cadastrado tem esse nome
+	defaultRancherName = "default"
+
+	// contextScopeChannel é o --scope do rancher-set e env-set que guarda a
+	// seleção só para o canal da mensagem
+	contextScopeChannel = "channel"
+
+	contextSourceOverride = "override"
+	contextSourceChannel  = "your selection in this channel"
+	contextSourceUser     = "your selection"
+	contextSourceDefault  = "default"
+)
+
+// contextFlags são aceitas por todos os comandos, para executá-los em outro
+// Rancher ou environment sem alterar a seleção do usuário
+var contextFlags = []Flag{
+	{Name: "rancher", Type: ArgString, Example: "rancher-name", Description: "Runs the command on another registered Rancher"},
+	{Name: "env", Type: ArgString, Example: "environment-name", Description: "Runs the command on another environment"},
+}
+
+// commandContext é o Rancher e o environment em que um comando é executado
+type commandContext struct {
+	rancherID   uint
+	rancherName string
+	projectID   string
+	environment string
+
+	// source é de onde veio a seleção (override, seleção do usuário ou padrão)
+	source string
+
+	orchestrator Orchestrator
+}
+
+// newCommandContext cria o orquestrador do Rancher cadastrado com o ID, ou do
+// Rancher das variáveis de ambiente com o ID 0, no environment
+func newCommandContext(rancherID uint, projectID string) (*commandContext, error) {
+	ctx := &commandContext{
+		rancherID: rancherID,
+		projectID: projectID,
+		source:    contextSourceDefault,
+	}
+
+	if rancherID == 0 {
+		if RanchListener == nil || RanchListener.baseURL == "" {
+			return nil, fmt.Errorf("there is no default Rancher, select one with `%s`", selectRancher)
+		}
+
+		listener := *RanchListener
+		listener.projectID = projectID
+
+		ctx.rancherName = defaultRancherName
+		ctx.orchestrator = &listener
+
+		return ctx, nil
+	}
+
+	var rancher model.Rancher
+	if err := repository.FindRancherByID(&rancher, rancherID); err != nil {
+		return nil, fmt.Errorf("Rancher `%d` not found, select another with `%s`", rancherID, selectRancher)
+	}
+
+	o, err := NewOrchestrator(rancher, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.rancherName = rancher.Name
+	ctx.orchestrator = o
+
+	return ctx, nil
+}
+
+// rancherListener retorna o orquestrador quando ele é um Rancher 1.6, usado
+// pelos comandos que só existem no Rancher 1.6 (canary, tasks, etc.)
+func (ctx *commandContext) rancherListener() *RancherListener {
+	listener, _ := ctx.orchestrator.(*RancherListener)
+	return listener
+}
+
+// String descreve o Rancher e o environment com os nomes
+func (ctx *commandContext) String() string {
+	if ctx.projectID == "" {
+		return fmt.Sprintf("Rancher `%s` (no environment selected)", ctx.rancherName)
+	}
+
+	if ctx.environment == "" {
+		ctx.environment = ctx.projectID
+		if name, err := EnvironmentName(ctx.orchestrator, ctx.projectID); err == nil {
+			ctx.environment = name
+		}
+	}
+
+	return fmt.Sprintf("environment `%s` of Rancher `%s`", ctx.environment, ctx.rancherName)
+}
+
+// resolveContext escolhe o Rancher e o environment do comando: as flags
+// --rancher e --env, a seleção do usuário no canal, a seleção do usuário em
+// todos os canais e, sem nenhuma delas, o Rancher das variáveis de ambiente
+func (s *SlackListener) resolveContext(ev *slack.MessageEvent, args CommandArgs) (*commandContext, error) {
+	selection, found, err := service.FindSlackContext(ev.User, ev.Channel)
+	if err != nil {
+		return nil, err
+	}
+
+	rancherID, projectID, environment, source := uint(0), RancherProjectID, "", contextSourceDefault
+	if found {
+		rancherID, projectID, environment = selection.RancherID, selection.ProjectID, selection.Environment
+
+		source = contextSourceUser
+		if selection.Channel != "" {
+			source = contextSourceChannel
+		}
+	}
+
+	if args.Has("rancher") {
+		if rancherID, err = findRancherID(args.String("rancher")); err != nil {
+			return nil, err
+		}
+		projectID, environment, source = "", "", contextSourceOverride
+
+		if rancherID == 0 {
+			projectID = RancherProjectID
+		}
+	}
+
+	ctx, err := newCommandContext(rancherID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	ctx.environment = environment
+	ctx.source = source
+
+	if args.Has("env") {
+		env, err := findEnvironment(ctx.orchestrator, args.String("env"))
+		if err != nil {
+			return nil, err
+		}
+
+		ctx.orchestrator.SetEnvironment(env.ID)
+		ctx.projectID, ctx.environment, ctx.source = env.ID, env.Name, contextSourceOverride
+	}
+
+	return ctx, nil
+}
+
+// withContext retorna uma cópia do listener que executa os comandos no contexto
+func (s *SlackListener) withContext(ctx *commandContext) *SlackListener {
+	listener := *s
+	listener.context = ctx
+	listener.orchestrator = ctx.orchestrator
+	listener.rancherListener = ctx.rancherListener()
+
+	return &listener
+}
+
+// findRancherID retorna o ID do Rancher cadastrado com o nome, ou 0 para o
+// Rancher das variáveis de ambiente
+func findRancherID(name string) (uint, error) {
+	rancher := model.Rancher{Name: name}
+	if err := repository.FindRancherByName(&rancher); err == nil {
+		return rancher.ID, nil
+	}
+
+	if name == defaultRancherName && RanchListener != nil && RanchListener.baseURL != "" {
+		return 0, nil
+	}
+
+	return 0, fmt.Errorf("Rancher `%s` not found, make sure it is registered", name)
+}
+
+// findEnvironment procura o environment pelo nome (com `_` no lugar dos
+// espaços) ou pelo ID
+func findEnvironment(o Orchestrator, name string) (Environment, error) {
+	environments, err := o.ListEnvironments()
+	if err != nil {
+		return Environment{}, err
+	}
+
+	spaced := strings.Replace(name, "_", " ", -1)
+	for _, env := range environments {
+		if env.Name == spaced || env.Name == name || env.ID == name {
+			return env, nil
+		}
+	}
+
+	return Environment{}, fmt.Errorf("environment `%s` not found, check if it exists", spaced)
+}
+
+// taskRancherListener cria o RancherListener de uma task ou rollout, que
+// guardam só o ID do Rancher (0 para o das variáveis de ambiente) e o environment
+func taskRancherListener(rancherID uint, projectID string) (*RancherListener, error) {
+	ctx, err := newCommandContext(rancherID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	listener := ctx.rancherListener()
+	if listener == nil {
+		return nil, fmt.Errorf("Rancher `%s` is not a Rancher 1.6", ctx.rancherName)
+	}
+
+	return listener, nil
+}
+
+// scopeName descreve o Rancher e o environment com os nomes, ou os IDs quando
+// não for possível buscá-los
+func scopeName(rancherID uint, projectID string) string {
+	ctx, err := newCommandContext(rancherID, projectID)
+	if err != nil {
+		return fmt.Sprintf("environment `%s` of Rancher `%d`", projectID, rancherID)
+	}
+
+	return ctx.String()
+}
+
+// setSlackContext guarda a seleção do rancher-set ou env-set para o usuário em
+// todos os canais ou, com `--scope channel`, só no canal da mensagem
+func (s *SlackListener) setSlackContext(ev *slack.MessageEvent, args CommandArgs, selection *model.SlackContext) bool {
+	switch args.String("scope") {
+	case "", "all":
+	case contextScopeChannel:
+		selection.Channel = ev.Channel
+	default:
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Invalid scope `%s`, use `all` or `%s`", args.String("scope"), contextScopeChannel), false))
+		return false
+	}
+
+	if err := service.SetSlackContext(selection); err != nil {
+		s.postError(ev, "Error on save your selection", err)
+		return false
+	}
+
+	return true
+}
+
+// contextScopeText descreve onde a seleção vale
+func contextScopeText(selection model.SlackContext) string {
+	if selection.Channel != "" {
+		return fmt.Sprintf("you in <#%s>", selection.Channel)
+	}
+
+	return "you"
+}
@@ -4,19 +4,23 @@
 package core
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/jinzhu/gorm"
 	"github.com/nlopes/slack"
 	"github.com/slack-bot-4all/slack-bot/src/config"
 	"github.com/slack-bot-4all/slack-bot/src/model"
-	"github.com/slack-bot-4all/slack-bot/src/repository"
 	"github.com/slack-bot-4all/slack-bot/src/routes"
+	"github.com/slack-bot-4all/slack-bot/src/service"
 )
 
 var (
@@ -41,9 +45,35 @@ var (
 	// SlackBotChannel é o canal padrão que o BOT irá escutar
 	SlackBotChannel string
 
-	// SlackBotVerificationToken é o Verification Token do BOT que será usado
-	// no interactive
-	SlackBotVerificationToken string
+	// SlackSigningSecret é o Signing Secret do app, usado para validar as
+	// requisições da Events API e dos botões
+	SlackSigningSecret string
+
+	// SlackMode é como o BOT recebe as mensagens: events (Events API) ou rtm
+	SlackMode string
+
+	// ApprovalTimeout é quanto tempo um pedido de aprovação espera um segundo usuário (ex.: 30m)
+	ApprovalTimeout string
+
+	// SecretEncryptionKey é a chave que criptografa as secret keys dos Ranchers no banco
+	SecretEncryptionKey string
+
+	// SecretEncryptionKeyFile é o arquivo com a SecretEncryptionKey, no lugar da variável
+	SecretEncryptionKeyFile string
+
+	// SecretEncryptionPreviousKeys são as chaves anteriores, separadas por vírgula,
+	// usadas só para ler as secret keys até o secret-rotate
+	SecretEncryptionPreviousKeys string
+
+	// JWTSecret é a chave que assina os JWT dos usuários da API
+	JWTSecret string
+
+	// JWTExpiry é quanto tempo um JWT vale (ex.: 1h)
+	JWTExpiry string
+
+	// BootstrapToken permite criar o primeiro usuário da API em /auth/bootstrap,
+	// gerado e mostrado no log quando não é informado
+	BootstrapToken string
 
 	// Port é a porta onde a API irá rodar
 	Port string
@@ -87,7 +117,15 @@ func init() {
 	flag.StringVar(&SlackBotID, "slack_bot_id", os.Getenv("SLACK_BOT_ID"), "Slack Bot ID to compare messages on channel's")
 	flag.StringVar(&SlackBotChannel, "slack_bot_channel", os.Getenv("SLACK_BOT_CHANNEL"), "Channel where the BOT will listen")
 	flag.StringVar(&Port, "http_port", os.Getenv("HTTP_PORT"), "HTTP Port where API's gonna run")
-	flag.StringVar(&SlackBotVerificationToken, "slack_bot_verification_token", os.Getenv("SLACK_BOT_VERIFICATION_TOKEN"), "Verification token of BOT")
+	flag.StringVar(&SlackSigningSecret, "slack_signing_secret", os.Getenv("SLACK_SIGNING_SECRET"), "Signing secret to verify the requests of Slack")
+	flag.StringVar(&SlackMode, "slack_mode", os.Getenv("SLACK_MODE"), "How the BOT receives messages: events (Events API, default with SLACK_SIGNING_SECRET) or rtm (deprecated, default without it)")
+	flag.StringVar(&ApprovalTimeout, "approval_timeout", os.Getenv("APPROVAL_TIMEOUT"), "How long an approval request of a protected environment waits, default 30m")
+	flag.StringVar(&SecretEncryptionKey, "secret_encryption_key", os.Getenv("SECRET_ENCRYPTION_KEY"), "Key to encrypt the secret keys of the Ranchers on db, with at least 16 characters")
+	flag.StringVar(&SecretEncryptionKeyFile, "secret_encryption_key_file", os.Getenv("SECRET_ENCRYPTION_KEY_FILE"), "File with the key to encrypt the secret keys of the Ranchers, instead of SECRET_ENCRYPTION_KEY")
+	flag.StringVar(&SecretEncryptionPreviousKeys, "secret_encryption_previous_keys", os.Getenv("SECRET_ENCRYPTION_PREVIOUS_KEYS"), "Previous encryption keys, comma separated, to read the secret keys until secret-rotate")
+	flag.StringVar(&JWTSecret, "jwt_secret", os.Getenv("JWT_SECRET"), "Key to sign the JWT of the API users, with at least 32 characters")
+	flag.StringVar(&JWTExpiry, "jwt_expiry", os.Getenv("JWT_EXPIRY"), "How long a JWT of the API is valid, default 1h")
+	flag.StringVar(&BootstrapToken, "bootstrap_token", os.Getenv("BOOTSTRAP_TOKEN"), "Token to create the first API user on /auth/bootstrap, generated when empty")
 	flag.StringVar(&DatabaseUsername, "database_username", os.Getenv("DATABASE_USERNAME"), "Username of db")
 	flag.StringVar(&DatabasePassword, "database_password", os.Getenv("DATABASE_PASSWORD"), "Password of db")
 	flag.StringVar(&DatabaseURL, "database_url", os.Getenv("DATABASE_URL"), "URL of db")
@@ -108,11 +146,47 @@ func Start() {
 		log.Fatal("[ERROR] To run the BOT, you need to set the environments, questions, see README")
 	}
 
+	// sem o signing secret as instalações antigas continuam no RTM
+	if SlackMode == "" {
+		SlackMode = SlackModeEvents
+		if SlackSigningSecret == "" {
+			SlackMode = SlackModeRTM
+		}
+	}
+
+	if SlackMode != SlackModeEvents && SlackMode != SlackModeRTM {
+		log.Fatalf("[ERROR] Invalid SLACK_MODE `%s`, use %s or %s", SlackMode, SlackModeEvents, SlackModeRTM)
+	}
+
+	if SlackMode == SlackModeEvents && SlackSigningSecret == "" {
+		log.Fatal("[ERROR] To receive the Slack events, you need to set SLACK_SIGNING_SECRET (or SLACK_MODE=rtm)")
+	}
+
+	if SlackMode == SlackModeRTM {
+		log.Println("[WARN] The BOT is receiving the messages by RTM, which is deprecated: set SLACK_SIGNING_SECRET and subscribe /slack/events on the Events API of the Slack app")
+	}
+
+	if ApprovalTimeout != "" {
+		timeout, err := time.ParseDuration(ApprovalTimeout)
+		if err != nil || timeout <= 0 {
+			log.Fatalf("[ERROR] Invalid APPROVAL_TIMEOUT `%s`, use a duration like 30m", ApprovalTimeout)
+		}
+		approvalTimeout = timeout
+	}
+
+	if err := initializeSecrets(); err != nil {
+		log.Fatalf("[ERROR] Error on load the encryption key of the secrets\n%s", err.Error())
+	}
+
 	err := initializeDB()
 	if err != nil {
 		log.Fatalf("[ERROR] Error to connect on database\n%s", err.Error())
 	}
 
+	if err := migrateRancherSecrets(); err != nil {
+		log.Fatalf("[ERROR] Error on migrate the Rancher credentials\n%s", err.Error())
+	}
+
 	t := time.Now()
 	fileName := fmt.Sprintf("logs/logs-%d%d%d%02d%02d%02d", t.Day(), t.Month(), t.Year(), t.Hour(), t.Minute(), t.Second())
 	f, err := os.Create(fileName)
@@ -162,9 +236,26 @@ func Start() {
 		projectID: RancherProjectID,
 	}
 
-	go slackListener.StartBot(RanchListener)
+	go slackListener.StartBot()
 
-	router := routes.GetRoutes()
+	auth, err := authConfig()
+	if err != nil {
+		log.Fatalf("[ERROR] Error on configure the authentication of the API\n%s", err.Error())
+	}
+
+	router := routes.GetRoutes(slackListener, auth)
+
+	// Events API, botões das mensagens interativas e slash commands, assinados pelo Slack
+	if SlackSigningSecret != "" {
+		slackGroup := router.Group("/slack", verifySlackSignature)
+
+		slackGroup.POST("/events", slackListener.handleEvents)
+		slackGroup.POST("/actions", slackListener.handleInteraction)
+		slackGroup.POST("/options", slackListener.handleOptions)
+		slackGroup.POST("/commands", slackListener.handleSlashCommand)
+	} else {
+		log.Println("[WARN] SLACK_SIGNING_SECRET not set, the buttons of the messages and the slash commands are disabled")
+	}
 
 	router.Run(fmt.Sprintf(":%s", Port))
 }
@@ -179,16 +270,132 @@ func initializeDB() error {
 
 	log.Println("[INFO] Connected to database")
 
-	config.DB.AutoMigrate(&model.Rancher{}, &model.User{}, &model.Task{}, &model.ContainerCount{})
+	// as tabelas novas usam snake_case, as criadas com camelCase por versões antigas
+	// são renomeadas antes do AutoMigrate criar as novas vazias
+	if err := service.RenameLegacyTables(); err != nil {
+		return err
+	}
+
+	// antes do AllRanchers, os role bindings com RancherID 0 valiam em todos os Ranchers
+	legacyBindings := config.DB.HasTable(&model.RoleBinding{}) && !config.DB.Dialect().HasColumn(model.RoleBinding{}.TableName(), "all_ranchers")
+
+	config.DB.AutoMigrate(&model.Rancher{}, &model.User{}, &model.Task{}, &model.ContainerCount{}, &model.CanaryRollout{}, &model.LbConfigVersion{}, &model.TaskHealth{}, &model.Silence{}, &model.Incident{}, &model.Role{}, &model.RoleBinding{}, &model.ApprovalRequest{}, &model.ProtectedEnvironment{}, &model.AuditEvent{}, &model.SlackContext{}, &model.APIToken{}, &model.ExecPolicy{}, &model.ScaleLimit{}, &model.ScaleRevert{}, &model.ServiceUpgrade{})
 
-	adminUser := model.User{
-		Username: "admin",
-		Password: "admin",
+	if legacyBindings {
+		migrated, err := service.MigrateRoleBindingScope()
+		if err != nil {
+			return err
+		}
+		log.Printf("[INFO] %d role bindings with rancherId 0 migrated to allRanchers", migrated)
 	}
-	err = repository.FindUserByUsername(&adminUser)
+
+	return nil
+}
+
+// initializeSecrets carrega a chave que criptografa as secret keys dos Ranchers,
+// da variável SECRET_ENCRYPTION_KEY ou do arquivo SECRET_ENCRYPTION_KEY_FILE
+func initializeSecrets() error {
+	key := SecretEncryptionKey
+	if SecretEncryptionKeyFile != "" {
+		content, err := ioutil.ReadFile(SecretEncryptionKeyFile)
+		if err != nil {
+			return err
+		}
+		key = string(content)
+	}
+
+	if key == "" {
+		return fmt.Errorf("set SECRET_ENCRYPTION_KEY or SECRET_ENCRYPTION_KEY_FILE")
+	}
+
+	secrets, err := config.NewSecret(key, strings.Split(SecretEncryptionPreviousKeys, ",")...)
+	if err != nil {
+		return err
+	}
+	config.Secrets = secrets
+
+	return nil
+}
+
+// migrateRancherSecrets criptografa as secret keys salvas antes da criptografia
+// e troca o Rancher copiado nas tasks, rollouts, silences e incidentes pelo ID
+// do Rancher cadastrado
+func migrateRancherSecrets() error {
+	encrypted, err := service.EncryptRancherSecrets()
 	if err != nil {
-		repository.AddUser(&adminUser)
+		return err
+	}
+	if encrypted > 0 {
+		log.Printf("[INFO] %d Rancher secret keys encrypted", encrypted)
+	}
+
+	migrated, unresolved, err := service.MigrateRancherRefs(RancherBaseURL, RancherAccessKey)
+	if err != nil {
+		return err
+	}
+	if migrated > 0 {
+		log.Printf("[INFO] %d rows migrated to reference the Rancher by ID", migrated)
+	}
+	for _, row := range unresolved {
+		log.Printf("[WARN] Rancher not registered: %s, the row is disabled until the Rancher is registered on /v1/ranchers and the BOT restarts", row)
 	}
 
 	return nil
 }
+
+// authConfig monta a chave e a validade dos JWT e, sem usuários no banco, o
+// token que cria o primeiro usuário
+func authConfig() (routes.AuthConfig, error) {
+	auth := routes.AuthConfig{
+		Secret:  []byte(JWTSecret),
+		Timeout: time.Hour,
+	}
+
+	if JWTExpiry != "" {
+		expiry, err := time.ParseDuration(JWTExpiry)
+		if err != nil || expiry <= 0 {
+			return auth, fmt.Errorf("invalid JWT_EXPIRY `%s`, use a duration like 1h", JWTExpiry)
+		}
+		auth.Timeout = expiry
+	}
+
+	if JWTSecret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return auth, err
+		}
+		auth.Secret = secret
+		log.Println("[WARN] JWT_SECRET not set, using a random key: the logins of the API end when the BOT restarts")
+	} else if len(JWTSecret) < 32 {
+		return auth, fmt.Errorf("JWT_SECRET must have at least 32 characters")
+	}
+
+	// o admin/admin das versões antigas não pode mais logar, sem outros
+	// usuários o primeiro é criado de novo pelo bootstrap
+	removed, err := service.RemoveDefaultAdmin()
+	if err != nil {
+		return auth, err
+	}
+	if removed {
+		log.Println("[WARN] The API user admin had the default password admin/admin and was removed, with its tokens")
+	}
+
+	needsBootstrap, err := service.NeedsBootstrap()
+	if err != nil {
+		return auth, err
+	}
+
+	if needsBootstrap {
+		auth.BootstrapToken = BootstrapToken
+		if auth.BootstrapToken == "" {
+			token := make([]byte, 16)
+			if _, err := rand.Read(token); err != nil {
+				return auth, err
+			}
+			auth.BootstrapToken = hex.EncodeToString(token)
+		}
+		log.Printf("[INFO] There is no API user yet, create the first one on POST /auth/bootstrap with the token %s", auth.BootstrapToken)
+	}
+
+	return auth, nil
+}
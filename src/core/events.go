@@ -0,0 +1,171 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nlopes/slack"
+)
+
+const (
+	// SlackModeEvents recebe as mensagens pela Events API, no endpoint /slack/events
+	SlackModeEvents = "events"
+
+	// SlackModeRTM recebe as mensagens pelo RTM, mantido para as instalações antigas
+	SlackModeRTM = "rtm"
+
+	// slackEventDedup é por quanto tempo um evento já recebido é ignorado. A
+	// mesma mensagem chega como app_mention e message, e o Slack reenvia os
+	// eventos que não foram respondidos a tempo
+	slackEventDedup = 10 * time.Minute
+)
+
+var (
+	slackEventsSeen      = map[string]time.Time{}
+	slackEventsSeenMutex sync.Mutex
+
+	// slackEventsMutex executa as mensagens uma por vez, como no RTM
+	slackEventsMutex sync.Mutex
+)
+
+// slackEventEnvelope é o corpo das requisições da Events API
+type slackEventEnvelope struct {
+	Type      string          `json:"type"`
+	Challenge string          `json:"challenge"`
+	EventID   string          `json:"event_id"`
+	Event     json.RawMessage `json:"event"`
+}
+
+// verifySlackSignature é o middleware que só aceita requisições assinadas com
+// o Signing Secret do app (X-Slack-Signature e X-Slack-Request-Timestamp)
+func verifySlackSignature(c *gin.Context) {
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		log.Printf("[ERROR] Failed to read request body: %s", err)
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	verifier, err := slack.NewSecretsVerifier(c.Request.Header, SlackSigningSecret)
+	if err != nil {
+		log.Printf("[ERROR] Invalid signature headers of Slack request: %s", err)
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	verifier.Write(body)
+	if err := verifier.Ensure(); err != nil {
+		log.Printf("[ERROR] Invalid signature of Slack request: %s", err)
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+	c.Next()
+}
+
+// handleEvents recebe os eventos da Events API e passa as mensagens e menções
+// para o mesmo roteador de comandos do RTM
+func (s *SlackListener) handleEvents(c *gin.Context) {
+	var envelope slackEventEnvelope
+	if err := c.BindJSON(&envelope); err != nil {
+		log.Printf("[ERROR] Failed to decode event from slack: %s", err)
+		return
+	}
+
+	switch envelope.Type {
+	case "url_verification":
+		c.String(http.StatusOK, envelope.Challenge)
+		return
+	case "event_callback":
+	default:
+		c.Status(http.StatusOK)
+		return
+	}
+
+	var ev slack.MessageEvent
+	if err := json.Unmarshal(envelope.Event, &ev); err != nil {
+		log.Printf("[ERROR] Failed to decode event %s from slack: %s", envelope.EventID, err)
+		c.Status(http.StatusOK)
+		return
+	}
+
+	// o Slack espera a resposta em até 3 segundos, os comandos rodam depois dela
+	c.Status(http.StatusOK)
+
+	if ev.Type != "app_mention" && ev.Type != "message" {
+		return
+	}
+
+	if firstSlackEvent(envelope.EventID) && firstSlackEvent(ev.Channel+"|"+ev.Timestamp) {
+		go func() {
+			slackEventsMutex.Lock()
+			defer slackEventsMutex.Unlock()
+
+			s.handleMessageEvent(&ev)
+		}()
+	}
+}
+
+// handleInteraction recebe os cliques nos botões das mensagens interativas
+func (s *SlackListener) handleInteraction(c *gin.Context) {
+	var callback blockInteraction
+	if err := json.Unmarshal([]byte(c.PostForm("payload")), &callback); err != nil {
+		log.Printf("[ERROR] Failed to decode json message from slack: %s", err)
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	// os pickers usam Block Kit, os incidentes e aprovações os attachments
+	switch callback.Type {
+	case slack.InteractionTypeBlockActions:
+		s.handleBlockAction(c, callback)
+		return
+	case interactionTypeViewSubmission:
+		s.handleViewSubmission(c, callback)
+		return
+	}
+
+	switch callback.CallbackID {
+	case incidentCallbackID:
+		s.handleIncidentAction(c, callback.InteractionCallback)
+	case approvalCallbackID:
+		s.handleApprovalAction(c, callback.InteractionCallback)
+	default:
+		log.Printf("[ERROR] Invalid callback: %s", callback.CallbackID)
+		c.Status(http.StatusBadRequest)
+	}
+}
+
+// firstSlackEvent verifica se o evento ainda não foi recebido
+func firstSlackEvent(key string) bool {
+	if key == "" || key == "|" {
+		return true
+	}
+
+	slackEventsSeenMutex.Lock()
+	defer slackEventsSeenMutex.Unlock()
+
+	now := time.Now()
+	for k, seen := range slackEventsSeen {
+		if now.Sub(seen) > slackEventDedup {
+			delete(slackEventsSeen, k)
+		}
+	}
+
+	if _, ok := slackEventsSeen[key]; ok {
+		return false
+	}
+	slackEventsSeen[key] = now
+
+	return true
+}
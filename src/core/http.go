@@ -1,10 +1,7 @@
 package core
 
 import (
-	"bytes"
 	"crypto/tls"
-	"io"
-	"log"
 	"net/http"
 )
 
@@ -34,49 +31,6 @@ func CreateHTTPClient() *http.Client {
 	return client
 }
 
-// HTTPSendRancherRequest é a função que envia a requisição para a
-// API do Rancher e retorna o body do response já convertido em
-// String
-func (rancherListener *RancherListener) HTTPSendRancherRequest(url string, method string, data string) string {
-	client := CreateHTTPClient()
-
-	var payload io.Reader
-	if data != "" {
-		payload = bytes.NewBufferString(data)
-	}
-
-	var req *http.Request
-	var err error
-	switch method {
-	case "GET":
-		req, err = http.NewRequest(method, url, nil)
-	case "POST":
-		req, err = http.NewRequest(method, url, payload)
-	case "PUT":
-		req, err = http.NewRequest(method, url, payload)
-	case "DELETE":
-		req, err = http.NewRequest(method, url, nil)
-	default:
-		log.Println("[INFO] Not possible create request, method not found.")
-	}
-	CheckErr("Error to create request", err)
-
-	rancherListener.RancherAuthAdd(req)
-
-	resp, err := client.Do(req)
-	CheckErr("Error to send request", err)
-
-	return ConvertResponseToString(resp.Body)
-}
-
-// RancherAuthAdd é a função que adiciona as credenciais na requisição que será feita
-// para a API do Rancher
-func (rancherListener *RancherListener) RancherAuthAdd(request *http.Request) {
-	if rancherListener.accessKey != "" && rancherListener.secretKey != "" {
-		request.SetBasicAuth(rancherListener.accessKey, rancherListener.secretKey)
-	}
-}
-
 func createHTTPClient() *http.Client {
 	transp := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
@@ -0,0 +1,154 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+const (
+	// incidentCallbackID é o callback dos botões da mensagem do incidente
+	incidentCallbackID = "incident"
+
+	actionAcknowledge = "acknowledge"
+	actionResolve     = "resolve"
+
+	// incidentListLimit é quantos incidentes o incident-list mostra
+	incidentListLimit = 20
+
+	// incidentStatusArg é o argumento com o status dos incidentes do incident-list
+	incidentStatusArg = "active|open|acknowledged|resolved|all"
+)
+
+// incidentAttachment é o rodapé da mensagem do incidente, com o status e os
+// botões das ações que ainda podem ser feitas
+func incidentAttachment(incident model.Incident) slack.Attachment {
+	attachment := slack.Attachment{
+		Text:       incidentStatusText(incident),
+		Color:      "danger",
+		CallbackID: incidentCallbackID,
+	}
+
+	switch incident.Status {
+	case model.IncidentOpen:
+		attachment.Actions = []slack.AttachmentAction{
+			{Name: actionAcknowledge, Text: "Acknowledge", Type: "button", Value: strconv.Itoa(int(incident.ID))},
+			{Name: actionResolve, Text: "Resolve", Type: "button", Style: "primary", Value: strconv.Itoa(int(incident.ID))},
+		}
+	case model.IncidentAcknowledged:
+		attachment.Color = "warning"
+		attachment.Actions = []slack.AttachmentAction{
+			{Name: actionResolve, Text: "Resolve", Type: "button", Style: "primary", Value: strconv.Itoa(int(incident.ID))},
+		}
+	case model.IncidentResolved:
+		attachment.Color = "good"
+	}
+
+	return attachment
+}
+
+func incidentStatusText(incident model.Incident) string {
+	text := fmt.Sprintf("Incident `%d` %s | Opened at %s", incident.ID, incident.Status, incident.OpenedAt.Format("2006-01-02 15:04:05"))
+
+	if d, ok := service.TimeToAck(incident); ok {
+		text += fmt.Sprintf(" | Acknowledged by <@%s> in %s", incident.AckedBy, d.Round(time.Second))
+	}
+
+	if d, ok := service.TimeToResolve(incident); ok {
+		resolvedBy := "automatically"
+		if incident.ResolvedBy != "" {
+			resolvedBy = fmt.Sprintf("by <@%s>", incident.ResolvedBy)
+		}
+		text += fmt.Sprintf(" | Resolved %s in %s", resolvedBy, d.Round(time.Second))
+	}
+
+	return text
+}
+
+func (s *SlackListener) slackIncidentList(ev *slack.MessageEvent, args CommandArgs) {
+	status := service.IncidentListActive
+	if args.Has(incidentStatusArg) {
+		status = args.String(incidentStatusArg)
+	}
+	if status == "all" {
+		status = ""
+	}
+
+	incidents, err := service.ListIncidents(status, incidentListLimit)
+	if err != nil {
+		s.postError(ev, "Error on list incidents", err)
+		return
+	}
+
+	if len(incidents) == 0 {
+		s.reply(ev, slack.MsgOptionText("No incidents.", false))
+		return
+	}
+
+	msg := "*Incidents:*"
+	for _, incident := range incidents {
+		msg += fmt.Sprintf("\n`%s` of task `%d` in Environment `%s` | %s", incident.Service, incident.TaskID, incident.Environment, incidentStatusText(incident))
+	}
+
+	s.reply(ev, slack.MsgOptionText(msg, false))
+}
+
+// handleIncidentAction trata os cliques nos botões de acknowledge e resolve
+// das mensagens dos incidentes
+func (s *SlackListener) handleIncidentAction(c *gin.Context, callback slack.InteractionCallback) {
+	if len(callback.ActionCallback.AttachmentActions) == 0 {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	action := callback.ActionCallback.AttachmentActions[0]
+
+	ID, err := strconv.ParseUint(action.Value, 10, 32)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	var incident model.Incident
+	var reply string
+
+	switch action.Name {
+	case actionAcknowledge:
+		incident, err = service.AcknowledgeIncident(uint(ID), callback.User.ID, now)
+		reply = fmt.Sprintf(":eyes: <@%s> acknowledged the incident", callback.User.ID)
+	case actionResolve:
+		incident, err = service.ResolveIncident(uint(ID), callback.User.ID, now)
+		reply = fmt.Sprintf(":white_check_mark: <@%s> resolved the incident", callback.User.ID)
+	default:
+		log.Printf("[ERROR] Invalid action: %s", action.Name)
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"response_type":    "ephemeral",
+			"replace_original": false,
+			"text":             err.Error(),
+		})
+		return
+	}
+
+	_, _, _, err = s.client.UpdateMessage(incident.Channel, incident.MessageTS, slack.MsgOptionText(callback.OriginalMessage.Text, false), slack.MsgOptionAttachments(incidentAttachment(incident)))
+	CheckErr(fmt.Sprintf("Error on update incident %d", incident.ID), err)
+
+	s.client.PostMessage(incident.Channel, slack.MsgOptionText(reply, false), slack.MsgOptionTS(incident.MessageTS))
+
+	c.Status(http.StatusOK)
+}
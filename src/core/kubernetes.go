@@ -0,0 +1,289 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/slack-bot-4all/slack-bot/src/kubernetes"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// replicasAnnotation guarda o número de réplicas de um deployment desativado,
+// para que ele volte com a mesma escala quando for ativado
+const replicasAnnotation = "jeremias.slack-bot-4all/replicas"
+
+// KubernetesListener é o Orchestrator de clusters Kubernetes (ex.: Rancher 2.x).
+// Os environments são os namespaces, os serviços são deployments e os containers são pods
+type KubernetesListener struct {
+	ID        uint
+	accessKey string
+	secretKey string
+	baseURL   string
+	namespace string
+
+	onResponse func(method string, url string, status int)
+}
+
+// client retorna o client da API do Kubernetes. As API Keys do Rancher 2.x
+// são usadas como token no formato `access-key:secret-key`
+func (k *KubernetesListener) client() *kubernetes.Client {
+	token := k.secretKey
+	if k.accessKey != "" {
+		token = k.accessKey + ":" + k.secretKey
+	}
+
+	client := kubernetes.NewClient(k.baseURL, token, k.namespace)
+	client.OnResponse = k.onResponse
+
+	return client
+}
+
+// Backend : implementação de Orchestrator
+func (k *KubernetesListener) Backend() string {
+	return model.RancherTypeKubernetes
+}
+
+// RancherID : implementação de Orchestrator
+func (k *KubernetesListener) RancherID() uint {
+	return k.ID
+}
+
+// EnvironmentID : implementação de Orchestrator
+func (k *KubernetesListener) EnvironmentID() string {
+	return k.namespace
+}
+
+// SetResponseHook : implementação de Orchestrator
+func (k *KubernetesListener) SetResponseHook(hook func(method string, url string, status int)) {
+	k.onResponse = hook
+}
+
+// ListEnvironments : implementação de Orchestrator
+func (k *KubernetesListener) ListEnvironments() ([]Environment, error) {
+	namespaces, err := k.client().ListNamespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var environments []Environment
+	for _, namespace := range namespaces {
+		environments = append(environments, Environment{ID: namespace.Metadata.Name, Name: namespace.Metadata.Name})
+	}
+
+	return environments, nil
+}
+
+// SetEnvironment : implementação de Orchestrator
+func (k *KubernetesListener) SetEnvironment(ID string) {
+	k.namespace = ID
+}
+
+// ListWorkloads : implementação de Orchestrator
+func (k *KubernetesListener) ListWorkloads() ([]Workload, error) {
+	deployments, err := k.client().ListDeployments()
+	if err != nil {
+		return nil, err
+	}
+
+	var workloads []Workload
+	for _, deployment := range deployments {
+		workloads = append(workloads, deploymentToWorkload(deployment))
+	}
+
+	return workloads, nil
+}
+
+// ListInstances : implementação de Orchestrator
+func (k *KubernetesListener) ListInstances(workloadID string) ([]Instance, error) {
+	client := k.client()
+
+	deployment, err := client.GetDeployment(workloadID)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := client.ListPods(deployment.Spec.Selector.MatchLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []Instance
+	for _, pod := range pods {
+		healthState := "unhealthy"
+		if pod.Ready() {
+			healthState = "healthy"
+		}
+
+		instances = append(instances, Instance{
+			ID:          pod.Metadata.Name,
+			Name:        pod.Metadata.Name,
+			State:       strings.ToLower(pod.Status.Phase),
+			HealthState: healthState,
+			Host:        pod.Spec.NodeName,
+		})
+	}
+
+	return instances, nil
+}
+
+// WorkloadHealth : implementação de Orchestrator
+func (k *KubernetesListener) WorkloadHealth(workloadID string) (string, error) {
+	deployment, err := k.client().GetDeployment(workloadID)
+	if err != nil {
+		return "", err
+	}
+
+	return deploymentHealth(deployment), nil
+}
+
+// RestartInstance : implementação de Orchestrator. O pod é removido e
+// recriado pelo ReplicaSet
+func (k *KubernetesListener) RestartInstance(ID string) error {
+	return k.client().DeletePod(ID)
+}
+
+// InstanceLogs : implementação de Orchestrator
+func (k *KubernetesListener) InstanceLogs(ID string, opts LogsOptions) (LogStream, error) {
+	body, err := k.client().StreamPodLogs(ID, kubernetes.LogsOptions{
+		TailLines:    opts.Lines,
+		SinceSeconds: int(opts.Since.Seconds()),
+		Follow:       opts.Follow,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &kubernetesLogStream{body: body, reader: bufio.NewReader(body)}, nil
+}
+
+// kubernetesLogStream lê os logs do pod linha a linha
+type kubernetesLogStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+}
+
+func (s *kubernetesLogStream) Next() (string, error) {
+	line, err := s.reader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (s *kubernetesLogStream) Close() error {
+	return s.body.Close()
+}
+
+// UpgradeWorkload : implementação de Orchestrator. A imagem é trocada no
+// primeiro container do deployment, o que inicia um rolling update
+func (k *KubernetesListener) UpgradeWorkload(ID string, image string) (Workload, error) {
+	client := k.client()
+
+	deployment, err := client.GetDeployment(ID)
+	if err != nil {
+		return Workload{}, err
+	}
+
+	if len(deployment.Spec.Template.Spec.Containers) == 0 {
+		return deploymentToWorkload(deployment), nil
+	}
+
+	container := deployment.Spec.Template.Spec.Containers[0].Name
+	deployment, err = client.SetDeploymentImage(ID, container, strings.TrimPrefix(image, "docker:"))
+
+	return deploymentToWorkload(deployment), err
+}
+
+// ScaleWorkload : implementação de Orchestrator
+func (k *KubernetesListener) ScaleWorkload(ID string, scale int) (Workload, error) {
+	deployment, err := k.client().ScaleDeployment(ID, scale, nil)
+	return deploymentToWorkload(deployment), err
+}
+
+// ActivateWorkload : implementação de Orchestrator. Volta o deployment para
+// as réplicas que ele tinha quando foi desativado
+func (k *KubernetesListener) ActivateWorkload(ID string) (Workload, error) {
+	client := k.client()
+
+	deployment, err := client.GetDeployment(ID)
+	if err != nil {
+		return Workload{}, err
+	}
+
+	if deployment.Spec.Replicas > 0 {
+		return deploymentToWorkload(deployment), nil
+	}
+
+	replicas, err := strconv.Atoi(deployment.Metadata.Annotations[replicasAnnotation])
+	if err != nil || replicas <= 0 {
+		replicas = 1
+	}
+
+	deployment, err = client.ScaleDeployment(ID, replicas, nil)
+
+	return deploymentToWorkload(deployment), err
+}
+
+// DeactivateWorkload : implementação de Orchestrator. O deployment vai para
+// zero réplicas, guardando a escala atual em uma annotation
+func (k *KubernetesListener) DeactivateWorkload(ID string) (Workload, error) {
+	client := k.client()
+
+	deployment, err := client.GetDeployment(ID)
+	if err != nil {
+		return Workload{}, err
+	}
+
+	if deployment.Spec.Replicas == 0 {
+		return deploymentToWorkload(deployment), nil
+	}
+
+	deployment, err = client.ScaleDeployment(ID, 0, map[string]string{
+		replicasAnnotation: strconv.Itoa(deployment.Spec.Replicas),
+	})
+
+	return deploymentToWorkload(deployment), err
+}
+
+func deploymentToWorkload(deployment kubernetes.Deployment) Workload {
+	workload := Workload{
+		ID:          deployment.Metadata.Name,
+		Name:        deployment.Metadata.Name,
+		Group:       deployment.Metadata.Namespace,
+		State:       "active",
+		HealthState: deploymentHealth(deployment),
+		Scale:       deployment.Spec.Replicas,
+	}
+
+	if deployment.Spec.Replicas == 0 {
+		workload.State = "inactive"
+	}
+
+	if len(deployment.Spec.Template.Spec.Containers) > 0 {
+		workload.Image = deployment.Spec.Template.Spec.Containers[0].Image
+	}
+
+	return workload
+}
+
+// deploymentHealth traduz o status do deployment para os health states do Rancher 1.6
+func deploymentHealth(deployment kubernetes.Deployment) string {
+	switch {
+	case deployment.Spec.Replicas == 0:
+		return "inactive"
+	case deployment.Status.ObservedGeneration < deployment.Metadata.Generation:
+		return "initializing"
+	case deployment.Status.ReadyReplicas >= deployment.Spec.Replicas:
+		return "healthy"
+	case deployment.Status.ReadyReplicas == 0:
+		return "unhealthy"
+	}
+
+	return "degraded"
+}
@@ -0,0 +1,375 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/rancher"
+)
+
+const (
+	// taskEventDebounce agrupa os vários eventos de uma mesma transição
+	// (serviço e containers) em uma única verificação da task
+	taskEventDebounce = 5 * time.Second
+
+	// taskSubscribeRetry é o tempo de espera para reconectar no websocket de eventos
+	taskSubscribeRetry = 30 * time.Second
+
+	// maxRecoveryAttempts é quantas vezes os containers de uma task com restart
+	// habilitado são recuperados (restart e depois remoção) em uma mesma falha
+	maxRecoveryAttempts = 2
+)
+
+var (
+	taskWatchers      = map[string]*taskWatcher{}
+	taskWatchersMutex sync.Mutex
+)
+
+// taskWatcher recebe os eventos resource.change de um environment e verifica
+// as tasks dos serviços que mudaram de estado, sem esperar o próximo polling
+type taskWatcher struct {
+	slack     *SlackListener
+	rancherID uint
+	projectID string
+
+	// listener é montado de novo a cada conexão do websocket, com as chaves
+	// atuais do Rancher
+	listener *RancherListener
+
+	mutex        sync.Mutex
+	checkMutex   sync.Mutex
+	tasks        map[uint]model.Task
+	taskServices map[uint]string
+	states       map[string]string
+	pending      map[string]bool
+	connected    bool
+	closed       bool
+	subscription *rancher.Subscription
+}
+
+// syncTaskWatchers mantém um taskWatcher por environment com tasks de
+// self-healing, fechando os que não têm mais tasks
+func (s *SlackListener) syncTaskWatchers(tasks []model.Task) {
+	groups := map[string][]model.Task{}
+	for _, task := range tasks {
+		if task.IsOnlyCheck {
+			continue
+		}
+
+		key := taskWatcherKey(task)
+		groups[key] = append(groups[key], task)
+	}
+
+	taskWatchersMutex.Lock()
+	defer taskWatchersMutex.Unlock()
+
+	for key, watcher := range taskWatchers {
+		if _, ok := groups[key]; !ok {
+			watcher.close()
+			delete(taskWatchers, key)
+		}
+	}
+
+	for key, group := range groups {
+		watcher, ok := taskWatchers[key]
+		if !ok {
+			var err error
+			if watcher, err = s.newTaskWatcher(group[0]); err != nil {
+				log.Printf("[ERROR] Error on watch the tasks of environment %s\n%s", group[0].RancherProjectID, err)
+				continue
+			}
+			taskWatchers[key] = watcher
+			go watcher.run()
+		}
+
+		watcher.setTasks(group)
+	}
+}
+
+// findTaskWatcher retorna o taskWatcher do environment da task
+func findTaskWatcher(task model.Task) *taskWatcher {
+	taskWatchersMutex.Lock()
+	defer taskWatchersMutex.Unlock()
+
+	return taskWatchers[taskWatcherKey(task)]
+}
+
+func taskWatcherKey(task model.Task) string {
+	return fmt.Sprintf("%d|%s", task.RancherID, task.RancherProjectID)
+}
+
+func (s *SlackListener) newTaskWatcher(task model.Task) (*taskWatcher, error) {
+	listener, err := taskRancherListener(task.RancherID, task.RancherProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &taskWatcher{
+		slack:        s,
+		rancherID:    task.RancherID,
+		projectID:    task.RancherProjectID,
+		listener:     listener,
+		tasks:        map[uint]model.Task{},
+		taskServices: map[uint]string{},
+		states:       map[string]string{},
+		pending:      map[string]bool{},
+	}, nil
+}
+
+// setTasks atualiza as tasks do environment
+func (w *taskWatcher) setTasks(tasks []model.Task) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	current := map[uint]model.Task{}
+	for _, task := range tasks {
+		if old, ok := w.tasks[task.ID]; ok && old.Service != task.Service {
+			delete(w.taskServices, task.ID)
+		}
+		current[task.ID] = task
+	}
+
+	for ID := range w.taskServices {
+		if _, ok := current[ID]; !ok {
+			delete(w.taskServices, ID)
+		}
+	}
+
+	w.tasks = current
+}
+
+// poll busca o serviço da task, guardando o ID dele para os eventos, e
+// verifica a task
+func (w *taskWatcher) poll(task model.Task) {
+	argSplitted := strings.Split(task.Service, "/")
+	if len(argSplitted) < 2 {
+		log.Printf("[ERROR] Service of task %d is not declared right. Right declaration example: stackName/serviceName\n", task.ID)
+		return
+	}
+
+	svc, err := w.rancherListener().FindService(argSplitted[0], argSplitted[1])
+	if err != nil {
+		log.Printf("[ERROR] Error on check task %d\n%s", task.ID, err)
+		return
+	}
+
+	w.mutex.Lock()
+	w.taskServices[task.ID] = svc.ID
+	w.mutex.Unlock()
+
+	w.check(task, svc)
+}
+
+// rancherListener retorna o listener da última conexão
+func (w *taskWatcher) rancherListener() *RancherListener {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.listener
+}
+
+func (w *taskWatcher) isConnected() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.connected
+}
+
+func (w *taskWatcher) check(task model.Task, svc rancher.Service) {
+	w.checkMutex.Lock()
+	defer w.checkMutex.Unlock()
+
+	listener := w.rancherListener()
+
+	release := acquireRancher(w.rancherID)
+	defer release()
+
+	if err := w.slack.checkTask(task, listener, svc); err != nil {
+		log.Printf("[ERROR] Error on check task %d\n%s", task.ID, err)
+	}
+}
+
+// run mantém o websocket de eventos conectado até o watcher ser fechado
+func (w *taskWatcher) run() {
+	for !w.isClosed() {
+		listener, err := taskRancherListener(w.rancherID, w.projectID)
+		if err != nil {
+			log.Printf("[ERROR] Error on get the Rancher of environment %s, using polling\n%s", w.projectID, err)
+			time.Sleep(taskSubscribeRetry)
+			continue
+		}
+
+		subscription, err := listener.client().Subscribe(rancher.EventResourceChange)
+		if err != nil {
+			log.Printf("[ERROR] Error on subscribe to events of environment %s, using polling\n%s", w.projectID, err)
+			time.Sleep(taskSubscribeRetry)
+			continue
+		}
+
+		w.mutex.Lock()
+		if w.closed {
+			w.mutex.Unlock()
+			subscription.Close()
+			return
+		}
+		w.listener = listener
+		w.subscription = subscription
+		w.connected = true
+		w.mutex.Unlock()
+
+		log.Printf("[INFO] Listening events of environment %s\n", w.projectID)
+
+		for {
+			event, err := subscription.Next()
+			if err != nil {
+				if !w.isClosed() {
+					log.Printf("[ERROR] Events of environment %s disconnected, using polling\n%s", w.projectID, err)
+				}
+				break
+			}
+
+			w.handle(event)
+		}
+
+		w.mutex.Lock()
+		w.connected = false
+		w.subscription = nil
+		w.mutex.Unlock()
+	}
+}
+
+// handle agenda a verificação das tasks quando um serviço monitorado, ou um
+// container dele, muda de estado
+func (w *taskWatcher) handle(event rancher.Event) {
+	if event.Name != rancher.EventResourceChange {
+		return
+	}
+
+	var serviceIDs []string
+	var resourceState, state string
+
+	switch event.ResourceType {
+	case "service":
+		svc, err := event.Service()
+		if err != nil {
+			return
+		}
+		serviceIDs = []string{event.ResourceID}
+		resourceState = svc.State
+		state = fmt.Sprintf("%s/%s", svc.State, svc.HealthState)
+	case "container", "instance":
+		container, err := event.Container()
+		if err != nil {
+			return
+		}
+		serviceIDs = container.ServiceIDs
+		resourceState = container.State
+		state = fmt.Sprintf("%s/%s", container.State, container.HealthState)
+	default:
+		return
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	var watched []string
+	for _, serviceID := range serviceIDs {
+		if w.watches(serviceID) {
+			watched = append(watched, serviceID)
+		}
+	}
+
+	// só os estados dos serviços das tasks, e dos containers deles, são
+	// guardados, e os removidos saem do mapa
+	if len(watched) == 0 {
+		delete(w.states, event.ResourceID)
+		return
+	}
+
+	if w.states[event.ResourceID] == state {
+		return
+	}
+
+	if resourceState == "removed" || resourceState == "purged" {
+		delete(w.states, event.ResourceID)
+	} else {
+		w.states[event.ResourceID] = state
+	}
+
+	for _, serviceID := range watched {
+		if w.pending[serviceID] {
+			continue
+		}
+
+		w.pending[serviceID] = true
+
+		serviceID := serviceID
+		time.AfterFunc(taskEventDebounce, func() {
+			w.checkService(serviceID)
+		})
+	}
+}
+
+// checkService verifica as tasks de um serviço depois de um evento
+func (w *taskWatcher) checkService(serviceID string) {
+	w.mutex.Lock()
+	delete(w.pending, serviceID)
+	var tasks []model.Task
+	for ID, taskServiceID := range w.taskServices {
+		if task, ok := w.tasks[ID]; ok && taskServiceID == serviceID {
+			tasks = append(tasks, task)
+		}
+	}
+	closed := w.closed
+	w.mutex.Unlock()
+
+	if closed || len(tasks) == 0 {
+		return
+	}
+
+	svc, err := w.rancherListener().GetService(serviceID)
+	if err != nil {
+		log.Printf("[ERROR] Error on get service %s after event\n%s", serviceID, err)
+		return
+	}
+
+	for _, task := range tasks {
+		w.check(task, svc)
+	}
+}
+
+// watches verifica se alguma task é do serviço. Precisa ser chamado com o mutex
+func (w *taskWatcher) watches(serviceID string) bool {
+	for _, taskServiceID := range w.taskServices {
+		if taskServiceID == serviceID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *taskWatcher) isClosed() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.closed
+}
+
+// close para o watcher quando o environment não tem mais tasks
+func (w *taskWatcher) close() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.closed = true
+	if w.subscription != nil {
+		w.subscription.Close()
+	}
+}
@@ -0,0 +1,146 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// Environment : a Rancher 1.6 environment (project) or a Kubernetes namespace
+type Environment struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Workload : a Rancher 1.6 service or a Kubernetes deployment
+type Workload struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Group       string `json:"group"`
+	State       string `json:"state"`
+	HealthState string `json:"healthState"`
+	Image       string `json:"image"`
+	Scale       int    `json:"scale"`
+}
+
+// Instance : a Rancher 1.6 container or a Kubernetes pod
+type Instance struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	State       string `json:"state"`
+	HealthState string `json:"healthState"`
+	Host        string `json:"host"`
+}
+
+// LogsOptions : the lines of the logs of an instance
+type LogsOptions struct {
+	// Lines from the end of the logs
+	Lines int
+	// Since : only the lines written in this last period, if not zero
+	Since time.Duration
+	// Follow : keeps receiving the new lines until the stream is closed
+	Follow bool
+}
+
+// LogStream : the logs of an instance, line by line
+type LogStream interface {
+	// Next retorna a próxima linha, io.EOF quando os logs acabaram
+	Next() (string, error)
+	// Close fecha a conexão, fazendo um Next bloqueado retornar um erro
+	Close() error
+}
+
+// Orchestrator é a interface com as operações que o BOT faz em um orquestrador,
+// seja um Rancher 1.6 (RancherListener) ou um cluster Kubernetes/Rancher 2.x
+// (KubernetesListener). Health states seguem o vocabulário do Rancher 1.6:
+// healthy, degraded, unhealthy, initializing e inactive
+type Orchestrator interface {
+	// Backend retorna o tipo do orquestrador (model.RancherTypeRancher, model.RancherTypeKubernetes)
+	Backend() string
+
+	// RancherID retorna o ID do Rancher cadastrado, 0 quando ele vem das variáveis de ambiente
+	RancherID() uint
+	// EnvironmentID retorna o environment selecionado (projeto do Rancher 1.6 ou namespace)
+	EnvironmentID() string
+
+	// SetResponseHook define a função chamada com o status de cada requisição à
+	// API, usada para auditar os comandos. nil remove a função
+	SetResponseHook(hook func(method string, url string, status int))
+
+	ListEnvironments() ([]Environment, error)
+	SetEnvironment(ID string)
+
+	ListWorkloads() ([]Workload, error)
+	ListInstances(workloadID string) ([]Instance, error)
+	WorkloadHealth(workloadID string) (string, error)
+
+	RestartInstance(ID string) error
+	// InstanceLogs abre o stream dos logs da instância, que sempre deve ser
+	// fechado por quem chamou
+	InstanceLogs(ID string, opts LogsOptions) (LogStream, error)
+
+	UpgradeWorkload(ID string, image string) (Workload, error)
+	ScaleWorkload(ID string, scale int) (Workload, error)
+	ActivateWorkload(ID string) (Workload, error)
+	DeactivateWorkload(ID string) (Workload, error)
+}
+
+// NewOrchestrator cria o orquestrador de acordo com o tipo do Rancher cadastrado
+func NewOrchestrator(r model.Rancher, projectID string) (Orchestrator, error) {
+	switch r.Type {
+	case model.RancherTypeRancher, "":
+		return &RancherListener{
+			ID:        r.ID,
+			baseURL:   r.URL,
+			accessKey: r.AccessKey,
+			secretKey: r.SecretKey,
+			projectID: projectID,
+		}, nil
+	case model.RancherTypeKubernetes:
+		return &KubernetesListener{
+			ID:        r.ID,
+			baseURL:   r.URL,
+			accessKey: r.AccessKey,
+			secretKey: r.SecretKey,
+			namespace: projectID,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown Rancher type `%s`", r.Type)
+}
+
+// EnvironmentName retorna o nome do environment pelo ID
+func EnvironmentName(o Orchestrator, ID string) (string, error) {
+	environments, err := o.ListEnvironments()
+	if err != nil {
+		return "", err
+	}
+
+	for _, environment := range environments {
+		if environment.ID == ID {
+			return environment.Name, nil
+		}
+	}
+
+	return "", fmt.Errorf("environment `%s` not found", ID)
+}
+
+// findWorkload procura o workload pelo ID entre os do environment
+func findWorkload(o Orchestrator, ID string) (Workload, error) {
+	workloads, err := o.ListWorkloads()
+	if err != nil {
+		return Workload{}, err
+	}
+
+	for _, workload := range workloads {
+		if workload.ID == ID {
+			return workload, nil
+		}
+	}
+
+	return Workload{}, fmt.Errorf("service `%s` not found", ID)
+}
@@ -4,20 +4,15 @@
 package core
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
 	"log"
-	"net/url"
-	"os"
-	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
-	"github.com/rgamba/evtwebsocket"
-	"github.com/tidwall/gjson"
-	"github.com/tidwall/sjson"
+	"github.com/slack-bot-4all/slack-bot/src/haproxy"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/rancher"
+	"github.com/slack-bot-4all/slack-bot/src/service"
 )
 
 // RancherListener é uma estrutura onde ficam armazenados os dados de acesso ao Rancher API
@@ -27,397 +22,546 @@ type RancherListener struct {
 	secretKey string
 	baseURL   string
 	projectID string
-}
 
-// Container é uma estrutura que é usada para mostrar informações ao usuário
-type Container struct {
-	ID          string `json:"id"`
-	ImageUUID   string `json:"imageUuid"`
-	Name        string `json:"name"`
-	State       string `json:"state"`
-	HealthState string `json:"healthState"`
-	HostID 		string `json:"hostId"`
+	onResponse func(method string, url string, status int)
 }
 
-// LoadBalancer é a estrutura que tem como objetivo representar um LoadBalancer do Rancher
-// de forma um pouco mais resumida (é usado na função GetLoadBalancers())
-type LoadBalancer struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+// client retorna o client tipado da API do Rancher com os dados do listener
+func (ranchListener *RancherListener) client() *rancher.Client {
+	client := rancher.NewClient(ranchListener.baseURL, ranchListener.accessKey, ranchListener.secretKey, ranchListener.projectID)
+	client.OnResponse = ranchListener.onResponse
+
+	return client
 }
 
 // RestartContainer : Função responsável por dar restart no container recebido por parâmetro
-func (ranchListener *RancherListener) RestartContainer(containerID string) {
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/containers/%s?action=restart", ranchListener.baseURL, ranchListener.projectID, containerID)
-	resp := ranchListener.HTTPSendRancherRequest(url, PostHTTP, "")
+func (ranchListener *RancherListener) RestartContainer(containerID string) (rancher.Container, error) {
+	container, err := ranchListener.client().RestartContainer(containerID)
+	if err != nil {
+		return container, err
+	}
 
-	idValue := gjson.Get(resp, "id").String()
+	log.Println("[INFO] Container restarted! ID:", container.ID)
 
-	log.Println("[INFO] Container restarted! ID:", idValue)
+	return container, nil
 }
 
 // StartService : Função responsável por dar start no container recebido por parâmetro
-func (ranchListener *RancherListener) StartService(ID string) {
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/services/%s?action=activate", ranchListener.baseURL, ranchListener.projectID, ID)
-	resp := ranchListener.HTTPSendRancherRequest(url, PostHTTP, "")
+func (ranchListener *RancherListener) StartService(ID string) (rancher.Service, error) {
+	service, err := ranchListener.client().ActivateService(ID)
+	if err != nil {
+		return service, err
+	}
 
-	idValue := gjson.Get(resp, "id").String()
+	log.Println("[INFO] Service started! ID:", service.ID)
 
-	log.Println("[INFO] Service started! ID:", idValue)
+	return service, nil
 }
 
 // StopService : Função responsável por dar stop no container recebido por parâmetro
-func (ranchListener *RancherListener) StopService(ID string) {
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/services/%s?action=deactivate", ranchListener.baseURL, ranchListener.projectID, ID)
-	resp := ranchListener.HTTPSendRancherRequest(url, PostHTTP, "")
-
-	idValue := gjson.Get(resp, "id").String()
+func (ranchListener *RancherListener) StopService(ID string) (rancher.Service, error) {
+	service, err := ranchListener.client().DeactivateService(ID)
+	if err != nil {
+		return service, err
+	}
 
-	log.Println("[INFO] Service stopped! ID:", idValue)
+	log.Println("[INFO] Service stopped! ID:", service.ID)
 
+	return service, nil
 }
 
 // ListContainers é uma função que retornará uma lista de todos os containers de um projeto/environment
-func (ranchListener *RancherListener) ListContainers() string {
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/containers?limit=0", ranchListener.baseURL, ranchListener.projectID)
-	resp := ranchListener.HTTPSendRancherRequest(url, GetHTTP, "")
-
-	return resp
+func (ranchListener *RancherListener) ListContainers() ([]rancher.Container, error) {
+	return ranchListener.client().ListContainers()
 }
 
-func (ranchListener * RancherListener) DeleteContainer(ID string) string {
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/containers/%s", ranchListener.baseURL, ranchListener.projectID, ID)
-	resp := ranchListener.HTTPSendRancherRequest(url, DeleteHTTP, "")
+// DeleteContainer : remove o container recebido por parâmetro
+func (ranchListener *RancherListener) DeleteContainer(ID string) (rancher.Container, error) {
+	container, err := ranchListener.client().DeleteContainer(ID)
+	if err != nil {
+		return container, err
+	}
 
-	log.Printf("Container deleted! ID: %s\n", ID)
+	log.Printf("[INFO] Container deleted! ID: %s\n", ID)
 
-	return resp
+	return container, nil
 }
 
 // GetInstances é uma função que retornará uma lista de todas as instâncias de um serviço
-func (ranchListener *RancherListener) GetInstances(serviceID string) string {
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/services/%s/instances", ranchListener.baseURL, ranchListener.projectID, serviceID)
-	resp := ranchListener.HTTPSendRancherRequest(url, GetHTTP, "")
-
-	return resp
+func (ranchListener *RancherListener) GetInstances(serviceID string) ([]rancher.Container, error) {
+	return ranchListener.client().ListServiceInstances(serviceID)
 }
 
-// GetService é uma função que retorna o JSON de uma requisição que busca
-// informações de um único serviço
-func (ranchListener *RancherListener) GetService(ID string) string {
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/services/%s", ranchListener.baseURL, ranchListener.projectID, ID)
-	resp := ranchListener.HTTPSendRancherRequest(url, GetHTTP, "")
-
-	return resp
+// GetService é uma função que busca informações de um único serviço
+func (ranchListener *RancherListener) GetService(ID string) (rancher.Service, error) {
+	return ranchListener.client().GetService(ID)
 }
 
-// GetServiceStack é uma função que retorna o JSON de uma requisição que busca
-// informações da stack de um serviço em específico
-func (ranchListener *RancherListener) GetServiceStack(ID string) string {
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/services/%s/stack", ranchListener.baseURL, ranchListener.projectID, ID)
-	resp := ranchListener.HTTPSendRancherRequest(url, GetHTTP, "")
+// GetServiceStack é uma função que busca informações da stack de um serviço em específico
+func (ranchListener *RancherListener) GetServiceStack(ID string) (rancher.Stack, error) {
+	return ranchListener.client().GetServiceStack(ID)
+}
 
-	return resp
+// GetHostInfo : busca as informações de um host
+func (ranchListener *RancherListener) GetHostInfo(ID string) (rancher.Host, error) {
+	return ranchListener.client().GetHost(ID)
 }
 
-func (ranchListener *RancherListener) GetHostInfo(ID string) string {
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/hosts/%s", ranchListener.baseURL, ranchListener.projectID, ID)
-	resp := ranchListener.HTTPSendRancherRequest(url, GetHTTP, "")
+// GetStacks é uma função que busca todas as stacks do environment
+func (ranchListener *RancherListener) GetStacks() ([]rancher.Stack, error) {
+	return ranchListener.client().ListStacks()
+}
 
-	return resp
+// GetServicesFromStack é uma função que busca todos os serviços de uma stack especificada
+func (ranchListener *RancherListener) GetServicesFromStack(ID string) ([]rancher.Service, error) {
+	return ranchListener.client().ListStackServices(ID)
 }
 
-// GetStacks é uma função que retorna o JSON de uma requisição que busca
-// todas as stacks do environment
-func (ranchListener *RancherListener) GetStacks() string {
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/stacks", ranchListener.baseURL, ranchListener.projectID)
-	resp := ranchListener.HTTPSendRancherRequest(url, GetHTTP, "")
+// FindService : busca um serviço pelo nome da stack e do serviço (stackName/serviceName)
+func (ranchListener *RancherListener) FindService(stackName string, serviceName string) (rancher.Service, error) {
+	stacks, err := ranchListener.GetStacks()
+	if err != nil {
+		return rancher.Service{}, err
+	}
 
-	return resp
-}
+	for _, stack := range stacks {
+		if stack.Name != stackName {
+			continue
+		}
 
-// GetServicesFromStack é uma função que retorna o JSON de uma requisição que busca
-// todos os serviços de uma stack especificada
-func (ranchListener *RancherListener) GetServicesFromStack(ID string) string {
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/stacks/%s/services", ranchListener.baseURL, ranchListener.projectID, ID)
-	resp := ranchListener.HTTPSendRancherRequest(url, GetHTTP, "")
+		services, err := ranchListener.GetServicesFromStack(stack.ID)
+		if err != nil {
+			return rancher.Service{}, err
+		}
 
-	return resp
+		for _, service := range services {
+			if service.Name == serviceName {
+				return service, nil
+			}
+		}
+	}
+
+	return rancher.Service{}, fmt.Errorf("service %s/%s not found", stackName, serviceName)
 }
 
 // UpgradeService é a função que faz o upgrade da imagem do serviço, recebendo
 // como parâmetro o ID do serviço e o nome da nova imagem do serviço
-func (ranchListener *RancherListener) UpgradeService(ID string, newImage string) string {
-	var data interface{}
-	var jsonRequest string
+func (ranchListener *RancherListener) UpgradeService(ID string, newImage string, opts rancher.UpgradeOptions) (rancher.Service, error) {
+	return ranchListener.client().UpgradeService(ID, newImage, opts)
+}
 
-	originalServiceCfg := ranchListener.GetService(ID)
+// FinishUpgradeService : termina o upgrade, removendo os containers da versão anterior
+func (ranchListener *RancherListener) FinishUpgradeService(ID string) (rancher.Service, error) {
+	return ranchListener.client().FinishUpgradeService(ID)
+}
 
-	originalServiceCfg, err := sjson.Set(originalServiceCfg, "launchConfig.imageUuid", newImage)
-	CheckErr("Error to set value of new variable of service JSON", err)
+// RollbackService : volta um serviço upgraded para a versão anterior
+func (ranchListener *RancherListener) RollbackService(ID string) (rancher.Service, error) {
+	return ranchListener.client().RollbackService(ID)
+}
 
-	launchConfigOri := gjson.Get(originalServiceCfg, "launchConfig").String()
+// ListServices é uma função que busca todos os serviços do Environment
+func (ranchListener *RancherListener) ListServices() ([]rancher.Service, error) {
+	return ranchListener.client().ListServices()
+}
 
-	json.Unmarshal([]byte(launchConfigOri), &data)
+// CanaryWeight é o peso de uma referência do haproxy.cfg, que pode ser
+// `backend/server`, `backend` ou parte do nome (ex.: `new` e `old`)
+type CanaryWeight struct {
+	Ref    string
+	Weight int
+}
 
-	jsonRequest, err = sjson.Set(jsonRequest, "inServiceStrategy.launchConfig", data)
-	CheckErr("Error to set value of new variable of service JSON", err)
+const (
+	// canaryNewRef é a referência padrão dos servers da nova versão no haproxy.cfg
+	canaryNewRef = "new"
 
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/services/%s?action=upgrade", ranchListener.baseURL, ranchListener.projectID, ID)
-	resp := ranchListener.HTTPSendRancherRequest(url, PostHTTP, jsonRequest)
+	// canaryOldRef é a referência padrão dos servers da versão antiga no haproxy.cfg
+	canaryOldRef = "old"
+)
 
-	return gjson.Get(resp, "launchConfig.imageUuid").String()
-}
+// DisableCanary é a função que envia a requisição para a API do
+// Rancher com a intenção de comentar todas as diretivas do haproxy.cfg
+func (ranchListener *RancherListener) DisableCanary(ID string, user string) (string, error) {
+	cfg, err := ranchListener.getParsedLbConfig(ID)
+	if err != nil {
+		return "", err
+	}
 
-// ListServices é uma função que retorna o JSON (em string) de uma requisição que tem como
-// objetivo buscar todos os serviços do Environment
-func (ranchListener *RancherListener) ListServices() string {
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/services", ranchListener.baseURL, ranchListener.projectID)
-	resp := ranchListener.HTTPSendRancherRequest(url, GetHTTP, "")
+	previous := cfg.String()
+	cfg.Disable()
 
-	return resp
+	return ranchListener.updateLbConfig(ID, previous, cfg.String(), user, model.LbConfigDisable)
 }
 
-// LogsContainer : Função responsável retornar os logs do container
-func (ranchListener *RancherListener) LogsContainer(containerID string) string {
-	data := &url.Values{}
-	data.Add("follow", "true")
-	data.Add("lines", "1000")
+// EnableCanary é a função que volta as diretivas comentadas pelo DisableCanary
+// depois envia como PUT para a API do Rancher
+func (ranchListener *RancherListener) EnableCanary(ID string, user string) (string, error) {
+	cfg, err := ranchListener.getParsedLbConfig(ID)
+	if err != nil {
+		return "", err
+	}
 
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/containers/%s?action=logs", ranchListener.baseURL, ranchListener.projectID, containerID)
+	previous := cfg.String()
 
-	resp := ranchListener.HTTPSendRancherRequest(url, PostHTTP, `{"follow": true, "lines": 1000}`)
+	// as versões antigas do BOT comentavam com um `#` comum, que não dá para
+	// separar dos comentários do arquivo
+	if cfg.Enable() == 0 && cfg.IsDisabled() {
+		return "", fmt.Errorf("haproxy.cfg of load balancer %s has no line disabled by canary-disable, it was commented by hand or by an older version of the BOT and must be uncommented by hand", ID)
+	}
 
-	tokenValue := gjson.Get(resp, "token").String()
-	urlValue := gjson.Get(resp, "url").String()
+	return ranchListener.updateLbConfig(ID, previous, cfg.String(), user, model.LbConfigEnable)
+}
 
-	urlAndToken := fmt.Sprintf("%s?token=%s", urlValue, tokenValue)
+// UpdateCustomHaproxyCfg Edita os pesos dos servers no lbConfig.config do LB,
+// habilitando o canary caso ele esteja desabilitado
+func (ranchListener *RancherListener) UpdateCustomHaproxyCfg(ID string, weights []CanaryWeight, user string) (string, error) {
+	sum := 0
+	for _, weight := range weights {
+		sum += weight.Weight
+	}
 
-	t := time.Now()
+	if sum != 100 {
+		return "", fmt.Errorf("weights don't sum 100 (%d)", sum)
+	}
 
-	f, err := os.Create(fmt.Sprintf("./logs-container-%d%d%d%02d%02d%02d.log", t.Day(), t.Month(), t.Year(), t.Hour(), t.Minute(), t.Second()))
-	CheckErr("Logs file creation error", err)
+	cfg, err := ranchListener.getParsedLbConfig(ID)
+	if err != nil {
+		return "", err
+	}
 
-	socketConnectionLogsContainer(urlAndToken, f.Name())
+	previous := cfg.String()
+	cfg.Enable()
 
-	return f.Name()
-}
+	for _, weight := range weights {
+		if err := cfg.SetWeight(weight.Ref, weight.Weight); err != nil {
+			return "", err
+		}
+	}
 
-func socketConnectionLogsContainer(urlAndToken string, fileName string) {
-	conn := &evtwebsocket.Conn{
-		OnConnected: func(w *evtwebsocket.Conn) {
-			log.Println("[INFO] Connectec on WebSocket!")
-		},
+	return ranchListener.updateLbConfig(ID, previous, cfg.String(), user, model.LbConfigWeights)
+}
 
-		OnMessage: func(msg []byte, w *evtwebsocket.Conn) {
-			verifyExists, err := os.Open(fileName)
+// RollbackLbConfig volta o lbConfig.config do LB para uma versão salva pelo
+// BOT. Sem versão, volta para a anterior à última alteração
+func (ranchListener *RancherListener) RollbackLbConfig(ID string, version int, user string) (model.LbConfigVersion, string, error) {
+	var target model.LbConfigVersion
 
-			if err == nil {
-				WriteOnFile(fileName, string(msg))
-			}
+	if version == 0 {
+		versions, err := service.ListLbConfigVersions(ranchListener.ID, ranchListener.projectID, ID, 2)
+		if err != nil {
+			return target, "", err
+		}
 
-			defer verifyExists.Close()
-		},
+		if len(versions) < 2 {
+			return target, "", fmt.Errorf("load balancer %s has no previous version", ID)
+		}
 
-		OnError: func(err error) {
-			log.Printf("[ERROR] %s\n", err.Error())
-		},
+		target = versions[1]
+	} else {
+		var err error
+		if target, err = service.FindLbConfigVersion(ranchListener.ID, ranchListener.projectID, ID, version); err != nil {
+			return target, "", err
+		}
 	}
 
-	conn.Dial(urlAndToken, "")
+	resp, err := ranchListener.RestoreLbConfig(ID, target.Config, user)
+
+	return target, resp, err
 }
 
-// DisableCanary é a função que envia a requisição para a API do
-// Rancher com a intenção de comentar todas as linhas do haproxy.cfg
-func (ranchListener *RancherListener) DisableCanary(ID string) string {
-	responseString := ranchListener.GetHaproxyCfg(ID)
-	actualLbConfig := gjson.Get(responseString, "lbConfig.config").String()
+// RestoreLbConfig volta o lbConfig.config do LB para um config salvo antes,
+// como está, sem mexer nos pesos nem habilitar o canary
+func (ranchListener *RancherListener) RestoreLbConfig(ID string, config string, user string) (string, error) {
+	lb, err := ranchListener.GetHaproxyCfg(ID)
+	if err != nil {
+		return "", err
+	}
 
-	if actualLbConfig == "" {
-		return "error"
+	var previous string
+	if lb.LbConfig != nil {
+		previous = lb.LbConfig.Config
 	}
 
-	scanner := bufio.NewScanner(strings.NewReader(actualLbConfig))
+	return ranchListener.updateLbConfig(ID, previous, config, user, model.LbConfigRollback)
+}
 
-	newLbConfig := ""
+// GetHaproxyCfg Busca o LoadBalancer enviado como parâmetro, com a Custom haproxy.cfg
+func (ranchListener *RancherListener) GetHaproxyCfg(ID string) (rancher.LoadBalancerService, error) {
+	return ranchListener.client().GetLoadBalancerService(ID)
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+func (ranchListener *RancherListener) getParsedLbConfig(ID string) (*haproxy.Config, error) {
+	lb, err := ranchListener.GetHaproxyCfg(ID)
+	if err != nil {
+		return nil, err
+	}
 
-		if strings.Contains(line, "#") {
-			newLbConfig += fmt.Sprintf("%s\n", line)
-		} else {
-			newLbConfig += fmt.Sprintf("#%s\n", line)
-		}
+	if lb.LbConfig == nil || lb.LbConfig.Config == "" {
+		return nil, fmt.Errorf("haproxy.cfg of load balancer %s is empty", ID)
 	}
 
-	responseString, err := sjson.Set(responseString, "lbConfig.config", newLbConfig)
-	CheckErr("Error to set new Custom haproxy.cfg on JSON", err)
+	return haproxy.Parse(lb.LbConfig.Config)
+}
+
+// updateLbConfig envia o novo lbConfig.config e salva ele como uma nova
+// versão do LB, com quem alterou e os pesos de antes e depois
+func (ranchListener *RancherListener) updateLbConfig(ID string, previous string, config string, user string, action string) (string, error) {
+	lb, err := ranchListener.client().UpdateLoadBalancerConfig(ID, config)
+	if err != nil {
+		return "", err
+	}
 
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/loadBalancerServices/%s", ranchListener.baseURL, ranchListener.projectID, ID)
-	resp := ranchListener.HTTPSendRancherRequest(url, PutHTTP, responseString)
+	var current string
+	if lb.LbConfig != nil {
+		current = lb.LbConfig.Config
+	}
 
-	return gjson.Get(resp, "lbConfig.config").String()
+	err = service.AddLbConfigVersion(&model.LbConfigVersion{
+		RancherID:      ranchListener.ID,
+		ProjectID:      ranchListener.projectID,
+		LoadBalancerID: ID,
+		Action:         action,
+		User:           user,
+		OldWeights:     canaryWeightsSummary(previous),
+		NewWeights:     canaryWeightsSummary(current),
+		Config:         current,
+	}, previous)
+	CheckErr(fmt.Sprintf("Error on save version of haproxy.cfg of load balancer %s", ID), err)
+
+	return current, nil
 }
 
-// EnableCanary é a função que retira os "#" de todo o haproxy.cfg
-// depois envia como PUT para a API do Rancher
-func (ranchListener *RancherListener) EnableCanary(ID string) string {
-	responseString := ranchListener.GetHaproxyCfg(ID)
-	actualLbConfig := gjson.Get(responseString, "lbConfig.config").String()
+// canaryWeightsSummary descreve os pesos do canary de um haproxy.cfg (ex.: `new=30 old=70`)
+func canaryWeightsSummary(config string) string {
+	cfg, err := haproxy.Parse(config)
+	if err != nil {
+		return "?"
+	}
 
-	if actualLbConfig == "" {
-		return "error"
+	if cfg.IsDisabled() {
+		return "disabled"
 	}
 
-	actualLbConfig = strings.Replace(actualLbConfig, "#", "", -1)
+	var weights []string
+	for _, ref := range []string{canaryNewRef, canaryOldRef} {
+		if weight, err := cfg.Weight(ref); err == nil {
+			weights = append(weights, fmt.Sprintf("%s=%d", ref, weight))
+		}
+	}
 
-	responseString, err := sjson.Set(responseString, "lbConfig.config", actualLbConfig)
-	CheckErr("Error to set new Custom haproxy.cfg on JSON", err)
+	if len(weights) == 0 {
+		return "-"
+	}
 
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/loadBalancerServices/%s", ranchListener.baseURL, ranchListener.projectID, ID)
-	resp := ranchListener.HTTPSendRancherRequest(url, PutHTTP, responseString)
+	return strings.Join(weights, " ")
+}
 
-	return gjson.Get(resp, "lbConfig.config").String()
+// GetLoadBalancers é a função responsável por trazer a lista
+// de LoadBalancers, que pode ser usada para selects na interface
+// do BOT do Slack
+func (ranchListener *RancherListener) GetLoadBalancers() ([]rancher.LoadBalancerService, error) {
+	return ranchListener.client().ListLoadBalancerServices()
 }
 
-// UpdateCustomHaproxyCfg Edita o lbConfig.config do LB
-func (ranchListener *RancherListener) UpdateCustomHaproxyCfg(ID string, newPercent string, oldPercent string) string {
-	newPercentToInteger, _ := strconv.Atoi(newPercent)
-	oldPercentToInteger, _ := strconv.Atoi(oldPercent)
+// GetAllEnvironmentsFromRancher : get all projects from Rancher
+func (ranchListener *RancherListener) GetAllEnvironmentsFromRancher() ([]rancher.Project, error) {
+	return ranchListener.client().ListProjects()
+}
 
-	if (newPercentToInteger + oldPercentToInteger) != 100 {
-		return "error"
+// GetEnvironmentName : returns the name of the project (environment) with the ID
+func (ranchListener *RancherListener) GetEnvironmentName(projectID string) (string, error) {
+	project, err := ranchListener.client().GetProject(projectID)
+	if err != nil {
+		return "", err
 	}
 
-	ranchListener.EnableCanary(ID)
-	responseString := ranchListener.GetHaproxyCfg(ID)
-	actualLbConfig := gjson.Get(responseString, "lbConfig.config").String()
+	return project.Name, nil
+}
 
-	if actualLbConfig == "" {
-		return "error"
+// SearchForLbPercent : Procura pelo percentual atual dos servers da nova e da
+// versão antiga dentro do lbConfig.config
+func (ranchListener *RancherListener) SearchForLbPercent(ID string) (new int, old int, err error) {
+	cfg, err := ranchListener.getParsedLbConfig(ID)
+	if err != nil {
+		return 0, 0, err
 	}
 
-	scanner := bufio.NewScanner(strings.NewReader(actualLbConfig))
+	if new, err = cfg.Weight(canaryNewRef); err != nil {
+		return 0, 0, err
+	}
 
-	var newLbConfig string
-	var lines []string
+	if old, err = cfg.Weight(canaryOldRef); err != nil {
+		return 0, 0, err
+	}
 
-	for scanner.Scan() {
-		lines = strings.Split(scanner.Text(), "\n")
-		if strings.HasPrefix(lines[0], "server") {
-			var n string
-			var o string
-			new := regexp.MustCompile(".+new.+(\\d{2})")
-			old := regexp.MustCompile(".+old.+(\\d{2})")
-			l := new.FindStringSubmatch(scanner.Text())
-			z := old.FindStringSubmatch(scanner.Text())
-			if len(l) >= 2 {
-				n = strings.Replace(scanner.Text(), fmt.Sprintf("weight %s", l[1]), fmt.Sprintf("weight %s", newPercent), 1)
-				if newLbConfig == "" {
-					newLbConfig = strings.Replace(actualLbConfig, scanner.Text(), n, 1)
-				} else {
-					newLbConfig = strings.Replace(newLbConfig, scanner.Text(), n, 1)
-				}
-			}
+	return new, old, nil
+}
 
-			if len(z) >= 2 {
-				o = strings.Replace(scanner.Text(), fmt.Sprintf("weight %s", z[1]), fmt.Sprintf("weight %s", oldPercent), 1)
-				if newLbConfig == "" {
-					newLbConfig = strings.Replace(actualLbConfig, scanner.Text(), o, 1)
-				} else {
-					newLbConfig = strings.Replace(newLbConfig, scanner.Text(), o, 1)
-				}
-			}
+// Backend : implementação de Orchestrator
+func (ranchListener *RancherListener) Backend() string {
+	return model.RancherTypeRancher
+}
 
-		}
+// RancherID : implementação de Orchestrator
+func (ranchListener *RancherListener) RancherID() uint {
+	return ranchListener.ID
+}
 
-	}
+// EnvironmentID : implementação de Orchestrator
+func (ranchListener *RancherListener) EnvironmentID() string {
+	return ranchListener.projectID
+}
 
-	responseString, err := sjson.Set(responseString, "lbConfig.config", newLbConfig)
-	CheckErr("Error to set new Custom haproxy.cfg on JSON", err)
+// SetResponseHook : implementação de Orchestrator
+func (ranchListener *RancherListener) SetResponseHook(hook func(method string, url string, status int)) {
+	ranchListener.onResponse = hook
+}
 
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/loadBalancerServices/%s", ranchListener.baseURL, ranchListener.projectID, ID)
-	resp := ranchListener.HTTPSendRancherRequest(url, PutHTTP, responseString)
+// ListEnvironments : implementação de Orchestrator
+func (ranchListener *RancherListener) ListEnvironments() ([]Environment, error) {
+	projects, err := ranchListener.GetAllEnvironmentsFromRancher()
+	if err != nil {
+		return nil, err
+	}
 
-	return gjson.Get(resp, "lbConfig.config").String()
+	var environments []Environment
+	for _, project := range projects {
+		environments = append(environments, Environment{ID: project.ID, Name: project.Name})
+	}
 
+	return environments, nil
 }
 
-// GetHaproxyCfg Busca a Custom haproxy.cfg do LoadBalancer enviado como parâmetro
-func (ranchListener *RancherListener) GetHaproxyCfg(containerID string) string {
-	url := fmt.Sprintf(ranchListener.baseURL + "/v2-beta/projects/" + ranchListener.projectID + "/loadBalancerServices/" + containerID)
-	resp := ranchListener.HTTPSendRancherRequest(url, GetHTTP, "")
+// SetEnvironment : implementação de Orchestrator
+func (ranchListener *RancherListener) SetEnvironment(ID string) {
+	ranchListener.projectID = ID
+}
 
-	if gjson.Get(resp, "id").String() != containerID {
-		return ""
+// ListWorkloads : implementação de Orchestrator
+func (ranchListener *RancherListener) ListWorkloads() ([]Workload, error) {
+	services, err := ranchListener.ListServices()
+	if err != nil {
+		return nil, err
 	}
 
-	return resp
+	var workloads []Workload
+	for _, service := range services {
+		workloads = append(workloads, serviceToWorkload(service))
+	}
+
+	return workloads, nil
 }
 
-// GetLoadBalancers é a função responsável por trazer um slice
-// de LoadBalancer, que pode ser usado para selects na interface
-// do BOT do Slack
-func (ranchListener *RancherListener) GetLoadBalancers() []*LoadBalancer {
-	url := fmt.Sprintf("%s/v2-beta/projects/%s/loadBalancerServices", ranchListener.baseURL, ranchListener.projectID)
-	resp := ranchListener.HTTPSendRancherRequest(url, GetHTTP, "")
+// ListInstances : implementação de Orchestrator
+func (ranchListener *RancherListener) ListInstances(workloadID string) ([]Instance, error) {
+	containers, err := ranchListener.GetInstances(workloadID)
+	if err != nil {
+		return nil, err
+	}
 
-	loadBalancersSlice := []*LoadBalancer{}
+	var instances []Instance
+	for _, container := range containers {
+		instances = append(instances, Instance{
+			ID:          container.ID,
+			Name:        container.Name,
+			State:       container.State,
+			HealthState: container.HealthState,
+			Host:        container.HostID,
+		})
+	}
 
-	data := gjson.Get(resp, "data")
-	data.ForEach(func(key, value gjson.Result) bool {
-		lb := new(LoadBalancer)
-		lb.ID = value.Get("id").String()
-		lb.Name = value.Get("name").String()
-		loadBalancersSlice = append(loadBalancersSlice, lb)
+	return instances, nil
+}
 
-		return true
-	})
+// WorkloadHealth : implementação de Orchestrator
+func (ranchListener *RancherListener) WorkloadHealth(workloadID string) (string, error) {
+	service, err := ranchListener.GetService(workloadID)
+	if err != nil {
+		return "", err
+	}
 
-	return loadBalancersSlice
+	return service.HealthState, nil
 }
 
-// GetAllEnvironmentsFromRancher : get all projects from Rancher
-func (RanchListener *RancherListener) GetAllEnvironmentsFromRancher() string {
-	url := fmt.Sprintf("%s/v2-beta/projects", RanchListener.baseURL)
-	resp := RanchListener.HTTPSendRancherRequest(url, GetHTTP, "")
-
-	return resp
+// RestartInstance : implementação de Orchestrator
+func (ranchListener *RancherListener) RestartInstance(ID string) error {
+	_, err := ranchListener.RestartContainer(ID)
+	return err
 }
 
-// SearchForLbPercent : Procura pelo percentual atual do LB dentro do lbConfig.config
-func (ranchListener *RancherListener) SearchForLbPercent(ID string) (new string, old string) {
-	var (
-		returnNew string
-		returnOld string
-		lines     []string
-	)
+// InstanceLogs : implementação de Orchestrator
+func (ranchListener *RancherListener) InstanceLogs(ID string, opts LogsOptions) (LogStream, error) {
+	stream, err := ranchListener.client().StreamContainerLogs(ID, rancher.LogsOptions{Follow: opts.Follow, Lines: opts.Lines})
+	if err != nil {
+		return nil, err
+	}
 
-	ranchListener.EnableCanary(ID)
-	responseString := ranchListener.GetHaproxyCfg(ID)
-	actualLbConfig := gjson.Get(responseString, "lbConfig.config").String()
+	logs := &rancherLogStream{LogStream: stream}
+	if opts.Since > 0 {
+		logs.since = time.Now().Add(-opts.Since)
+	}
 
-	scanner := bufio.NewScanner(strings.NewReader(actualLbConfig))
+	return logs, nil
+}
 
-	for scanner.Scan() {
-		lines = strings.Split(scanner.Text(), "\n")
-		if strings.HasPrefix(lines[0], "server") {
-			new := regexp.MustCompile(".+new.+(\\d{2})")
-			old := regexp.MustCompile(".+old.+(\\d{2})")
-			l := new.FindStringSubmatch(scanner.Text())
-			z := old.FindStringSubmatch(scanner.Text())
+// rancherLogStream descarta as linhas antes do since, o Rancher 1.6 não
+// filtra os logs pelo tempo
+type rancherLogStream struct {
+	*rancher.LogStream
+	since time.Time
+}
 
-			if len(l) == 2 {
-				returnNew = l[1]
-			}
+func (s *rancherLogStream) Next() (string, error) {
+	for {
+		line, err := s.LogStream.Next()
+		if err != nil {
+			return "", err
+		}
 
-			if len(z) == 2 {
-				returnOld = z[1]
-			}
+		if line.Time.IsZero() || !line.Time.Before(s.since) {
+			return line.Text, nil
 		}
 	}
+}
 
-	return returnNew, returnOld
+// ExecContainer executa o comando no container e abre o websocket da saída
+func (ranchListener *RancherListener) ExecContainer(ID string, command []string) (*rancher.ExecStream, error) {
+	return ranchListener.client().StreamContainerExec(ID, command)
+}
 
+// UpgradeWorkload : implementação de Orchestrator
+func (ranchListener *RancherListener) UpgradeWorkload(ID string, image string) (Workload, error) {
+	service, err := ranchListener.UpgradeService(ID, image, rancher.UpgradeOptions{})
+	return serviceToWorkload(service), err
+}
+
+// ScaleWorkload : implementação de Orchestrator
+func (ranchListener *RancherListener) ScaleWorkload(ID string, scale int) (Workload, error) {
+	service, err := ranchListener.client().ScaleService(ID, scale)
+	return serviceToWorkload(service), err
+}
+
+// ActivateWorkload : implementação de Orchestrator
+func (ranchListener *RancherListener) ActivateWorkload(ID string) (Workload, error) {
+	service, err := ranchListener.StartService(ID)
+	return serviceToWorkload(service), err
+}
+
+// DeactivateWorkload : implementação de Orchestrator
+func (ranchListener *RancherListener) DeactivateWorkload(ID string) (Workload, error) {
+	service, err := ranchListener.StopService(ID)
+	return serviceToWorkload(service), err
+}
+
+func serviceToWorkload(service rancher.Service) Workload {
+	return Workload{
+		ID:          service.ID,
+		Name:        service.Name,
+		Group:       service.StackID,
+		State:       service.State,
+		HealthState: service.HealthState,
+		Image:       service.LaunchConfig.ImageUUID(),
+		Scale:       service.Scale,
+	}
 }
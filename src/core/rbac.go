@@ -0,0 +1,118 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+// authorizeCommand verifica se o usuário pode executar um comando Restricted no
+// Rancher e environment selecionados. Os user groups do Slack só são consultados
+// quando o usuário não tem o comando em um Role próprio. Tentativas negadas são
+// explicadas ao usuário e registradas no log de auditoria
+func (s *SlackListener) authorizeCommand(ev *slack.MessageEvent, cmd Command, event *model.AuditEvent) bool {
+	allowed, err := s.commandAllowed(cmd.Cmd, ev.User, s.context.rancherID, s.context.projectID)
+	if err != nil {
+		saveAuditEvent(event, model.AuditError, fmt.Sprintf("check of permissions failed: %s", err))
+		s.postError(ev, fmt.Sprintf("Error on check the permissions of `%s`", cmd.Cmd), err)
+		return false
+	}
+
+	if allowed {
+		return true
+	}
+
+	scope := s.context.String()
+
+	saveAuditEvent(event, model.AuditDenied, fmt.Sprintf("no role with `%s` on %s", cmd.Cmd, scope))
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("%s, you are not allowed to run `%s` on %s. Ask an admin for a role with this command (role bindings are managed on `/v1/role-bindings`)", mentionUser(ev.User), cmd.Cmd, scope), false))
+
+	return false
+}
+
+// authorizeObject verifica se o usuário pode executar um comando Global no
+// Rancher e environment do objeto que ele altera (uma task, um silence), que
+// não precisam ser os selecionados. A recusa é explicada e fica no audit
+func (s *SlackListener) authorizeObject(ev *slack.MessageEvent, command string, object string, rancherID uint, projectID string) bool {
+	allowed, err := s.commandAllowed(command, ev.User, rancherID, projectID)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on check the permissions of `%s`", command), err)
+		return false
+	}
+
+	if !allowed {
+		s.denyCommand(ev, fmt.Sprintf("%s, you are not allowed to run `%s` on %s, it is on %s", mentionUser(ev.User), command, object, scopeName(rancherID, projectID)))
+	}
+
+	return allowed
+}
+
+// authorizeGlobal verifica se o usuário pode executar um comando Global que
+// vale para todos os Ranchers, o que só um Role em todos os Ranchers e
+// environments permite
+func (s *SlackListener) authorizeGlobal(ev *slack.MessageEvent, command string) bool {
+	allowed, err := s.withUserGroups(ev.User, func(groups []string) (bool, error) {
+		return service.GlobalCommandAllowed(command, ev.User, groups)
+	})
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on check the permissions of `%s`", command), err)
+		return false
+	}
+
+	if !allowed {
+		s.denyCommand(ev, fmt.Sprintf("%s, `%s` acts on all Ranchers, it needs a role with this command on all Ranchers and environments", mentionUser(ev.User), command))
+	}
+
+	return allowed
+}
+
+// commandAllowed verifica se o usuário tem um Role com o comando no Rancher e
+// environment, diretamente ou por um dos seus user groups
+func (s *SlackListener) commandAllowed(command string, user string, rancherID uint, projectID string) (bool, error) {
+	return s.withUserGroups(user, func(groups []string) (bool, error) {
+		return service.CommandAllowed(command, user, groups, rancherID, projectID)
+	})
+}
+
+// withUserGroups verifica os Roles do usuário e, se eles não permitem, os dos
+// seus user groups. Os usuários da API não têm user groups
+func (s *SlackListener) withUserGroups(user string, allowed func(groups []string) (bool, error)) (bool, error) {
+	ok, err := allowed(nil)
+	if err != nil || ok || strings.HasPrefix(user, model.APIUserPrefix) {
+		return ok, err
+	}
+
+	groups, err := s.userGroups(user)
+	if err != nil || len(groups) == 0 {
+		return false, err
+	}
+
+	return allowed(groups)
+}
+
+// userGroups retorna os user groups do Slack dos quais o usuário faz parte
+func (s *SlackListener) userGroups(user string) ([]string, error) {
+	userGroups, err := s.client.GetUserGroups(slack.GetUserGroupsOptionIncludeUsers(true))
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []string
+	for _, group := range userGroups {
+		for _, member := range group.Users {
+			if member == user {
+				groups = append(groups, group.ID)
+				break
+			}
+		}
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,242 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/slack-bot-4all/slack-bot/src/cron"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+const (
+	// defaultTaskInterval é o intervalo das tasks de self-healing sem --every/--cron
+	// enquanto os eventos do environment estão desconectados ou o serviço não está healthy
+	defaultTaskInterval = 90 * time.Second
+
+	// taskReconcileInterval é o intervalo das tasks de self-healing sem
+	// --every/--cron quando os eventos estão conectados, só para pegar
+	// qualquer mudança que não tenha chegado pelo websocket
+	taskReconcileInterval = 15 * time.Minute
+
+	// defaultStatusInterval é o intervalo das tasks de service-status sem --every/--cron
+	defaultStatusInterval = time.Hour
+
+	// schedulerTick é de quanto em quanto tempo o scheduler procura tasks para executar
+	schedulerTick = 5 * time.Second
+
+	// schedulerReload é de quanto em quanto tempo as tasks e Ranchers são relidos do banco
+	schedulerReload = 30 * time.Second
+)
+
+var (
+	rancherSlots      = map[uint]chan struct{}{}
+	rancherSlotsMutex sync.Mutex
+)
+
+// taskScheduler executa cada task na sua própria cadência (--every, --cron ou
+// o padrão do tipo da task)
+type taskScheduler struct {
+	slack *SlackListener
+
+	mutex   sync.Mutex
+	next    map[uint]time.Time
+	running map[uint]bool
+}
+
+// runTaskScheduler é o loop do scheduler, iniciado pelo StartBot
+func (s *SlackListener) runTaskScheduler() {
+	scheduler := &taskScheduler{
+		slack:   s,
+		next:    map[uint]time.Time{},
+		running: map[uint]bool{},
+	}
+
+	var tasks []model.Task
+	var loadedAt time.Time
+
+	for {
+		if time.Since(loadedAt) >= schedulerReload {
+			if loaded, err := service.ListTask(); err != nil {
+				log.Println("[ERROR] Error on execute task check, no response from database")
+			} else {
+				tasks = activeTasks(loaded)
+				s.syncTaskWatchers(tasks)
+			}
+
+			if ranchers, err := service.ListRancher(); err != nil {
+				CheckErr("Error on list Ranchers", err)
+			} else {
+				setRancherLimits(ranchers)
+			}
+
+			loadedAt = time.Now()
+		}
+
+		scheduler.runDue(tasks, time.Now())
+
+		time.Sleep(schedulerTick)
+	}
+}
+
+// activeTasks tira as tasks pausadas, que não são verificadas nem pelo polling
+// nem pelos eventos até serem retomadas
+func activeTasks(tasks []model.Task) []model.Task {
+	var active []model.Task
+	for _, task := range tasks {
+		if !task.Paused {
+			active = append(active, task)
+		}
+	}
+
+	return active
+}
+
+// runDue inicia as tasks que chegaram na hora e não estão rodando
+func (t *taskScheduler) runDue(tasks []model.Task, now time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	current := map[uint]bool{}
+	for _, task := range tasks {
+		current[task.ID] = true
+
+		next, ok := t.next[task.ID]
+		if !ok {
+			// tasks com cron esperam o horário, as outras rodam assim que são criadas
+			next = now
+			if task.Cron != "" {
+				next = t.nextRun(task, now)
+			}
+			t.next[task.ID] = next
+		}
+
+		if t.running[task.ID] || next.IsZero() || now.Before(next) {
+			continue
+		}
+
+		t.running[task.ID] = true
+
+		go func(task model.Task) {
+			t.run(task)
+
+			next := t.nextRun(task, time.Now())
+
+			t.mutex.Lock()
+			t.running[task.ID] = false
+			t.next[task.ID] = next
+			t.mutex.Unlock()
+		}(task)
+	}
+
+	for ID := range t.next {
+		if !current[ID] && !t.running[ID] {
+			delete(t.next, ID)
+			delete(t.running, ID)
+		}
+	}
+}
+
+func (t *taskScheduler) run(task model.Task) {
+	if task.IsOnlyCheck {
+		t.slack.reportTask(task)
+		return
+	}
+
+	watcher := findTaskWatcher(task)
+	if watcher == nil {
+		return
+	}
+
+	watcher.poll(task)
+}
+
+// nextRun calcula a próxima execução da task
+func (t *taskScheduler) nextRun(task model.Task, now time.Time) time.Time {
+	if task.Cron != "" {
+		schedule, err := cron.Parse(task.Cron)
+		if err != nil {
+			log.Printf("[ERROR] Invalid cron of task %d\n%s", task.ID, err)
+			return time.Time{}
+		}
+
+		return schedule.Next(now)
+	}
+
+	if task.IntervalSeconds > 0 {
+		return now.Add(time.Duration(task.IntervalSeconds) * time.Second)
+	}
+
+	if task.IsOnlyCheck {
+		return now.Add(defaultStatusInterval)
+	}
+
+	// serviços fora do healthy continuam no polling, porque os eventos só
+	// chegam nas mudanças e os thresholds contam verificações
+	if watcher := findTaskWatcher(task); watcher != nil && watcher.isConnected() {
+		if health, err := service.GetTaskHealth(task.ID); err == nil && health.State == model.HealthHealthy && !health.Flapping {
+			return now.Add(taskReconcileInterval)
+		}
+	}
+
+	return now.Add(defaultTaskInterval)
+}
+
+// taskScheduleDescription descreve a cadência da task para as mensagens
+func taskScheduleDescription(task model.Task) string {
+	switch {
+	case task.Paused:
+		return "paused"
+	case task.Cron != "":
+		return fmt.Sprintf("cron `%s`", task.Cron)
+	case task.IntervalSeconds > 0:
+		return fmt.Sprintf("every `%s`", time.Duration(task.IntervalSeconds)*time.Second)
+	case task.IsOnlyCheck:
+		return fmt.Sprintf("every `%s`", defaultStatusInterval)
+	}
+
+	return "on events"
+}
+
+// setRancherLimits atualiza o número de verificações simultâneas de cada Rancher
+func setRancherLimits(ranchers []model.Rancher) {
+	rancherSlotsMutex.Lock()
+	defer rancherSlotsMutex.Unlock()
+
+	for _, rancher := range ranchers {
+		limit := rancher.MaxConcurrency
+		if limit <= 0 {
+			limit = model.DefaultMaxConcurrency
+		}
+
+		if slots, ok := rancherSlots[rancher.ID]; ok && cap(slots) == limit {
+			continue
+		}
+
+		rancherSlots[rancher.ID] = make(chan struct{}, limit)
+	}
+}
+
+// acquireRancher espera uma vaga nas verificações simultâneas do Rancher e
+// retorna a função que libera a vaga. O Rancher das variáveis de ambiente (ID
+// 0) usa o limite padrão
+func acquireRancher(rancherID uint) func() {
+	rancherSlotsMutex.Lock()
+	slots, ok := rancherSlots[rancherID]
+	if !ok {
+		slots = make(chan struct{}, model.DefaultMaxConcurrency)
+		rancherSlots[rancherID] = slots
+	}
+	rancherSlotsMutex.Unlock()
+
+	slots <- struct{}{}
+
+	return func() {
+		<-slots
+	}
+}
@@ -0,0 +1,310 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+const (
+	// scaleMaxFor é o maior --for aceito
+	scaleMaxFor = 24 * time.Hour
+
+	// scaleWaitTimeout é quanto tempo o BOT espera os containers ficarem
+	// running e healthy depois de um scale
+	scaleWaitTimeout = 5 * time.Minute
+
+	// scaleCheckInterval é o intervalo em que os containers são verificados
+	// enquanto o BOT espera o scale
+	scaleCheckInterval = 10 * time.Second
+
+	// scaleRevertCheckInterval é o intervalo em que o BOT procura scales
+	// temporários para reverter
+	scaleRevertCheckInterval = 30 * time.Second
+)
+
+// scaleRevertMutex evita que dois ciclos revertam o mesmo scale
+var scaleRevertMutex sync.Mutex
+
+func (s *SlackListener) slackScaleService(ev *slack.MessageEvent, args CommandArgs) {
+	name := args.String("stackName/serviceName")
+	stackName, serviceName := args.Service("stackName/serviceName")
+	duration := args.Duration("for")
+
+	if duration > scaleMaxFor {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("`--for` must be at most %s", scaleMaxFor), false))
+		return
+	}
+
+	svc, err := s.rancherListener.FindService(stackName, serviceName)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on find service `%s`", name), err)
+		return
+	}
+
+	scale, err := parseScale(args.String("scale"), svc.Scale)
+	if err != nil {
+		s.reply(ev, slack.MsgOptionText(err.Error(), false))
+		return
+	}
+
+	rancherID := s.orchestrator.RancherID()
+	environment := s.rancherListener.projectID
+
+	limit, err := service.EnvironmentScaleLimit(rancherID, environment)
+	if err != nil {
+		s.postError(ev, "Error on get the scale limit of the environment", err)
+		return
+	}
+
+	if scale < limit.Min || scale > limit.Max {
+		s.denyCommand(ev, fmt.Sprintf("The scale of the services of environment `%s` must be between %d and %d, `%s` would go to %d", environment, limit.Min, limit.Max, name, scale))
+		return
+	}
+
+	pending, timed, err := service.FindPendingScaleRevert(rancherID, svc.ID)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on get the timed scale of `%s`", name), err)
+		return
+	}
+
+	// um novo --for mantém o scale de antes do primeiro, que é o que volta
+	previous := svc.Scale
+	if timed && duration > 0 {
+		previous = pending.Scale
+	}
+
+	if scale == svc.Scale && (duration == 0 || scale == previous) {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("`%s` is already at scale %d", name, scale), false))
+		return
+	}
+
+	if _, err := s.rancherListener.ScaleWorkload(svc.ID, scale); err != nil {
+		s.postError(ev, fmt.Sprintf("Error on scale service `%s`", name), err)
+		return
+	}
+
+	msg := fmt.Sprintf(":bar_chart: %s scaled `%s` from %d to %d", mentionUser(ev.User), name, svc.Scale, scale)
+
+	if timed {
+		CheckErr(fmt.Sprintf("Error on cancel the timed scale of %s", name), service.FinishScaleRevert(&pending, model.ScaleRevertCanceled, fmt.Sprintf("scaled again to %d by %s", scale, ev.User)))
+		if duration == 0 {
+			msg += fmt.Sprintf(", the timed scale that would go back to %d was canceled", pending.Scale)
+		}
+	}
+
+	var revert *model.ScaleRevert
+	if duration > 0 {
+		revert = &model.ScaleRevert{
+			RancherID:      rancherID,
+			ProjectID:      environment,
+			ServiceID:      svc.ID,
+			ServiceName:    name,
+			Scale:          previous,
+			TemporaryScale: scale,
+			RevertAt:       time.Now().Add(duration),
+			User:           ev.User,
+			Channel:        ev.Channel,
+			ThreadTS:       ev.ThreadTimestamp,
+		}
+
+		msg += fmt.Sprintf(" for %s, it goes back to %d at %s", duration, previous, revert.RevertAt.Format("2006-01-02 15:04"))
+	}
+
+	// a API não espera os containers, a resposta volta logo
+	if s.output != nil {
+		s.saveScaleRevert(ev, revert)
+		s.reply(ev, slack.MsgOptionText(msg, false))
+		return
+	}
+
+	// os slash commands recebem só o resultado, o progresso vai em uma thread
+	thread := ""
+	if s.responseURL == "" {
+		_, ts, err := s.client.PostMessage(ev.Channel, threadOptions(ev.ThreadTimestamp, slack.MsgOptionText(msg+"\n_The progress is sent on this thread_", false))...)
+		if err != nil {
+			s.postError(ev, fmt.Sprintf("Error on send the progress of the scale of `%s`", name), err)
+			return
+		}
+
+		thread = ev.ThreadTimestamp
+		if thread == "" {
+			thread = ts
+		}
+	} else {
+		s.reply(ev, slack.MsgOptionText(msg, false))
+	}
+
+	if revert != nil && revert.ThreadTS == "" {
+		revert.ThreadTS = thread
+	}
+	s.saveScaleRevert(ev, revert)
+
+	listener := s.rancherListener
+	go s.watchScale(listener, svc.ID, name, scale, func(text string, done bool) {
+		switch {
+		case thread != "":
+			s.client.PostMessage(ev.Channel, slack.MsgOptionText(text, false), slack.MsgOptionTS(thread))
+		case done:
+			s.reply(ev, slack.MsgOptionText(text, false))
+		}
+	})
+}
+
+// saveScaleRevert agenda a volta do scale do --for
+func (s *SlackListener) saveScaleRevert(ev *slack.MessageEvent, revert *model.ScaleRevert) {
+	if revert == nil {
+		return
+	}
+
+	if err := service.AddScaleRevert(revert); err != nil {
+		s.postError(ev, fmt.Sprintf("Error on schedule the revert of the scale of `%s`, it must be reverted by hand", revert.ServiceName), err)
+	}
+}
+
+// parseScale lê o novo scale, absoluto (5) ou relativo ao atual (+2, -1)
+func parseScale(value string, current int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("`%s` is not a valid scale, use a number (`5`) or a change of the current scale (`+2`, `-1`)", value)
+	}
+
+	if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+		n += current
+	}
+
+	if n < 0 {
+		return 0, fmt.Errorf("the scale can't be negative, the current scale is %d", current)
+	}
+
+	return n, nil
+}
+
+// watchScale verifica os containers do serviço a cada scaleCheckInterval até
+// todos estarem running e healthy ou o scaleWaitTimeout. O progresso é enviado
+// quando muda e o resultado com done
+func (s *SlackListener) watchScale(listener *RancherListener, serviceID string, name string, scale int, notify func(text string, done bool)) {
+	deadline := time.Now().Add(scaleWaitTimeout)
+	progress := ""
+
+	for {
+		time.Sleep(scaleCheckInterval)
+
+		containers, err := listener.GetInstances(serviceID)
+		if err != nil {
+			notify(fmt.Sprintf(":warning: Error on check the containers of `%s`: %s", name, err), true)
+			return
+		}
+
+		var running, healthy int
+		for _, container := range containers {
+			if container.State != "running" {
+				continue
+			}
+			running++
+
+			if isHealthy(container.HealthState) {
+				healthy++
+			}
+		}
+
+		current := fmt.Sprintf("%d containers, %d/%d running and %d/%d healthy", len(containers), running, scale, healthy, scale)
+
+		if len(containers) == scale && running == scale && healthy == scale {
+			notify(fmt.Sprintf(":white_check_mark: `%s` reached scale %d, all containers are running and healthy", name, scale), true)
+			return
+		}
+
+		if time.Now().After(deadline) {
+			notify(fmt.Sprintf(":warning: `%s` didn't reach scale %d in %s: %s", name, scale, scaleWaitTimeout, current), true)
+			return
+		}
+
+		if current != progress {
+			notify(fmt.Sprintf(":hourglass_flowing_sand: `%s`: %s", name, current), false)
+			progress = current
+		}
+	}
+}
+
+// isHealthy verifica o health state de um serviço ou container. Os que não
+// têm health check não têm health state
+func isHealthy(healthState string) bool {
+	return healthState == "" || healthState == "healthy"
+}
+
+// revertTimedScales volta os serviços dos scales com --for que acabaram
+func (s *SlackListener) revertTimedScales() {
+	scaleRevertMutex.Lock()
+	defer scaleRevertMutex.Unlock()
+
+	reverts, err := service.ListDueScaleReverts(time.Now())
+	if err != nil {
+		CheckErr("Error on list timed scales", err)
+		return
+	}
+
+	for i := range reverts {
+		s.revertTimedScale(&reverts[i])
+	}
+}
+
+// revertTimedScale volta o serviço para o scale de antes do --for, a não ser
+// que ele tenha sido alterado fora do BOT nesse tempo
+func (s *SlackListener) revertTimedScale(revert *model.ScaleRevert) {
+	notify := func(text string, done bool) {
+		_, _, err := s.client.PostMessage(revert.Channel, threadOptions(revert.ThreadTS, slack.MsgOptionText(text, false))...)
+		CheckErr(fmt.Sprintf("Error on send the revert of the scale of %s", revert.ServiceName), err)
+	}
+
+	fail := func(err error) {
+		CheckErr(fmt.Sprintf("Error on revert the scale of %s", revert.ServiceName), service.FinishScaleRevert(revert, model.ScaleRevertFailed, err.Error()))
+		notify(fmt.Sprintf(":x: The timed scale of `%s` ended but it couldn't go back to %d, it must be reverted by hand\nError: %s", revert.ServiceName, revert.Scale, err), true)
+	}
+
+	listener, err := taskRancherListener(revert.RancherID, revert.ProjectID)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	svc, err := listener.GetService(revert.ServiceID)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	if svc.Scale != revert.TemporaryScale {
+		detail := fmt.Sprintf("the scale was changed to %d outside of the BOT", svc.Scale)
+		CheckErr(fmt.Sprintf("Error on cancel the timed scale of %s", revert.ServiceName), service.FinishScaleRevert(revert, model.ScaleRevertCanceled, detail))
+		notify(fmt.Sprintf(":information_source: The timed scale of `%s` ended, but %s, so it was kept", revert.ServiceName, detail), true)
+		return
+	}
+
+	_, err = listener.ScaleWorkload(revert.ServiceID, revert.Scale)
+	recordSystemAudit(auditSystemScale, model.AuditEvent{
+		Channel:   revert.Channel,
+		Command:   scaleService,
+		RancherID: revert.RancherID,
+		ProjectID: revert.ProjectID,
+		Resource:  revert.ServiceID,
+	}, err, fmt.Sprintf("%s back from %d to %d, --for of %s ended", revert.ServiceName, revert.TemporaryScale, revert.Scale, revert.User))
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	CheckErr(fmt.Sprintf("Error on finish the timed scale of %s", revert.ServiceName), service.FinishScaleRevert(revert, model.ScaleRevertDone, ""))
+	notify(fmt.Sprintf(":rewind: The timed scale of `%s` by %s ended, scaled back from %d to %d", revert.ServiceName, mentionUser(revert.User), revert.TemporaryScale, revert.Scale), false)
+
+	go s.watchScale(listener, revert.ServiceID, revert.ServiceName, revert.Scale, notify)
+}
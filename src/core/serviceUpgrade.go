@@ -0,0 +1,370 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/rancher"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+const (
+	// defaultUpgradeBatchSize e defaultUpgradeInterval são os padrões do
+	// Rancher 1.6 para o in-service upgrade
+	defaultUpgradeBatchSize = 1
+	defaultUpgradeInterval  = 2 * time.Second
+
+	// upgradeMaxAutoFinish é o maior --auto-finish-after aceito
+	upgradeMaxAutoFinish = 2 * time.Hour
+
+	// upgradeCheckInterval é o intervalo em que os upgrades são verificados
+	upgradeCheckInterval = 10 * time.Second
+
+	// upgradeTrackTimeout é por quanto tempo um upgrade é acompanhado, depois
+	// dele precisa ser terminado ou revertido pelos comandos ou no Rancher
+	upgradeTrackTimeout = 24 * time.Hour
+)
+
+// upgradeMutex evita que o acompanhamento e os comandos alterem o mesmo upgrade
+var upgradeMutex sync.Mutex
+
+// startServiceUpgrade inicia o in-service upgrade de um serviço do Rancher 1.6
+// e o acompanha até ele ser terminado ou revertido
+func (s *SlackListener) startServiceUpgrade(ev *slack.MessageEvent, serviceID string, image string, args CommandArgs) {
+	opts := rancher.UpgradeOptions{BatchSize: defaultUpgradeBatchSize, IntervalMillis: int64(defaultUpgradeInterval / time.Millisecond)}
+	if args.Has("batch-size") {
+		opts.BatchSize = args.Int("batch-size")
+	}
+	if args.Has("interval") {
+		opts.IntervalMillis = int64(args.Duration("interval") / time.Millisecond)
+	}
+	autoFinish := args.Duration("auto-finish-after")
+
+	if opts.BatchSize < 1 {
+		s.reply(ev, slack.MsgOptionText("`--batch-size` must be at least 1", false))
+		return
+	}
+
+	if autoFinish > upgradeMaxAutoFinish {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("`--auto-finish-after` must be at most %s", upgradeMaxAutoFinish), false))
+		return
+	}
+
+	previous, err := s.rancherListener.GetService(serviceID)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on get service `%s`", serviceID), err)
+		return
+	}
+
+	if _, err := s.rancherListener.UpgradeService(serviceID, image, opts); err != nil {
+		s.postError(ev, "Service upgrade error. Check:\n*- If service ID really exists*\n*- If service is not in upgrading state*", err)
+		return
+	}
+
+	log.Printf("[INFO] Service %s upgraded to %s by %s\n", serviceID, image, ev.Msg.User)
+
+	name := previous.Name
+	if stack, err := s.rancherListener.GetServiceStack(serviceID); err == nil {
+		name = fmt.Sprintf("%s/%s", stack.Name, previous.Name)
+	}
+
+	upgrade := &model.ServiceUpgrade{
+		RancherID:         s.orchestrator.RancherID(),
+		ProjectID:         s.rancherListener.projectID,
+		ServiceID:         serviceID,
+		ServiceName:       name,
+		Image:             image,
+		PreviousImage:     previous.LaunchConfig.ImageUUID(),
+		BatchSize:         opts.BatchSize,
+		IntervalMillis:    opts.IntervalMillis,
+		AutoFinishSeconds: int64(autoFinish / time.Second),
+		User:              ev.User,
+	}
+
+	msg := fmt.Sprintf(":rocket: %s started the upgrade of `%s` from `%s` to `%s`, %d container(s) at a time every %s", mentionUser(ev.User), name, upgrade.PreviousImage, image, opts.BatchSize, time.Duration(opts.IntervalMillis)*time.Millisecond)
+	if autoFinish > 0 {
+		msg += fmt.Sprintf(", it is finished after %s healthy", autoFinish)
+	}
+
+	switch {
+	case s.output != nil:
+		// a API não recebe o progresso, mas o --auto-finish-after continua valendo
+		s.reply(ev, slack.MsgOptionText(msg+fmt.Sprintf("\nUse `%s` or `%s` when it is upgraded", finishUpgrade, rollbackUpgrade), false))
+	case s.responseURL != "":
+		// a resposta do slash command é efêmera, o progresso vai em uma nova thread do canal
+		s.reply(ev, slack.MsgOptionText(msg+"\n_The progress is sent on a thread of the channel_", false))
+		upgrade.Channel = ev.Channel
+	default:
+		_, ts, err := s.client.PostMessage(ev.Channel, threadOptions(ev.ThreadTimestamp, slack.MsgOptionText(msg+"\n_The progress is sent on this thread_", false))...)
+		if err != nil {
+			CheckErr(fmt.Sprintf("Error on send the upgrade of %s", name), err)
+		}
+
+		upgrade.Channel = ev.Channel
+		upgrade.ThreadTS = ev.ThreadTimestamp
+		if upgrade.ThreadTS == "" {
+			upgrade.ThreadTS = ts
+		}
+	}
+
+	upgradeMutex.Lock()
+	defer upgradeMutex.Unlock()
+
+	if err := service.TrackServiceUpgrade(upgrade); err != nil {
+		s.postError(ev, fmt.Sprintf("Error on track the upgrade of `%s`, it must be finished or rolled back by hand", name), err)
+	}
+}
+
+func (s *SlackListener) slackFinishUpgrade(ev *slack.MessageEvent, args CommandArgs) {
+	serviceID := args.String("service-id")
+
+	if _, err := s.rancherListener.FinishUpgradeService(serviceID); err != nil {
+		s.postError(ev, fmt.Sprintf("Error on finish the upgrade of service `%s`, check if it is upgraded", serviceID), err)
+		return
+	}
+
+	s.closeServiceUpgrade(serviceID, ev.User)
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf(":checkered_flag: Finishing the upgrade of service `%s`, the containers of the previous version are being removed", serviceID), false))
+}
+
+func (s *SlackListener) slackRollbackUpgrade(ev *slack.MessageEvent, args CommandArgs) {
+	serviceID := args.String("service-id")
+
+	if _, err := s.rancherListener.RollbackService(serviceID); err != nil {
+		s.postError(ev, fmt.Sprintf("Error on roll back the upgrade of service `%s`, check if it is upgraded", serviceID), err)
+		return
+	}
+
+	s.closeServiceUpgrade(serviceID, ev.User)
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf(":rewind: Rolling back the upgrade of service `%s` to the previous version", serviceID), false))
+}
+
+// closeServiceUpgrade guarda quem terminou ou reverteu o upgrade acompanhado,
+// que é avisado na thread quando o serviço voltar a ficar active
+func (s *SlackListener) closeServiceUpgrade(serviceID string, user string) {
+	upgradeMutex.Lock()
+	defer upgradeMutex.Unlock()
+
+	upgrade, found, err := service.FindTrackedServiceUpgrade(s.orchestrator.RancherID(), serviceID)
+	if err != nil || !found {
+		CheckErr(fmt.Sprintf("Error on find the upgrade of %s", serviceID), err)
+		return
+	}
+
+	upgrade.ClosedBy = user
+	CheckErr(fmt.Sprintf("Error on save the upgrade of %s", serviceID), service.SaveServiceUpgrade(&upgrade))
+}
+
+// trackServiceUpgrades verifica os upgrades acompanhados
+func (s *SlackListener) trackServiceUpgrades() {
+	upgradeMutex.Lock()
+	defer upgradeMutex.Unlock()
+
+	upgrades, err := service.ListTrackedServiceUpgrades()
+	if err != nil {
+		CheckErr("Error on list service upgrades", err)
+		return
+	}
+
+	for i := range upgrades {
+		s.checkServiceUpgrade(&upgrades[i])
+	}
+}
+
+// checkServiceUpgrade envia o progresso do upgrade pelo estado do serviço:
+// upgrading -> upgraded (botões Finish e Rollback) -> active. Com o
+// AutoFinishSeconds o upgrade é terminado depois do serviço ficar healthy
+func (s *SlackListener) checkServiceUpgrade(upgrade *model.ServiceUpgrade) {
+	listener, err := taskRancherListener(upgrade.RancherID, upgrade.ProjectID)
+	if err != nil {
+		CheckErr(fmt.Sprintf("Error on check the upgrade of %s", upgrade.ServiceName), err)
+		return
+	}
+
+	svc, err := listener.GetService(upgrade.ServiceID)
+	if err != nil {
+		CheckErr(fmt.Sprintf("Error on check the upgrade of %s", upgrade.ServiceName), err)
+		s.untrackStaleUpgrade(upgrade)
+		return
+	}
+
+	switch svc.State {
+	case "upgrading":
+		s.upgradeProgress(listener, upgrade, svc)
+	case "upgraded":
+		s.upgradeFinished(listener, upgrade, svc)
+	case "active":
+		s.upgradeClosed(upgrade, svc)
+		return
+	case "removed", "purged":
+		s.untrackServiceUpgrade(upgrade, "the service was removed")
+		return
+	}
+
+	if !s.untrackStaleUpgrade(upgrade) {
+		CheckErr(fmt.Sprintf("Error on save the upgrade of %s", upgrade.ServiceName), service.SaveServiceUpgrade(upgrade))
+	}
+}
+
+// upgradeProgress envia quantos containers já estão na nova imagem, quando muda
+func (s *SlackListener) upgradeProgress(listener *RancherListener, upgrade *model.ServiceUpgrade, svc rancher.Service) {
+	containers, err := listener.GetInstances(upgrade.ServiceID)
+	if err != nil {
+		CheckErr(fmt.Sprintf("Error on check the containers of %s", upgrade.ServiceName), err)
+		return
+	}
+
+	var updated int
+	for _, container := range containers {
+		if container.ImageUUID == upgrade.Image && container.State == "running" {
+			updated++
+		}
+	}
+
+	progress := fmt.Sprintf("%d/%d containers running the new image", updated, svc.Scale)
+	if progress != upgrade.Progress {
+		s.postUpgrade(upgrade, slack.MsgOptionText(fmt.Sprintf(":hourglass_flowing_sand: `%s`: %s", upgrade.ServiceName, progress), false))
+		upgrade.Progress = progress
+	}
+}
+
+// upgradeFinished avisa que os containers foram trocados, com os botões de
+// Finish e Rollback, e cuida do --auto-finish-after
+func (s *SlackListener) upgradeFinished(listener *RancherListener, upgrade *model.ServiceUpgrade, svc rancher.Service) {
+	now := time.Now()
+
+	if upgrade.Status == model.UpgradeUpgrading {
+		upgrade.Status = model.UpgradeUpgraded
+		upgrade.UpgradedAt = &now
+
+		text := fmt.Sprintf(":white_check_mark: All containers of `%s` were upgraded to `%s`, health `%s`. Finish the upgrade to remove the previous containers, or roll back to `%s`", upgrade.ServiceName, upgrade.Image, svc.HealthState, upgrade.PreviousImage)
+		if upgrade.AutoFinishSeconds > 0 {
+			text += fmt.Sprintf("\n_It is finished automatically after %s healthy_", time.Duration(upgrade.AutoFinishSeconds)*time.Second)
+		}
+
+		s.postUpgrade(upgrade, slack.MsgOptionText(text, false), upgradeButtons(upgrade, text))
+	}
+
+	if upgrade.AutoFinishSeconds == 0 {
+		return
+	}
+
+	if !isHealthy(svc.HealthState) {
+		if upgrade.HealthySince != nil || upgrade.Progress != svc.HealthState {
+			s.postUpgrade(upgrade, slack.MsgOptionText(fmt.Sprintf(":warning: `%s` is `%s`, the auto finish waits for it to be healthy for %s", upgrade.ServiceName, svc.HealthState, time.Duration(upgrade.AutoFinishSeconds)*time.Second), false))
+		}
+		upgrade.HealthySince = nil
+		upgrade.Progress = svc.HealthState
+		return
+	}
+
+	if upgrade.HealthySince == nil {
+		upgrade.HealthySince = &now
+		upgrade.Progress = "healthy"
+		return
+	}
+
+	window := time.Duration(upgrade.AutoFinishSeconds) * time.Second
+	if now.Sub(*upgrade.HealthySince) < window {
+		return
+	}
+
+	_, err := listener.FinishUpgradeService(upgrade.ServiceID)
+	recordSystemAudit(auditSystemUpgrade, model.AuditEvent{
+		Channel:   upgrade.Channel,
+		Command:   finishUpgrade,
+		RancherID: upgrade.RancherID,
+		ProjectID: upgrade.ProjectID,
+		Resource:  upgrade.ServiceID,
+	}, err, fmt.Sprintf("upgrade of %s to %s by %s auto finished after %s healthy", upgrade.ServiceName, upgrade.Image, upgrade.User, window))
+	if err != nil {
+		// sem o auto finish o upgrade espera os botões
+		upgrade.AutoFinishSeconds = 0
+		s.postUpgrade(upgrade, slack.MsgOptionText(fmt.Sprintf(":x: Error on auto finish the upgrade of `%s`, finish or roll back it with the buttons\nError: %s", upgrade.ServiceName, err), false))
+		return
+	}
+
+	s.postUpgrade(upgrade, slack.MsgOptionText(fmt.Sprintf(":robot_face: `%s` stayed healthy for %s, finishing the upgrade", upgrade.ServiceName, window), false))
+}
+
+// upgradeClosed avisa que o serviço voltou a ficar active, terminado se ele
+// está na nova imagem ou revertido se voltou para a anterior
+func (s *SlackListener) upgradeClosed(upgrade *model.ServiceUpgrade, svc rancher.Service) {
+	by := "on Rancher"
+	if upgrade.ClosedBy != "" {
+		by = fmt.Sprintf("by %s", mentionUser(upgrade.ClosedBy))
+	} else if upgrade.AutoFinishSeconds > 0 && upgrade.HealthySince != nil {
+		by = "automatically"
+	}
+
+	status := model.UpgradeFinished
+	msg := fmt.Sprintf(":checkered_flag: The upgrade of `%s` to `%s` was finished %s", upgrade.ServiceName, upgrade.Image, by)
+
+	if svc.LaunchConfig.ImageUUID() != upgrade.Image {
+		status = model.UpgradeRolledBack
+		msg = fmt.Sprintf(":rewind: The upgrade of `%s` was rolled back %s, it is running `%s`", upgrade.ServiceName, by, svc.LaunchConfig.ImageUUID())
+	}
+
+	s.postUpgrade(upgrade, slack.MsgOptionText(msg, false))
+	CheckErr(fmt.Sprintf("Error on save the upgrade of %s", upgrade.ServiceName), service.FinishServiceUpgrade(upgrade, status, ""))
+}
+
+// untrackStaleUpgrade para de acompanhar o upgrade depois do upgradeTrackTimeout
+func (s *SlackListener) untrackStaleUpgrade(upgrade *model.ServiceUpgrade) bool {
+	if time.Since(upgrade.CreatedAt) < upgradeTrackTimeout {
+		return false
+	}
+
+	s.untrackServiceUpgrade(upgrade, fmt.Sprintf("it was not finished in %s", upgradeTrackTimeout))
+	return true
+}
+
+func (s *SlackListener) untrackServiceUpgrade(upgrade *model.ServiceUpgrade, reason string) {
+	s.postUpgrade(upgrade, slack.MsgOptionText(fmt.Sprintf(":information_source: Stopped tracking the upgrade of `%s`, %s", upgrade.ServiceName, reason), false))
+	CheckErr(fmt.Sprintf("Error on save the upgrade of %s", upgrade.ServiceName), service.FinishServiceUpgrade(upgrade, model.UpgradeUntracked, reason))
+}
+
+// postUpgrade envia a mensagem na thread do upgrade. Sem thread (slash
+// commands) a primeira mensagem vira a thread, e sem canal (API) nada é enviado
+func (s *SlackListener) postUpgrade(upgrade *model.ServiceUpgrade, options ...slack.MsgOption) {
+	if upgrade.Channel == "" {
+		return
+	}
+
+	_, ts, err := s.client.PostMessage(upgrade.Channel, threadOptions(upgrade.ThreadTS, options...)...)
+	if err != nil {
+		CheckErr(fmt.Sprintf("Error on send the upgrade of %s", upgrade.ServiceName), err)
+		return
+	}
+
+	if upgrade.ThreadTS == "" {
+		upgrade.ThreadTS = ts
+	}
+}
+
+// upgradeButtons são os botões Finish e Rollback do upgrade. O action_id é o
+// comando executado por quem clicou e o block_id o contexto do serviço, como
+// nos pickers
+func upgradeButtons(upgrade *model.ServiceUpgrade, text string) slack.MsgOption {
+	ref := pickerRef{Command: upgradeService, RancherID: upgrade.RancherID, ProjectID: upgrade.ProjectID}
+
+	finish := slack.NewButtonBlockElement(finishUpgrade, upgrade.ServiceID, plainText("Finish"))
+	finish.WithStyle(slack.StylePrimary)
+	finish.Confirm = confirmation(fmt.Sprintf("Finish the upgrade of `%s`? The containers of `%s` are removed", upgrade.ServiceName, upgrade.PreviousImage))
+
+	rollback := slack.NewButtonBlockElement(rollbackUpgrade, upgrade.ServiceID, plainText("Rollback"))
+	rollback.WithStyle(slack.StyleDanger)
+	rollback.Confirm = confirmation(fmt.Sprintf("Roll back `%s` to `%s`?", upgrade.ServiceName, upgrade.PreviousImage))
+
+	return slack.MsgOptionBlocks(
+		slack.NewSectionBlock(markdownText(text), nil, nil),
+		slack.NewActionBlock(ref.String(), finish, rollback),
+	)
+}
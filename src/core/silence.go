@@ -0,0 +1,236 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/nlopes/slack"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+const (
+	// maintenanceTimeLayout é o formato do --at do maintenance, no horário local do BOT
+	maintenanceTimeLayout = "2006-01-02 15:04"
+
+	// silenceTargetArg é o argumento com a task, o serviço ou `all` do silence e do maintenance
+	silenceTargetArg = "task-id|stackName/serviceName|all"
+)
+
+func (s *SlackListener) slackSilence(ev *slack.MessageEvent, args CommandArgs) {
+	now := time.Now()
+	silence := model.Silence{
+		Kind:     model.SilenceKindSilence,
+		StartsAt: now,
+		EndsAt:   now.Add(args.Duration("duration")),
+		Reason:   args.String("reason"),
+		User:     ev.User,
+	}
+
+	if err := s.setSilenceTarget(&silence, args.String(silenceTargetArg)); err != nil {
+		s.postError(ev, "Invalid target", err)
+		return
+	}
+
+	if !s.authorizeSilenceTarget(ev, silenceCommand, silence) {
+		return
+	}
+
+	if err := service.AddSilence(&silence); err != nil {
+		s.postError(ev, "Error on add silence", err)
+		return
+	}
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf(":zzz: Silence `%d` added: %s until %s. Health is still checked, but alerts and restarts are suppressed.", silence.ID, silenceTargetDescription(silence), silence.EndsAt.Format(maintenanceTimeLayout)), false))
+}
+
+func (s *SlackListener) slackMaintenance(ev *slack.MessageEvent, args CommandArgs) {
+	duration := args.Duration("for")
+	if duration <= 0 || (!args.Has("at") && !args.Has("cron")) {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Command call error, correct syntax: @jeremias %s task-id|stackName/serviceName|all --at \"%s\" --for 2h reason (optional) or --cron \"0 22 * * SAT\" --for 2h reason (optional)", maintenanceCommand, maintenanceTimeLayout), false))
+		return
+	}
+
+	silence := model.Silence{
+		Kind:   model.SilenceKindMaintenance,
+		Reason: args.String("reason"),
+		User:   ev.User,
+	}
+
+	if args.Has("cron") {
+		silence.Cron = args.String("cron")
+		silence.DurationMinutes = int(duration / time.Minute)
+	} else {
+		var err error
+		if silence.StartsAt, err = time.ParseInLocation(maintenanceTimeLayout, args.String("at"), time.Local); err != nil {
+			s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Invalid start `%s`, use the format `%s`", args.String("at"), maintenanceTimeLayout), false))
+			return
+		}
+		silence.EndsAt = silence.StartsAt.Add(duration)
+	}
+
+	if err := s.setSilenceTarget(&silence, args.String(silenceTargetArg)); err != nil {
+		s.postError(ev, "Invalid target", err)
+		return
+	}
+
+	if !s.authorizeSilenceTarget(ev, maintenanceCommand, silence) {
+		return
+	}
+
+	if err := service.AddSilence(&silence); err != nil {
+		s.postError(ev, "Error on add maintenance window", err)
+		return
+	}
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf(":construction: Maintenance window `%d` added: %s, %s", silence.ID, silenceTargetDescription(silence), silenceDescription(silence)), false))
+}
+
+func (s *SlackListener) slackSilenceList(ev *slack.MessageEvent, args CommandArgs) {
+	now := time.Now()
+
+	silences, err := service.ListSilences(now)
+	if err != nil {
+		s.postError(ev, "Error on list silences", err)
+		return
+	}
+
+	if len(silences) == 0 {
+		s.reply(ev, slack.MsgOptionText("No silences or maintenance windows.", false))
+		return
+	}
+
+	msg := "*Silences and maintenance windows:*"
+	for _, silence := range silences {
+		status := "scheduled"
+		if active, _ := service.SilenceActive(silence, now); active {
+			status = "active"
+		}
+
+		msg += fmt.Sprintf("\n`%d` %s | %s | %s | %s | by <@%s>", silence.ID, silence.Kind, silenceTargetDescription(silence), silenceDescription(silence), status, silence.User)
+		if silence.Reason != "" {
+			msg += fmt.Sprintf(" | %s", silence.Reason)
+		}
+	}
+
+	s.reply(ev, slack.MsgOptionText(msg, false))
+}
+
+func (s *SlackListener) slackSilenceRemove(ev *slack.MessageEvent, args CommandArgs) {
+	ID := args.ID("silence-id")
+
+	silence, err := service.FindSilence(ID)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on find silence `%d`", ID), err)
+		return
+	}
+
+	rancherID, projectID, err := silenceScope(silence)
+	switch {
+	case gorm.IsRecordNotFoundError(err):
+		// sem a task não se sabe o environment, só quem pode em todos remove
+		if !s.authorizeGlobal(ev, silenceRemove) {
+			return
+		}
+	case err != nil:
+		s.postError(ev, fmt.Sprintf("Error on find the task of silence `%d`", ID), err)
+		return
+	case !s.authorizeObject(ev, silenceRemove, fmt.Sprintf("%s `%d`", silence.Kind, silence.ID), rancherID, projectID):
+		return
+	}
+
+	silence, err = service.DeleteSilence(ID)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on remove silence `%d`", ID), err)
+		return
+	}
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Removed %s `%d` of %s, alerts are enabled again.", silence.Kind, silence.ID, silenceTargetDescription(silence)), false))
+}
+
+// setSilenceTarget lê o alvo do silêncio: o ID de uma task, com o Rancher e
+// environment dela, ou `stack/service` e `all` no environment selecionado
+func (s *SlackListener) setSilenceTarget(silence *model.Silence, target string) error {
+	if ID, err := strconv.ParseUint(target, 10, 32); err == nil {
+		task, err := service.FindTask(uint(ID))
+		if err != nil {
+			return fmt.Errorf("task `%d` not found, the IDs are listed by `%s`", ID, listAllRunningTasks)
+		}
+
+		silence.TaskID = task.ID
+		silence.RancherID = task.RancherID
+		silence.ProjectID = task.RancherProjectID
+		return nil
+	}
+
+	if target != model.SilenceAllTasks && !strings.Contains(target, "/") {
+		return fmt.Errorf("`%s` is not a task ID, a stackName/serviceName or `%s`", target, model.SilenceAllTasks)
+	}
+
+	if s.rancherListener == nil || s.rancherListener.projectID == "" {
+		return fmt.Errorf("select a Rancher 1.6 and an environment to silence `%s`", target)
+	}
+
+	silence.Service = target
+	silence.RancherID = s.context.rancherID
+	silence.ProjectID = s.rancherListener.projectID
+
+	return nil
+}
+
+// authorizeSilenceTarget verifica a permissão no environment da task do
+// silêncio, que não precisa ser o selecionado. Os serviços e o `all` são do
+// environment selecionado, já verificado antes do comando
+func (s *SlackListener) authorizeSilenceTarget(ev *slack.MessageEvent, command string, silence model.Silence) bool {
+	if silence.TaskID == 0 {
+		return true
+	}
+
+	return s.authorizeObject(ev, command, fmt.Sprintf("task `%d`", silence.TaskID), silence.RancherID, silence.ProjectID)
+}
+
+// silenceScope é o Rancher e environment do silence. Os silences de task
+// criados antes de guardarem o environment usam o da task
+func silenceScope(silence model.Silence) (uint, string, error) {
+	if silence.TaskID == 0 || silence.ProjectID != "" {
+		return silence.RancherID, silence.ProjectID, nil
+	}
+
+	task, err := service.FindTask(silence.TaskID)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return task.RancherID, task.RancherProjectID, nil
+}
+
+func silenceTargetDescription(silence model.Silence) string {
+	if silence.TaskID != 0 {
+		return fmt.Sprintf("task `%d`", silence.TaskID)
+	}
+
+	if silence.Service == model.SilenceAllTasks {
+		return fmt.Sprintf("all tasks of environment `%s`", silence.ProjectID)
+	}
+
+	return fmt.Sprintf("`%s` in environment `%s`", silence.Service, silence.ProjectID)
+}
+
+// silenceDescription descreve quando o silêncio vale
+func silenceDescription(silence model.Silence) string {
+	if silence.Cron != "" {
+		return fmt.Sprintf("cron `%s` for %s", silence.Cron, time.Duration(silence.DurationMinutes)*time.Minute)
+	}
+
+	if silence.Kind == model.SilenceKindSilence {
+		return fmt.Sprintf("until %s", silence.EndsAt.Format(maintenanceTimeLayout))
+	}
+
+	return fmt.Sprintf("from %s until %s", silence.StartsAt.Format(maintenanceTimeLayout), silence.EndsAt.Format(maintenanceTimeLayout))
+}
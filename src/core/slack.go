@@ -8,9 +8,9 @@ import (
 	"fmt"
 	"github.com/slack-bot-4all/slack-bot/src/scripts"
 	"io/ioutil"
-	"os"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -18,11 +18,11 @@ import (
 	"github.com/cayohollanda/runner"
 	"github.com/slack-bot-4all/slack-bot/src/config"
 	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/rancher"
 	"github.com/slack-bot-4all/slack-bot/src/repository"
 	"github.com/slack-bot-4all/slack-bot/src/service"
 
 	"github.com/nlopes/slack"
-	"github.com/tidwall/gjson"
 )
 
 const (
@@ -31,13 +31,20 @@ const (
 	canaryActivate      = "canary-enable"
 	canaryInfo          = "canary-info"
 	canaryUpTen         = "canary-up"
+	canaryRollout       = "canary-rollout"
+	canaryRollback      = "canary-rollback"
+	canaryHistory       = "canary-history"
 	haproxyList         = "lb-list"
 	logsContainer       = "container-logs"
 	restartContainer    = "container-restart"
-	containerList 		= "container-list"
+	execContainer       = "container-exec"
+	containerList       = "container-list"
 	getServiceInfo      = "service-info"
 	upgradeService      = "service-upgrade"
+	finishUpgrade       = "service-upgrade-finish"
+	rollbackUpgrade     = "service-upgrade-rollback"
 	listService         = "service-list"
+	scaleService        = "service-scale"
 	startService        = "service-start"
 	stopService         = "service-stop"
 	statusService       = "service-status"
@@ -50,48 +57,94 @@ const (
 	envCleanupMachines  = "env-cleanup"
 	selectRancher       = "rancher-set"
 	listRancher         = "rancher-list"
+	secretRotate        = "secret-rotate"
 	commands            = "commands"
+	silenceCommand      = "silence"
+	silenceList         = "silence-list"
+	silenceRemove       = "silence-remove"
+	maintenanceCommand  = "maintenance"
+	incidentList        = "incident-list"
+	auditCommand        = "audit"
 )
 
 // SlackListener é a struct que armazena dados do BOT
 type SlackListener struct {
-	client    *slack.Client
-	botID     string
-	channelID string
+	client              *slack.Client
+	botID               string
+	channelID           string
 	statusCakeChannelID string
-}
 
-var (
+	// responseURL e responseType são do slash command sendo respondido, as
+	// respostas vão pelo response_url em vez de para o canal
+	responseURL  string
+	responseType string
+
+	// audit guarda os erros do comando sendo executado, para a auditoria
+	audit *auditRecorder
+
+	// context é o Rancher e o environment do comando sendo executado, do
+	// usuário que o chamou (ver resolveContext)
+	context *commandContext
+
+	// orchestrator é o backend (Rancher 1.6 ou Kubernetes) do context
+	orchestrator Orchestrator
+
+	// rancherListener é o mesmo backend quando ele é um Rancher 1.6, usado pelos
+	// comandos que só existem no Rancher 1.6 (canary, tasks, etc.)
 	rancherListener *RancherListener
-	tasks           []*runner.Task
-)
+
+	// output guarda as respostas dos comandos executados pela API, que não
+	// são enviadas ao Slack
+	output *commandOutput
+}
+
+var tasks []*runner.Task
 
 // StartBot é a função que inicia o BOT e o prepara para receber eventos de mensagens
-func (s *SlackListener) StartBot(rList *RancherListener) {
+func (s *SlackListener) StartBot() {
 	log.Println("[INFO] Initializating BOT...")
 
-	rancherListener = rList
+	go s.runTaskScheduler()
 
-	rtm := s.client.NewRTM()
-	go rtm.ManageConnection()
+	go func() {
+		for {
+			s.executeCanaryRollouts()
+			time.Sleep(rolloutCheckInterval)
+		}
+	}()
 
-	log.Println("[INFO] BOT connection successful!")
+	go func() {
+		for {
+			s.expireApprovalRequests()
+			time.Sleep(approvalCheckInterval)
+		}
+	}()
 
 	go func() {
 		for {
-			log.Println("Executando verificação nas tasks...")
-			s.executeTasks()
-			time.Sleep(time.Second * 90)
+			s.revertTimedScales()
+			time.Sleep(scaleRevertCheckInterval)
 		}
 	}()
 
 	go func() {
 		for {
-			s.executeOnlyCheckTasks()
-			time.Sleep(time.Hour * 1)
+			s.trackServiceUpgrades()
+			time.Sleep(upgradeCheckInterval)
 		}
 	}()
 
+	// na Events API as mensagens chegam pelo endpoint /slack/events
+	if SlackMode != SlackModeRTM {
+		log.Println("[INFO] BOT started successfully! Receiving messages by the Events API")
+		return
+	}
+
+	rtm := s.client.NewRTM()
+	go rtm.ManageConnection()
+
+	log.Println("[INFO] BOT connection successful!")
+
 	for msg := range rtm.IncomingEvents {
 		switch ev := msg.Data.(type) {
 		case *slack.ConnectedEvent:
@@ -119,33 +172,33 @@ func (s *SlackListener) handleMessageEvent(ev *slack.MessageEvent) error {
 				preSplit = strings.Replace(preSplit, ">", "", -1)
 				preSplit = strings.Replace(preSplit, "\\", "", -1)
 				preSplit = strings.Replace(preSplit, "(", "", -1)
-				preSplit = strings.Replace(preSplit, ")", "",  -1)
+				preSplit = strings.Replace(preSplit, ")", "", -1)
 				lifeSaveSplit := strings.Split(preSplit, "LifeSave")
 				if len(lifeSaveSplit) == 2 {
 					patternAndBaseSplit := strings.Split(lifeSaveSplit[0], " - ")
-	
+
 					spListener := SplunkListener{
 						Username: SplunkUsername,
 						Password: SplunkPassword,
-						APIURL: SplunkBaseURL,
+						APIURL:   SplunkBaseURL,
 					}
-	
+
 					result := spListener.ConnectSplunk(fmt.Sprintf("index%%3Dpier-logs%%20trace.base%%3D%s%%20trace.pattern%%3D%s%%20trace.resultStatus%%3D500%%20earliest%%3D-15m", patternAndBaseSplit[1], patternAndBaseSplit[0]))
-	
+
 					fileNameTrace := fmt.Sprintf("trace-%s.json", patternAndBaseSplit[1])
-	
+
 					if strings.Contains(result.Trace.StackTrace.Stack, "Read timed out") {
 						s.client.PostMessage(s.statusCakeChannelID, slack.MsgOptionText("Erro: Read timed out na base, favor acionar DBA.", false))
 						readTimedOutTrace := strings.Split(result.Trace.StackTrace.Stack, "Read timed out")[1]
-	
+
 						w, err := os.Create(fileNameTrace)
 						if err != nil {
 							log.Printf("Erro ao criar arquivo de log: %s\n", err.Error())
 						}
 						defer w.Close()
-						
+
 						ioutil.WriteFile(fileNameTrace, []byte(readTimedOutTrace), 0644)
-	
+
 						_, err = s.client.UploadFile(slack.FileUploadParameters{
 							File:     fileNameTrace,
 							Filename: fileNameTrace,
@@ -157,18 +210,18 @@ func (s *SlackListener) handleMessageEvent(ev *slack.MessageEvent) error {
 						if err != nil {
 							log.Printf("Erro ao enviar arquivo ao Slack: %s\n", err.Error())
 						}
-	
+
 						return nil
 					}
-	
+
 					w, err := os.Create(fileNameTrace)
 					if err != nil {
 						log.Println(err)
 					}
 					defer w.Close()
-					
+
 					ioutil.WriteFile(fileNameTrace, []byte(result.Trace.StackTrace.Stack), 0644)
-	
+
 					s.client.PostMessage(s.statusCakeChannelID, slack.MsgOptionText("Erro: Desconhecido. Verificar stackTrace.", false))
 
 					_, err = s.client.UploadFile(slack.FileUploadParameters{
@@ -195,6 +248,11 @@ func (s *SlackListener) handleMessageEvent(ev *slack.MessageEvent) error {
 		return nil
 	}
 
+	return s.handleCommand(ev)
+}
+
+// handleCommand executa o comando de uma mensagem que menciona o BOT
+func (s *SlackListener) handleCommand(ev *slack.MessageEvent) error {
 	var isReminder bool
 	if strings.Contains(ev.Msg.Text, fmt.Sprintf("Reminder: <@%s", s.botID)) {
 		ev.Msg.Text = strings.Replace(ev.Msg.Text, "Reminder: ", "", 1)
@@ -208,7 +266,7 @@ func (s *SlackListener) handleMessageEvent(ev *slack.MessageEvent) error {
 	}
 
 	var message string
-	messageSlice := strings.Split(ev.Msg.Text, " ") // Tirando a menção ao BOT da mensagem e guardando em uma variável
+	messageSlice := strings.Fields(ev.Msg.Text) // Tirando a menção ao BOT da mensagem e guardando em uma variável
 	if len(messageSlice) <= 1 {
 		if ev.Msg.Text != fmt.Sprintf("<@%s>", s.botID) {
 			return nil
@@ -217,1028 +275,911 @@ func (s *SlackListener) handleMessageEvent(ev *slack.MessageEvent) error {
 		message = messageSlice[1]
 	}
 
-	if strings.Contains(ev.Msg.Text, "help") {
+	if message == "help" {
+		if len(messageSlice) >= 3 {
+			s.slackCommandHelper(ev, messageSlice[2])
+		} else {
+			s.slackHelper(ev, CommandArgs{})
+		}
+		return nil
+	}
+
+	if len(messageSlice) >= 3 && messageSlice[2] == "help" {
 		s.slackCommandHelper(ev, message)
 		return nil
 	}
 
 	log.Printf("[INFO] New received message: %s", message)
 
-	// Fazendo as verificações de mensagens e jogando
-	// para as devidas funções
-	if strings.HasPrefix(message, restartContainer) {
-		s.slackRestartContainer(ev)
-	} else if strings.HasPrefix(message, logsContainer) {
-		s.slackLogsContainer(ev)
-	} else if strings.HasPrefix(message, canaryUpdate) {
-		s.slackUpdateCanary(ev)
-	} else if strings.HasPrefix(message, haproxyList) {
-		s.slackListLoadBalancers(ev)
-	} else if strings.HasPrefix(message, getServiceInfo) {
-		s.slackServiceInfo(ev)
-	} else if strings.HasPrefix(message, listService) {
-		s.slackServicesList(ev)
-	} else if strings.HasPrefix(message, upgradeService) {
-		s.slackServiceUpgrade(ev)
-	} else if strings.HasPrefix(message, canaryDisable) {
-		s.slackCanaryDisable(ev)
-	} else if strings.HasPrefix(message, canaryActivate) {
-		s.slackCanaryEnable(ev)
-	} else if strings.HasPrefix(message, canaryInfo) {
-		s.slackCanaryInfo(ev)
-	} else if strings.HasPrefix(message, startService) {
-		s.slackStartService(ev)
-	} else if strings.HasPrefix(message, stopService) {
-		s.slackStopService(ev)
-	} else if strings.HasPrefix(message, checkServiceHealth) {
-		s.slackCheckServiceHealth(ev)
-	} else if strings.HasPrefix(message, taskAddByKeyword) {
-		s.slackCheckServiceByKeyword(ev)
-	} else if strings.HasPrefix(message, removeServiceCheck) {
-		s.stopServiceCheck(ev)
-	} else if strings.HasPrefix(message, listAllRunningTasks) {
-		s.listAllRunningTasks(ev)
-	} else if strings.HasPrefix(message, selectRancher) {
-		s.selectRancher(ev)
-	} else if strings.HasPrefix(message, listAllEnvironments) {
-		s.listAllEnvironments(ev)
-	} else if strings.HasPrefix(message, listRancher) {
-		s.listAllRanchers(ev)
-	} else if strings.HasPrefix(message, selectEnvironment) {
-		s.selectEnvironment(ev)
-	} else if strings.HasPrefix(message, envCleanupMachines) {
-		s.envCleanupMachinesFunc(ev)
-	} else if strings.HasPrefix(message, statusService) {
-		s.serviceCheck(ev)
-	} else if strings.HasPrefix(message, commands) {
-		s.slackHelper(ev)
-	} else if strings.HasPrefix(message, canaryUpTen) {
-		s.slackCanaryUpTen(ev)
-	} else if strings.HasPrefix(message, containerList) {
-		s.containersList(ev)
-	} else {
+	cmd := findCommand(message)
+	if cmd == nil {
 		s.interactiveMessage(ev)
+		return nil
 	}
 
-	return nil
-}
-
-func (s *SlackListener) envCleanupMachinesFunc(ev *slack.MessageEvent) {
-	if rancherListener.projectID == "" {
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Please select environment."), false))
-	} else {
-		err := scripts.CleanupMachines(rancherListener.baseURL, rancherListener.accessKey, rancherListener.secretKey, rancherListener.projectID)
-		if err != nil {
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Error on cleanup machines\nError: %s", err.Error()), false))
-		} else {
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Finish rotine, environment cleaned!"), false))
-		}
+	event := newAuditEvent(ev, *cmd)
+	if s.output != nil {
+		s.output.event = &event
 	}
-}
 
-func (s *SlackListener) containersList(ev *slack.MessageEvent) {
-	args := strings.Split(ev.Msg.Text, " ")
+	args, err := parseCommandArgs(*cmd, ev.Msg.Text)
+	if err != nil {
+		saveAuditEvent(&event, model.AuditInvalid, err.Error())
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Command call error: %s\nCorrect syntax: %s\nFor details: `@jeremias %s help`", err, cmd.Usage, cmd.Cmd), false))
+		return nil
+	}
 
-	if len(args) == 3 {
-		keyword := args[2]
-		var containers []Container
-		containersString := rancherListener.ListContainers()
+	setAuditArgs(&event, *cmd, args)
 
-		data := gjson.Get(containersString, "data")
-		data.ForEach(func(key, value gjson.Result) bool {
-			if strings.Contains(value.Get("name").String(), keyword) {
-				var container Container
-				container.ID = value.Get("id").String()
-				container.Name = value.Get("name").String()
-				container.HostID = value.Get("hostId").String()
+	ctx, err := s.resolveContext(ev, args)
+	if err != nil {
+		saveAuditEvent(&event, model.AuditInvalid, err.Error())
+		s.postError(ev, "Error on select the Rancher and environment of the command", err)
+		return nil
+	}
 
-				containers = append(containers, container)
-			}
+	s.dispatchCommand(ev, *cmd, args, ctx, &event)
 
-			return true
-		})
+	return nil
+}
 
-		msg := "*Containers List:*\n"
+// dispatchCommand executa o comando no Rancher e environment já escolhidos,
+// verificando antes as permissões e se ele precisa de aprovação
+func (s *SlackListener) dispatchCommand(ev *slack.MessageEvent, cmd Command, args CommandArgs, ctx *commandContext, event *model.AuditEvent) {
+	event.RancherID, event.ProjectID = ctx.rancherID, ctx.projectID
 
-		for _, container := range containers {
-			host := gjson.Get(rancherListener.GetHostInfo(container.HostID), "hostname").String()
-			msg += fmt.Sprintf("ID: `%s` | Name: `%s` | Host: `%s`\n", container.ID, container.Name, host)
-		}
+	// cada comando usa uma cópia do listener com o contexto de quem o chamou
+	listener := s.withContext(ctx)
 
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(msg, false))
-	} else {
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText("Please, send keyword to query.", false))
+	if cmd.RancherOnly && listener.rancherListener == nil {
+		listener.reply(ev, slack.MsgOptionText(fmt.Sprintf("Command `%s` is only available for Rancher 1.6, %s is `%s`", cmd.Cmd, ctx, ctx.orchestrator.Backend()), false))
+		return
 	}
 
-}
+	// os comandos Global verificam as permissões no Rancher e environment de
+	// cada objeto que eles alteram, não no selecionado
+	if cmd.Restricted && !cmd.Global && !listener.authorizeCommand(ev, cmd, event) {
+		return
+	}
 
-func (s *SlackListener) serviceCheck(ev *slack.MessageEvent) {
-	args := strings.Split(ev.Msg.Text, " ")
+	if needsApproval(cmd, args) && listener.holdForApproval(ev, cmd, event) {
+		return
+	}
 
-	if len(args) == 4 {
-		task := &model.Task{
-			Service:            args[2],
-			ChannelToSendAlert: args[3],
-			RancherURL:         rancherListener.baseURL,
-			RancherAccessKey:   rancherListener.accessKey,
-			RancherSecretKey:   rancherListener.secretKey,
-			RancherProjectID:   rancherListener.projectID,
-			IsOnlyCheck:        true,
-		}
+	listener.runCommand(ev, cmd, args, event)
+}
 
-		err := service.AddTask(task)
+func (s *SlackListener) envCleanupMachinesFunc(ev *slack.MessageEvent, args CommandArgs) {
+	if s.rancherListener.projectID == "" {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Please select environment."), false))
+	} else {
+		err := scripts.CleanupMachines(s.rancherListener.baseURL, s.rancherListener.accessKey, s.rancherListener.secretKey, s.rancherListener.projectID)
 		if err != nil {
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText("Error on register task, verify if BOT haves connection with database", false))
+			s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Error on cleanup machines\nError: %s", err.Error()), false))
 		} else {
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText("Task added successfully!", false))
+			s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Finish rotine, environment cleaned!"), false))
 		}
 	}
 }
 
-func (s *SlackListener) executeOnlyCheckTasks() {
-	var stackName string
-	var serviceName string
-	var stackID string
-	var serviceID string
-	var serviceHealthState string
-	var containers []Container
-	var tasks []model.Task
-	tasks, err := service.ListTask()
+func (s *SlackListener) containersList(ev *slack.MessageEvent, args CommandArgs) {
+	keyword := args.String("keyword")
 
+	allContainers, err := s.rancherListener.ListContainers()
 	if err != nil {
-		log.Println("[ERROR] Error on execute task check, no response from database")
+		s.postError(ev, "Error on list containers", err)
 		return
 	}
 
-	for _, task := range tasks {
-		if task.IsOnlyCheck == true {
-			rancherListener := &RancherListener{
-				baseURL:   task.RancherURL,
-				accessKey: task.RancherAccessKey,
-				secretKey: task.RancherSecretKey,
-				projectID: task.RancherProjectID,
-			}
-
-			argSplitted := strings.Split(task.Service, "/")
-
-			if len(argSplitted) >= 2 {
-				stackName = argSplitted[0]
-				serviceName = argSplitted[1]
-			} else {
-				log.Println("Error! service name is not declared right. Right declaration example: stackName/serviceName")
-				return
-			}
+	msg := "*Containers List:*\n"
 
-			respAllStacks := rancherListener.GetStacks()
+	for _, container := range allContainers {
+		if !strings.Contains(container.Name, keyword) {
+			continue
+		}
 
-			dataStack := gjson.Get(respAllStacks, "data")
-			dataStack.ForEach(func(key, value gjson.Result) bool {
-				if value.Get("name").String() == stackName {
-					stackID = value.Get("id").String()
-				}
-				return true
-			})
+		host, err := s.rancherListener.GetHostInfo(container.HostID)
+		if err != nil {
+			s.postError(ev, fmt.Sprintf("Error on get host of container `%s`", container.ID), err)
+			return
+		}
 
-			respAllServicesFromStack := rancherListener.GetServicesFromStack(stackID)
+		msg += fmt.Sprintf("ID: `%s` | Name: `%s` | Host: `%s`\n", container.ID, container.Name, host.Hostname)
+	}
 
-			dataService := gjson.Get(respAllServicesFromStack, "data")
-			dataService.ForEach(func(key, value gjson.Result) bool {
-				if value.Get("name").String() == serviceName {
-					serviceID = value.Get("id").String()
-					serviceHealthState = value.Get("healthState").String()
-				}
-				return true
-			})
+	s.reply(ev, slack.MsgOptionText(msg, false))
+}
 
-			if stackID == "" || serviceID == "" {
-				log.Println("Error! Check if you are passing correct argument, the correct is: @bot command stackName/serviceName")
+func (s *SlackListener) serviceCheck(ev *slack.MessageEvent, args CommandArgs) {
+	task := &model.Task{
+		Service:            args.String("stackName/serviceName"),
+		ChannelToSendAlert: args.String("channel-to-send-alert"),
+		RancherID:          s.context.rancherID,
+		RancherProjectID:   s.rancherListener.projectID,
+		IsOnlyCheck:        true,
+	}
 
-				return
-			}
+	task.IntervalSeconds = int64(args.Duration("every") / time.Second)
+	task.Cron = args.String("cron")
 
-			respAllInstances := rancherListener.GetInstances(serviceID)
-			dataInstances := gjson.Get(respAllInstances, "data")
-			dataInstances.ForEach(func(key, value gjson.Result) bool {
-				var container Container
-				container.ID = value.Get("id").String()
-				container.Name = value.Get("name").String()
-				container.State = value.Get("state").String()
+	if err := service.AddTask(task); err != nil {
+		s.postError(ev, "Error on register task", err)
+		return
+	}
 
-				containers = append(containers, container)
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Task added successfully! Running %s", taskScheduleDescription(*task)), false))
+}
 
-				return true
-			})
+// reportTask envia para o canal da task o estado atual do serviço (service-status)
+func (s *SlackListener) reportTask(task model.Task) {
+	rancherListener, err := taskRancherListener(task.RancherID, task.RancherProjectID)
+	if err != nil {
+		log.Printf("[ERROR] Error on report task %d\n%s", task.ID, err)
+		return
+	}
 
-			var envName string
+	release := acquireRancher(task.RancherID)
+	defer release()
 
-			resp := rancherListener.GetAllEnvironmentsFromRancher()
+	argSplitted := strings.Split(task.Service, "/")
+	if len(argSplitted) < 2 {
+		log.Println("Error! service name is not declared right. Right declaration example: stackName/serviceName")
+		return
+	}
 
-			data := gjson.Get(resp, "data")
-			data.ForEach(func(key, value gjson.Result) bool {
-				if value.Get("id").String() == task.RancherProjectID {
-					envName = value.Get("name").String()
-				}
+	stackName := argSplitted[0]
+	serviceName := argSplitted[1]
 
-				return true
-			})
+	svc, err := rancherListener.FindService(stackName, serviceName)
+	if err != nil {
+		log.Printf("[ERROR] Error on check task %d\n%s", task.ID, err)
+		return
+	}
 
-			s.client.PostMessage(task.ChannelToSendAlert, slack.MsgOptionText(fmt.Sprintf("The service `%s/%s` in Environment `%s` actually is `%s`", stackName, serviceName, envName, serviceHealthState), true))
-		}
+	envName, err := rancherListener.GetEnvironmentName(task.RancherProjectID)
+	if err != nil {
+		log.Printf("[ERROR] Error on get environment of task %d\n%s", task.ID, err)
+		return
 	}
-}
 
-func (s *SlackListener) executeTasks() error {
-	var stackName string
-	var serviceName string
-	var stackID string
-	var serviceID string
-	var serviceState string
-	var serviceHealthState string
-	var containers []Container
-	var tasks []model.Task
-	tasks, err := service.ListTask()
+	s.client.PostMessage(task.ChannelToSendAlert, slack.MsgOptionText(fmt.Sprintf("The service `%s/%s` in Environment `%s` actually is `%s`", stackName, serviceName, envName, svc.HealthState), true))
+}
 
+// checkTask verifica o serviço da task e seus containers e passa o resultado
+// pela máquina de estados da task (healthy -> degraded -> failing ->
+// recovering). Cada falha abre um incidente, com uma única mensagem no Slack
+// atualizada a cada verificação e os eventos (restarts, logs, recuperação) na
+// thread. Tasks oscilando (flapping) ou silenciadas (silence, maintenance) não
+// alertam nem reiniciam containers
+func (s *SlackListener) checkTask(task model.Task, rancherListener *RancherListener, svc rancher.Service) error {
+	containers, err := rancherListener.GetInstances(svc.ID)
 	if err != nil {
-		log.Println("[ERROR] Error on execute task check, no response from database")
 		return err
 	}
 
-	for _, task := range tasks {
-		if task.IsOnlyCheck == false {
-			containers = []Container{}
-
-			rancherListener := &RancherListener{
-				baseURL:   task.RancherURL,
-				accessKey: task.RancherAccessKey,
-				secretKey: task.RancherSecretKey,
-				projectID: task.RancherProjectID,
-			}
+	now := time.Now()
+	unhealthy := svc.State != "inactive" && svc.HealthState != "healthy" && svc.HealthState != "inactive" && svc.HealthState != "initializing"
 
-			argSplitted := strings.Split(task.Service, "/")
-
-			if len(argSplitted) >= 2 {
-				stackName = argSplitted[0]
-				serviceName = argSplitted[1]
-			} else {
-				log.Println("Error! service name is not declared right. Right declaration example: stackName/serviceName")
-				return err
-			}
+	health, err := service.GetTaskHealth(task.ID)
+	if err != nil {
+		return err
+	}
+	health.ServiceID = svc.ID
 
-			respAllStacks := rancherListener.GetStacks()
+	transition := service.EvaluateTaskHealth(task, &health, unhealthy, now)
 
-			dataStack := gjson.Get(respAllStacks, "data")
-			dataStack.ForEach(func(key, value gjson.Result) bool {
-				if value.Get("name").String() == stackName {
-					stackID = value.Get("id").String()
-				}
-				return true
-			})
+	if transition.From != transition.To {
+		log.Printf("[INFO] Task %d (%s): %s -> %s\n", task.ID, task.Service, transition.From, transition.To)
+	}
 
-			respAllServicesFromStack := rancherListener.GetServicesFromStack(stackID)
+	silence, err := service.FindTaskSilence(task, now)
+	CheckErr(fmt.Sprintf("Error on find silences of task %d", task.ID), err)
 
-			dataService := gjson.Get(respAllServicesFromStack, "data")
-			dataService.ForEach(func(key, value gjson.Result) bool {
-				if value.Get("name").String() == serviceName {
-					serviceID = value.Get("id").String()
-					serviceHealthState = value.Get("healthState").String()
-					serviceState = value.Get("state").String()
-				}
-				return true
-			})
+	if silence != nil {
+		// o alerta fica para quando o silêncio acabar, se a task ainda estiver falhando
+		if transition.Alert {
+			health.Alerted = false
+		}
+		transition.Alert = false
+		transition.FlappingStarted = false
+		transition.FlappingStopped = false
+	} else if health.State == model.HealthFailing && !health.Alerted && !health.Flapping {
+		health.Alerted = true
+		transition.Alert = true
+	}
 
-			if stackID == "" || serviceID == "" {
-				log.Println("Error! Check if you are passing correct argument, the correct is: @bot command stackName/serviceName")
+	var unhealthyContainers []rancher.Container
+	for _, container := range containers {
+		if container.HealthState == "unhealthy" || (container.State != "running" && container.State != "stopped") {
+			unhealthyContainers = append(unhealthyContainers, container)
+		}
+	}
 
-				return err
+	var recovered []string
+	if silence == nil && transition.To == model.HealthFailing && task.IsRestartEnabled && health.RecoveryAttempts < maxRecoveryAttempts && len(unhealthyContainers) > 0 {
+		for _, container := range unhealthyContainers {
+			// primeiro tenta reiniciar, depois remove o container para o Rancher recriar
+			action := "restarted"
+			if health.RecoveryAttempts == 0 {
+				_, err = rancherListener.RestartContainer(container.ID)
+			} else {
+				action = "deleted"
+				_, err = rancherListener.DeleteContainer(container.ID)
 			}
 
-			respAllInstances := rancherListener.GetInstances(serviceID)
-			dataInstances := gjson.Get(respAllInstances, "data")
-			dataInstances.ForEach(func(key, value gjson.Result) bool {
-				var container Container
-				container.ID = value.Get("id").String()
-				container.Name = value.Get("name").String()
-				container.State = value.Get("state").String()
-				container.HealthState = value.Get("healthState").String()
-
-				containers = append(containers, container)
-
-				return true
-			})
-
-			if serviceHealthState != "healthy" && serviceHealthState != "inactive" && serviceHealthState != "initializing" {
-				var findCounterService model.ContainerCount
-				if err := repository.GetCounterByContainerID(&findCounterService, serviceID); err != nil {
-					if err.Error() == "record not found" {
-						if err := repository.CreateCounterToService(&model.ContainerCount{
-							ContainerID: serviceID,
-							Count:       0,
-							IsService:   true,
-							ServiceName: serviceName,
-							StackName:   stackName,
-						}); err != nil {
-							log.Println("Erro ao criar Counter do serviço")
-							return err
-						}
-
-						return err
-					}
-
-					return err
-				}
-
-				if err := repository.GetCounterByContainerID(&findCounterService, serviceID); err != nil {
-					return err
-				}
-
-				if err := repository.IncrementCounterByContainerID(findCounterService.ContainerID); err != nil {
-					return err
-				}
-
-				var upContainers []Container
-
-				var envName string
-
-				var counters []model.ContainerCount
-				if err := config.DB.Find(&counters).Error; err != nil {
-					return err
-				}
-
-				for _, container := range containers {
-					if container.State == "running" && container.HealthState != "unhealthy" || (container.State == "stopped" && container.HealthState != "unhealthy") {
-						upContainers = append(upContainers, container)
-						for _, counter := range counters {
-							if counter.ContainerID == container.ID {
-								if counter.Count != 0 {
-									counter.Count = 0
-									if err := repository.ChangeToZeroCounter(&counter); err != nil {
-										return err
-									}
-								}
-							}
-						}
-					} else {
-						var counterByContainerID model.ContainerCount
-						err := repository.GetCounterByContainerID(&counterByContainerID, container.ID)
-						if err != nil {
-							if err.Error() == "record not found" {
-								repository.CreateCounterToContainer(&model.ContainerCount{
-									ContainerID: container.ID,
-									Count:       0,
-								})
-							}
-							err := repository.GetCounterByContainerID(&counterByContainerID, container.ID)
-							if err != nil {
-								return err
-							}
-
-							return err
-						}
-
-						if counterByContainerID.Count >= 2 {
-							if serviceState != "inactive" {
-								resp := rancherListener.GetAllEnvironmentsFromRancher()
-
-								data := gjson.Get(resp, "data")
-								data.ForEach(func(key, value gjson.Result) bool {
-									if value.Get("id").String() == task.RancherProjectID {
-										envName = value.Get("name").String()
-									}
-
-									return true
-								})
-
-								s.client.PostMessage(task.ChannelToSendAlert, slack.MsgOptionText(fmt.Sprintf("Please, check the service `%s/%s` in Environment `%s` actually is `%s`", stackName, serviceName, envName, serviceHealthState), true))
-								return nil
-							}
-
-							return nil
-						}
-
-						err = repository.IncrementCounterByContainerID(container.ID)
-						if err != nil {
-							return err
-						}
-
-						if task.IsRestartEnabled {
-							if counterByContainerID.Count == 1 {
-								rancherListener.DeleteContainer(container.ID)
-							} else {
-								rancherListener.RestartContainer(container.ID)
-							}
-						}
-
-						resp := rancherListener.GetAllEnvironmentsFromRancher()
-
-						data := gjson.Get(resp, "data")
-						data.ForEach(func(key, value gjson.Result) bool {
-							if value.Get("id").String() == task.RancherProjectID {
-								envName = value.Get("name").String()
-							}
-
-							return true
-						})
-
-						s.client.PostMessage(task.ChannelToSendAlert, slack.MsgOptionText(fmt.Sprintf("Please, check the service `%s/%s` in Environment `%s` actually is `%s`", stackName, serviceName, envName, serviceHealthState), true))
-
-						fileName := rancherListener.LogsContainer(container.ID)
-
-						time.Sleep(10 * time.Second)
-
-						_, err = s.client.UploadFile(slack.FileUploadParameters{
-							File:     fileName,
-							Filename: fileName,
-							Filetype: "text",
-							Channels: []string{
-								s.channelID,
-							},
-						})
-					}
-				}
-
-				// if serviceState != "inactive" && container != "stopped" {
-				// 	s.client.PostMessage(task.ChannelToSendAlert, slack.MsgOptionText(fmt.Sprintf("Please, check the service `%s/%s` in Environment `%s` actually is `%s`", stackName, serviceName, envName, serviceHealthState), true))
-				// }
-
-				resp := rancherListener.GetAllEnvironmentsFromRancher()
+			recordSystemAudit(auditSystemMonitor, model.AuditEvent{
+				Channel:   task.ChannelToSendAlert,
+				Command:   taskRecovery,
+				RancherID: task.RancherID,
+				ProjectID: task.RancherProjectID,
+				Resource:  container.ID,
+			}, err, fmt.Sprintf("container %s of %s %s by task %d", container.Name, task.Service, action, task.ID))
+
+			if err != nil {
+				CheckErr(fmt.Sprintf("Error on recover container %s", container.ID), err)
+				continue
+			}
+			recovered = append(recovered, fmt.Sprintf("`%s` %s", container.Name, action))
+		}
+		health.RecoveryAttempts++
+	}
 
-				data := gjson.Get(resp, "data")
-				data.ForEach(func(key, value gjson.Result) bool {
-					if value.Get("id").String() == task.RancherProjectID {
-						envName = value.Get("name").String()
-					}
+	var incident *model.Incident
+	if health.IncidentID != 0 {
+		found, err := service.FindIncident(health.IncidentID)
+		CheckErr(fmt.Sprintf("Error on find incident %d", health.IncidentID), err)
 
-					return true
-				})
+		// resolvido no Slack: a task fica sem incidente até se recuperar e falhar de novo
+		if err == nil && found.Status != model.IncidentResolved {
+			incident = &found
+		} else if err == nil {
+			health.IncidentID = 0
+		}
+	}
 
-				s.client.PostMessage(task.ChannelToSendAlert, slack.MsgOptionText(fmt.Sprintf("Please, check the service `%s/%s` in Environment `%s` actually is `%s`", stackName, serviceName, envName, serviceHealthState), true))
-			} else {
-				var findCounterService model.ContainerCount
-				if err := repository.GetCounterByContainerID(&findCounterService, serviceID); err != nil {
-					return err
-				}
+	if transition.Alert || transition.Recovered || transition.FlappingStarted || transition.FlappingStopped || incident != nil {
+		envName, err := rancherListener.GetEnvironmentName(task.RancherProjectID)
+		if err != nil {
+			CheckErr(fmt.Sprintf("Error on get environment of task %d", task.ID), err)
+			envName = task.RancherProjectID
+		}
 
-				var envName string
-				resp := rancherListener.GetAllEnvironmentsFromRancher()
+		alert := taskAlert{task: task, health: &health, incident: incident, svc: svc, envName: envName}
 
-				data := gjson.Get(resp, "data")
-				data.ForEach(func(key, value gjson.Result) bool {
-					if value.Get("id").String() == task.RancherProjectID {
-						envName = value.Get("name").String()
-					}
+		if transition.Alert {
+			s.openTaskAlert(&alert)
 
-					return true
-				})
+			if len(unhealthyContainers) > 0 && alert.incident != nil && alert.incident.MessageTS != "" {
+				go s.uploadContainerLogs(alert.incident.Channel, alert.incident.MessageTS, rancherListener, unhealthyContainers[0].ID)
+			}
+		} else if alert.incident != nil && !transition.Recovered {
+			s.updateTaskAlert(alert, silence)
+		}
 
-				if findCounterService.Count > 1 {
-					s.client.PostMessage(task.ChannelToSendAlert, slack.MsgOptionText(fmt.Sprintf("The service `%s/%s` of environment `%s` is back! Actually is `%s`", stackName, serviceName, envName, serviceHealthState), true))
-				}
+		if len(recovered) > 0 {
+			s.replyTaskAlert(alert, fmt.Sprintf("Recovery attempt %d: %s", health.RecoveryAttempts, strings.Join(recovered, ", ")))
+		}
 
-				if err := repository.ChangeToZeroCounter(&findCounterService); err != nil {
-					log.Printf("%s", err.Error())
-					return err
-				}
+		if transition.FlappingStarted {
+			s.replyTaskAlert(alert, fmt.Sprintf(":warning: The service `%s` in Environment `%s` is flapping (%d failures in %d minutes), alerts are suppressed until it gets stable", task.Service, envName, health.FlapCount, task.FlapWindowMinutes))
+		}
 
-				var counters []model.ContainerCount
-				if err := config.DB.Find(&counters).Error; err != nil {
-					return err
-				}
+		if transition.Recovered {
+			s.closeTaskAlert(&alert)
+		}
 
-				for _, container := range containers {
-					if container.State == "running" && container.HealthState != "unhealthy" {
-						for _, counter := range counters {
-							if counter.ContainerID == container.ID {
-								if counter.Count != 0 {
-									counter.Count = 0
-									if err := repository.ChangeToZeroCounter(&counter); err != nil {
-										return err
-									}
-								}
-							}
-						}
-					}
-				}
-			}
+		if transition.FlappingStopped {
+			s.replyTaskAlert(alert, fmt.Sprintf("The service `%s` of environment `%s` stopped flapping, actually is `%s`. Alerts are enabled again", task.Service, envName, svc.HealthState))
 		}
 	}
 
-	return nil
+	return service.SaveTaskHealth(&health)
 }
 
-func (s *SlackListener) listAllRanchers(ev *slack.MessageEvent) {
+func (s *SlackListener) listAllRanchers(ev *slack.MessageEvent, args CommandArgs) {
 	ranchers, err := service.ListRancher()
 	if err != nil {
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText("Error, verify if database is active", false))
+		s.reply(ev, slack.MsgOptionText("Error, verify if database is active", false))
 		return
 	}
 
 	msg := "*Registered Ranchers:*\n\n"
 	for _, rancher := range ranchers {
-		msg += fmt.Sprintf("Name: `%s`\nURL: `%s`\nAccess Key: `%s`\n\n", rancher.Name, rancher.URL, rancher.AccessKey)
+		msg += fmt.Sprintf("Name: `%s`\nType: `%s`\nURL: `%s`\nAccess Key: `%s`\n\n", rancher.Name, rancher.Type, rancher.URL, rancher.AccessKey)
 	}
 
-	s.client.PostMessage(ev.Channel, slack.MsgOptionText(msg, false))
+	s.reply(ev, slack.MsgOptionText(msg, false))
 }
 
-func (s *SlackListener) selectEnvironment(ev *slack.MessageEvent) {
-	args := strings.Split(ev.Msg.Text, " ")
-
-	if len(args) == 3 {
-		var idEnv string
-		var haveEnv bool
-
-		environment := args[2]
-		environment = strings.Replace(environment, "_", " ", -1)
+// slackSecretRotate criptografa de novo com a chave atual as secret keys dos
+// Ranchers criptografadas com uma chave anterior
+func (s *SlackListener) slackSecretRotate(ev *slack.MessageEvent, args CommandArgs) {
+	if !s.authorizeGlobal(ev, secretRotate) {
+		return
+	}
 
-		resp := rancherListener.GetAllEnvironmentsFromRancher()
+	count, err := service.RotateRancherSecrets()
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on rotate the secret keys, %d were rotated before the error", count), err)
+		return
+	}
 
-		data := gjson.Get(resp, "data")
-		data.ForEach(func(key, value gjson.Result) bool {
-			if value.Get("name").String() == environment {
-				idEnv = value.Get("id").String()
-				haveEnv = true
-			}
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("%d Rancher secret keys encrypted again with the key `%s`. The previous keys can be removed from SECRET_ENCRYPTION_PREVIOUS_KEYS.", count, config.Secrets.KeyID()), false))
+}
 
-			return true
-		})
+func (s *SlackListener) selectEnvironment(ev *slack.MessageEvent, args CommandArgs) {
+	env, err := findEnvironment(s.orchestrator, args.String("environment-name"))
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on select environment `%s`", args.String("environment-name")), err)
+		return
+	}
 
-		if haveEnv && idEnv != "" {
-			rancherListener.projectID = idEnv
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Environment `%s` selected successfully!", environment), false))
-			return
-		}
+	selection := model.SlackContext{
+		User:        ev.User,
+		RancherID:   s.context.rancherID,
+		ProjectID:   env.ID,
+		Environment: env.Name,
+	}
 
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Error on select environment `%s`, check if it exists!", environment), false))
+	if !s.setSlackContext(ev, args, &selection) {
+		return
 	}
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Environment `%s` of Rancher `%s` selected successfully for %s!", env.Name, s.context.rancherName, contextScopeText(selection)), false))
 }
 
-func (s *SlackListener) listAllEnvironments(ev *slack.MessageEvent) {
-	resp := rancherListener.GetAllEnvironmentsFromRancher()
+func (s *SlackListener) listAllEnvironments(ev *slack.MessageEvent, args CommandArgs) {
+	environments, err := s.orchestrator.ListEnvironments()
+	if err != nil {
+		s.postError(ev, "Error on list environments", err)
+		return
+	}
 
 	msg := "*Environments from this Rancher:*\n\n"
 
-	data := gjson.Get(resp, "data")
-	data.ForEach(func(key, value gjson.Result) bool {
-		msg += fmt.Sprintf("`%s`\n", value.Get("name").String())
-
-		return true
-	})
+	for _, env := range environments {
+		msg += fmt.Sprintf("`%s`\n", env.Name)
+	}
 
-	s.client.PostMessage(ev.Channel, slack.MsgOptionText(msg, false))
+	s.reply(ev, slack.MsgOptionText(msg, false))
 }
 
-func (s *SlackListener) selectRancher(ev *slack.MessageEvent) {
-	args := strings.Split(ev.Msg.Text, " ")
+func (s *SlackListener) selectRancher(ev *slack.MessageEvent, args CommandArgs) {
+	rancherInstance := args.String("rancher-name")
 
-	if len(args) == 3 {
-		rancherInstance := args[2]
+	rancherID, err := findRancherID(rancherInstance)
+	if err != nil {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Error on select Rancher `%s`, make sure it is registered!", rancherInstance), false))
+		return
+	}
 
-		var rancher model.Rancher
-		rancher.Name = rancherInstance
+	projectID := ""
+	if rancherID == 0 {
+		projectID = RancherProjectID
+	}
 
-		err := repository.FindRancherByName(&rancher)
-		if err != nil {
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Error on select Rancher `%s`, make sure it is registered!", rancherInstance), false))
-			return
-		}
+	selected, err := newCommandContext(rancherID, projectID)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on select Rancher `%s`", rancherInstance), err)
+		return
+	}
 
-		rancherListener.ID = rancher.ID
-		rancherListener.baseURL = rancher.URL
-		rancherListener.accessKey = rancher.AccessKey
-		rancherListener.secretKey = rancher.SecretKey
+	selection := model.SlackContext{
+		User:      ev.User,
+		RancherID: rancherID,
+		ProjectID: projectID,
+	}
 
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Rancher `%s` selected successfully!", rancherInstance), false))
+	if !s.setSlackContext(ev, args, &selection) {
+		return
 	}
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Rancher `%s` (`%s`) selected successfully for %s! Now select an environment with `%s`", rancherInstance, selected.orchestrator.Backend(), contextScopeText(selection), selectEnvironment), false))
 }
 
-func (s *SlackListener) listAllRunningTasks(ev *slack.MessageEvent) {
+func (s *SlackListener) listAllRunningTasks(ev *slack.MessageEvent, args CommandArgs) {
 
 	msg := "*Running Tasks List:* \n\n"
 
 	var tasks []model.Task
 	err := repository.ListTask(&tasks)
 	if err != nil {
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText("Error on check running tasks. Verify if the BOT have connection with database", false))
+		s.reply(ev, slack.MsgOptionText("Error on check running tasks. Verify if the BOT have connection with database", false))
 		return
 	}
 
+	health, err := service.ListTaskHealth()
+	if err != nil {
+		CheckErr("Error on list health of tasks", err)
+	}
+
 	for _, task := range tasks {
-		var envName string
-		ranchList := &RancherListener{
-			accessKey: task.RancherAccessKey,
-			secretKey: task.RancherSecretKey,
-			baseURL:   task.RancherURL,
+		envName := task.RancherProjectID
+		ranchList, err := taskRancherListener(task.RancherID, task.RancherProjectID)
+		if err == nil {
+			envName, err = ranchList.GetEnvironmentName(task.RancherProjectID)
+		}
+		if err != nil {
+			CheckErr(fmt.Sprintf("Error on get environment of task %d", task.ID), err)
+			envName = task.RancherProjectID
 		}
-		resp := ranchList.GetAllEnvironmentsFromRancher()
 
-		data := gjson.Get(resp, "data")
-		data.ForEach(func(key, value gjson.Result) bool {
-			if value.Get("id").String() == task.RancherProjectID {
-				envName = value.Get("name").String()
+		if task.IsOnlyCheck == true {
+			msg += fmt.Sprintf("*%d* / %s - Environment `%s` - Is only check! / Schedule: %s\n", task.ID, task.Service, envName, taskScheduleDescription(task))
+		} else {
+			state := model.HealthHealthy
+			if h, ok := health[task.ID]; ok {
+				state = h.State
+				if h.Flapping {
+					state += ", flapping"
+				}
 			}
 
-			return true
-		})
-		if string(task.ID) != "" {
-			if task.IsOnlyCheck == true {
-				msg += fmt.Sprintf("*%d* / %s - Environment `%s` - Is only check!\n", task.ID, task.Service, envName)
-			} else {
-				msg += fmt.Sprintf("*%d* / %s - Environment `%s` / Restart: `%s`\n", task.ID, task.Service, envName, task.IsRestartEnabled)
-			}
+			msg += fmt.Sprintf("*%d* / %s - Environment `%s` / Restart: `%t` / Schedule: %s / State: `%s`\n", task.ID, task.Service, envName, task.IsRestartEnabled, taskScheduleDescription(task), state)
 		}
 	}
 
-	s.client.PostMessage(ev.Channel, slack.MsgOptionText(msg, false))
+	s.reply(ev, slack.MsgOptionText(msg, false))
 }
 
-func (s *SlackListener) stopServiceCheck(ev *slack.MessageEvent) {
-	args := strings.Split(ev.Msg.Text, " ")
+func (s *SlackListener) stopServiceCheck(ev *slack.MessageEvent, args CommandArgs) {
+	taskIDToStop := args.String("task-ID")
 
-	if len(args) == 3 {
-		taskIDToStop := args[2]
+	var tasks []model.Task
+	err := repository.ListTask(&tasks)
 
-		var tasks []model.Task
-		err := repository.ListTask(&tasks)
+	if err != nil {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Failed to stop task `%s`, check if this task is already running or if the database is running", taskIDToStop), false))
+		return
+	}
 
-		if err != nil {
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Failed to stop task `%s`, check if this task is already running or if the database is running", taskIDToStop), false))
+	if taskIDToStop == "all" {
+		s.stopAllowedTasks(ev, tasks)
+		return
+	}
+
+	// todas as tasks são verificadas antes de parar alguma
+	ids := strings.Split(taskIDToStop, ",")
+	var tasksToStop []model.Task
+	for _, id := range ids {
+		task, found := findTaskByID(tasks, strings.TrimSpace(id))
+		if !found {
+			s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Task `%s` not found, the IDs are listed by `%s`", id, listAllRunningTasks), false))
 			return
 		}
 
-		if taskIDToStop == "all" {
-			for _, task := range tasks {
-				err = service.DeleteTask(task)
-				if err != nil {
-					s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Failed to stop task `%s`", taskIDToStop), false))
-					return
-				}
+		if !s.authorizeObject(ev, removeServiceCheck, fmt.Sprintf("task `%d`", task.ID), task.RancherID, task.RancherProjectID) {
+			return
+		}
 
-			}
+		tasksToStop = append(tasksToStop, task)
+	}
 
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText("All tasks stopped!", false))
+	for _, task := range tasksToStop {
+		if err := service.DeleteTask(task); err != nil {
+			s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Failed to stop task `%d`", task.ID), false))
+			return
 		}
+	}
 
-		if taskIDToStop != "all" {
-			if strings.Contains(taskIDToStop, ",") {
-				ids := strings.Split(taskIDToStop, ",")
-
-				for _, id := range ids {
-					for _, task := range tasks {
-						if fmt.Sprintf("%d", task.ID) == id {
-							err = service.DeleteTask(task)
-							if err != nil {
-								s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Failed to stop task `%d`", task.ID), false))
-								return
-							}
-						}
-					}
-				}
+	if len(tasksToStop) == 1 {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Task *%d*/`%s` stopped successfully!", tasksToStop[0].ID, tasksToStop[0].Service), false))
+		return
+	}
 
-				s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Tasks with ID's: %s are stopped!", ids), false))
-			} else {
-				var taskToStop model.Task
-				for _, task := range tasks {
-					if fmt.Sprintf("%d", task.ID) == taskIDToStop {
-						taskToStop = task
-					}
-				}
-				err = service.DeleteTask(taskToStop)
-				if err != nil {
-					s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Failed to stop task `%s`", taskIDToStop), false))
-					return
-				}
-				s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Task *%s*/`%s` stopped successfully!", taskIDToStop, taskToStop.Service), false))
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Tasks with ID's: %s are stopped!", ids), false))
+}
+
+// stopAllowedTasks para as tasks dos Ranchers e environments em que o usuário
+// pode executar o task-stop, mantendo as outras
+func (s *SlackListener) stopAllowedTasks(ev *slack.MessageEvent, tasks []model.Task) {
+	allowedScopes := map[string]bool{}
+	var stopped, kept int
+
+	for _, task := range tasks {
+		scope := fmt.Sprintf("%d|%s", task.RancherID, task.RancherProjectID)
+
+		allowed, checked := allowedScopes[scope]
+		if !checked {
+			var err error
+			if allowed, err = s.commandAllowed(removeServiceCheck, ev.User, task.RancherID, task.RancherProjectID); err != nil {
+				s.postError(ev, fmt.Sprintf("Error on check the permissions of `%s`", removeServiceCheck), err)
+				return
 			}
+			allowedScopes[scope] = allowed
+		}
+
+		if !allowed {
+			kept++
+			continue
+		}
 
+		if err := service.DeleteTask(task); err != nil {
+			s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Failed to stop task `%d`, %d tasks were stopped before the error", task.ID, stopped), false))
+			return
 		}
+		stopped++
 	}
-}
 
-func (s *SlackListener) slackCheckServiceByKeyword(ev *slack.MessageEvent) {
-	args := strings.Split(ev.Msg.Text, " ")
+	if stopped == 0 && kept > 0 {
+		s.denyCommand(ev, fmt.Sprintf("%s, you are not allowed to run `%s` on the Ranchers and environments of the %d tasks", mentionUser(ev.User), removeServiceCheck, kept))
+		return
+	}
 
-	if len(args) >= 4 {
-		keywordsInCommand := args[2]
-		channelInCommand := args[3]
-		deleteInCommand := args[4]
+	if kept > 0 {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("%d tasks stopped! %d tasks of Ranchers and environments where you are not allowed to run `%s` were kept.", stopped, kept, removeServiceCheck), false))
+		return
+	}
 
-		var ranchers []model.Rancher
-		err := repository.ListRancher(&ranchers)
-		if err != nil {
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText("Erro ao carregar Ranchers da base", false))
-			return
+	s.reply(ev, slack.MsgOptionText("All tasks stopped!", false))
+}
+
+// findTaskByID procura na lista a task com o ID digitado
+func findTaskByID(tasks []model.Task, ID string) (model.Task, bool) {
+	for _, task := range tasks {
+		if fmt.Sprintf("%d", task.ID) == ID {
+			return task, true
 		}
+	}
 
-		if strings.Contains(keywordsInCommand, ",") {
-			keywords := strings.Split(keywordsInCommand, ",")
-
-			for _, keyword := range keywords {
-				// if serviceId != inativo
-				//pier
-				for _, rancher := range ranchers {
-					rancherListener = &RancherListener{
-						accessKey: rancher.AccessKey,
-						secretKey: rancher.SecretKey,
-						baseURL:   rancher.URL,
-					}
-					resp := rancherListener.GetAllEnvironmentsFromRancher()
+	return model.Task{}, false
+}
 
-					data := gjson.Get(resp, "data")
-					data.ForEach(func(key, value gjson.Result) bool {
-						envID := value.Get("id").String()
+func (s *SlackListener) slackCheckServiceByKeyword(ev *slack.MessageEvent, args CommandArgs) {
+	var ranchers []model.Rancher
+	err := repository.ListRancher(&ranchers)
+	if err != nil {
+		s.postError(ev, "Error on list the registered Ranchers", err)
+		return
+	}
 
-						rancherListener.projectID = envID
-						respAllStacks := rancherListener.GetStacks()
+	// o Rancher das variáveis de ambiente (ID 0) também é procurado
+	if RanchListener != nil && RanchListener.baseURL != "" {
+		ranchers = append([]model.Rancher{{Name: defaultRancherName}}, ranchers...)
+	}
 
-						dataStack := gjson.Get(respAllStacks, "data")
-						dataStack.ForEach(func(key, value gjson.Result) bool {
-							stackID := value.Get("id").String()
-							stackName := value.Get("name").String()
+	keywords := strings.Split(args.String("keyword1,keyword2"), ",")
 
-							if strings.Contains(stackName, keyword) {
-								respAllServicesFromStack := rancherListener.GetServicesFromStack(stackID)
+	// as tasks só são criadas nos environments em que o usuário pode executar o comando
+	allowedScopes := map[string]bool{}
+	var added, skipped []string
 
-								dataService := gjson.Get(respAllServicesFromStack, "data")
-								dataService.ForEach(func(key, value gjson.Result) bool {
-									serviceName := value.Get("name").String()
+	for _, keyword := range keywords {
+		for _, rancher := range ranchers {
+			if rancher.Type == model.RancherTypeKubernetes {
+				continue
+			}
+			rancherID, rancherName := rancher.ID, rancher.Name
 
-									if strings.Contains(serviceName, keyword) {
-										ev.Msg.Text = fmt.Sprintf("@jeremias task-add %s/%s %s %s", stackName, serviceName, channelInCommand, deleteInCommand)
-										s.slackCheckServiceHealth(ev)
-									}
+			listener, err := taskRancherListener(rancherID, "")
+			if err != nil {
+				s.postError(ev, fmt.Sprintf("Error on connect on Rancher `%s`", rancherName), err)
+				continue
+			}
 
-									return true
-								})
-							}
+			projects, err := listener.GetAllEnvironmentsFromRancher()
+			if err != nil {
+				s.postError(ev, fmt.Sprintf("Error on list environments of Rancher `%s`", rancherName), err)
+				continue
+			}
 
-							return true
-						})
+			for _, project := range projects {
+				scope := fmt.Sprintf("%d|%s", rancherID, project.ID)
+				allowed, checked := allowedScopes[scope]
+				if !checked {
+					if allowed, err = s.commandAllowed(taskAddByKeyword, ev.User, rancherID, project.ID); err != nil {
+						s.postError(ev, fmt.Sprintf("Error on check the permissions of `%s`", taskAddByKeyword), err)
+						return
+					}
+					allowedScopes[scope] = allowed
 
-						return true
-					})
-				}
-			}
-		} else {
-			for _, rancher := range ranchers {
-				rancherListener = &RancherListener{
-					accessKey: rancher.AccessKey,
-					secretKey: rancher.SecretKey,
-					baseURL:   rancher.URL,
+					if !allowed {
+						skipped = append(skipped, fmt.Sprintf("environment `%s` of Rancher `%s`", project.Name, rancherName))
+					}
 				}
-				resp := rancherListener.GetAllEnvironmentsFromRancher()
 
-				data := gjson.Get(resp, "data")
-				data.ForEach(func(key, value gjson.Result) bool {
-					envID := value.Get("id").String()
-
-					rancherListener.projectID = envID
-					respAllStacks := rancherListener.GetStacks()
+				if !allowed {
+					continue
+				}
 
-					dataStack := gjson.Get(respAllStacks, "data")
-					dataStack.ForEach(func(key, value gjson.Result) bool {
-						stackID := value.Get("id").String()
-						stackName := value.Get("name").String()
+				listener.projectID = project.ID
 
-						if strings.Contains(stackName, keywordsInCommand) {
-							respAllServicesFromStack := rancherListener.GetServicesFromStack(stackID)
+				stacks, err := listener.GetStacks()
+				if err != nil {
+					s.postError(ev, fmt.Sprintf("Error on list stacks of environment `%s`", project.Name), err)
+					continue
+				}
 
-							dataService := gjson.Get(respAllServicesFromStack, "data")
-							dataService.ForEach(func(key, value gjson.Result) bool {
-								serviceName := value.Get("name").String()
+				for _, stack := range stacks {
+					if !strings.Contains(stack.Name, keyword) {
+						continue
+					}
 
-								if strings.Contains(serviceName, keywordsInCommand) {
-									ev.Msg.Text = fmt.Sprintf("@jeremias task-add %s/%s %s %s", stackName, serviceName, channelInCommand, deleteInCommand)
-									s.slackCheckServiceHealth(ev)
-								}
+					services, err := listener.GetServicesFromStack(stack.ID)
+					if err != nil {
+						s.postError(ev, fmt.Sprintf("Error on list services of stack `%s`", stack.Name), err)
+						continue
+					}
 
-								return true
-							})
+					for _, svc := range services {
+						if !strings.Contains(svc.Name, keyword) {
+							continue
 						}
 
-						return true
-					})
+						task := newHealthTask(rancherID, project.ID, fmt.Sprintf("%s/%s", stack.Name, svc.Name), args.String("channel-to-send-alert"), args.Bool("restart"))
+						if err := service.AddTask(task); err != nil {
+							s.postError(ev, fmt.Sprintf("Error on register task of `%s`", task.Service), err)
+							continue
+						}
 
-					return true
-				})
+						added = append(added, fmt.Sprintf("*%d* / %s - Environment `%s` of Rancher `%s`", task.ID, task.Service, project.Name, rancherName))
+					}
+				}
 			}
 		}
 	}
-}
 
-func (s *SlackListener) slackCheckServiceHealth(ev *slack.MessageEvent) {
-	args := strings.Split(ev.Msg.Text, " ")
+	var note string
+	if len(skipped) > 0 {
+		note = fmt.Sprintf("\n_Skipped, you are not allowed to run `%s` on: %s_", taskAddByKeyword, strings.Join(skipped, ", "))
+	}
 
-	if len(args) == 4 {
-		task := &model.Task{
-			Service:            args[2],
-			ChannelToSendAlert: args[3],
-			RancherURL:         rancherListener.baseURL,
-			RancherAccessKey:   rancherListener.accessKey,
-			RancherSecretKey:   rancherListener.secretKey,
-			RancherProjectID:   rancherListener.projectID,
-		}
+	if len(added) == 0 {
+		s.reply(ev, slack.MsgOptionText("No services found with the keywords."+note, false))
+		return
+	}
 
-		task.IsRestartEnabled = false
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Tasks added successfully!\n%s%s", strings.Join(added, "\n"), note), false))
+}
 
-		err := service.AddTask(task)
-		if err != nil {
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText("Error on register task, verify if BOT haves connection with database", false))
-		} else {
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText("Task added successfully!", false))
+func (s *SlackListener) slackCheckServiceHealth(ev *slack.MessageEvent, args CommandArgs) {
+	task := newHealthTask(s.context.rancherID, s.rancherListener.projectID, args.String("stackName/serviceName"), args.String("channel-to-send-alert"), args.Bool("restart"))
+
+	for flag, value := range map[string]*int{
+		"failing-after":   &task.FailingAfter,
+		"recovered-after": &task.RecoveredAfter,
+		"flap-threshold":  &task.FlapThreshold,
+	} {
+		if !args.Has(flag) {
+			continue
+		}
+		if *value = args.Int(flag); *value <= 0 {
+			s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Invalid value `%s` for --%s, use a number greater than zero", args.String(flag), flag), false))
+			return
 		}
 	}
 
-	if len(args) == 5 {
-		task := &model.Task{
-			Service:            args[2],
-			ChannelToSendAlert: args[3],
-			RancherURL:         rancherListener.baseURL,
-			RancherAccessKey:   rancherListener.accessKey,
-			RancherSecretKey:   rancherListener.secretKey,
-			RancherProjectID:   rancherListener.projectID,
+	if args.Has("flap-window") {
+		window := args.Duration("flap-window")
+		if window < time.Minute {
+			s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Invalid value `%s` for --flap-window, use a duration like `30m` or `2h`", args.String("flap-window")), false))
+			return
 		}
+		task.FlapWindowMinutes = int(window / time.Minute)
+	}
 
-		if args[4] == "true" {
-			task.IsRestartEnabled = true
-		} else {
-			task.IsRestartEnabled = false
-		}
+	task.IntervalSeconds = int64(args.Duration("every") / time.Second)
+	task.Cron = args.String("cron")
 
-		err := service.AddTask(task)
-		if err != nil {
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText("Error on register task, verify if BOT haves connection with database", false))
-		} else {
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText("Task added successfully!", false))
-		}
+	if err := service.AddTask(task); err != nil {
+		s.postError(ev, "Error on register task", err)
+		return
 	}
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Task added successfully! Running %s", taskScheduleDescription(*task)), false))
 }
 
-func (s *SlackListener) slackCanaryInfo(ev *slack.MessageEvent) {
-	args := strings.Split(ev.Msg.Text, " ")
-	if len(args) == 3 {
-		lbid := args[2]
+// newHealthTask cria a task de self-healing de um serviço no environment do Rancher
+func newHealthTask(rancherID uint, projectID string, svc string, channel string, restart bool) *model.Task {
+	return &model.Task{
+		Service:            svc,
+		ChannelToSendAlert: channel,
+		IsRestartEnabled:   restart,
+		RancherID:          rancherID,
+		RancherProjectID:   projectID,
+	}
+}
 
-		resp := rancherListener.GetHaproxyCfg(lbid)
-		lbConfig := gjson.Get(resp, "lbConfig.config").String()
+func (s *SlackListener) slackCanaryInfo(ev *slack.MessageEvent, args CommandArgs) {
+	if !args.Has("lb-id") {
+		s.sendPicker(ev, picker{
+			command: canaryInfo,
+			text:    "Which Load Balancer you need the haproxy.cfg?",
+		})
+		return
+	}
 
-		msg := fmt.Sprintf("haproxy.cfg file of Load Balancer `%s`.\n```%s```",
-			lbid, lbConfig)
+	lbid := args.String("lb-id")
 
-		if resp == "error" {
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText("Error", false))
-			return
-		}
+	lb, err := s.rancherListener.GetHaproxyCfg(lbid)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on get haproxy.cfg of Load Balancer `%s`", lbid), err)
+		return
+	}
 
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("ConfigHaprox:\n\n\n%s\n", msg), true))
+	var lbConfig string
+	if lb.LbConfig != nil {
+		lbConfig = lb.LbConfig.Config
 	}
-}
 
-func (s *SlackListener) slackCanaryEnable(ev *slack.MessageEvent) {
-	args := strings.Split(ev.Msg.Text, " ")
+	msg := fmt.Sprintf("haproxy.cfg file of Load Balancer `%s`.\n```%s```",
+		lbid, lbConfig)
 
-	if len(args) == 3 {
-		lb := args[2]
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("ConfigHaprox:\n\n\n%s\n", msg), true))
+}
 
-		resp := rancherListener.EnableCanary(lb)
+func (s *SlackListener) slackCanaryEnable(ev *slack.MessageEvent, args CommandArgs) {
+	if args.Has("lb-id") {
+		lb := args.String("lb-id")
 
-		if resp == "error" {
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText("Error on update haproxy.cfg, check if ID param is right or the body of haproxy.cfg is empty", false))
+		resp, err := s.rancherListener.EnableCanary(lb, ev.User)
+		if err != nil {
+			s.postError(ev, fmt.Sprintf("Error on enable the canary of Load Balancer `%s`", lb), err)
 			return
 		}
 
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("File 'haproxy.cfg' updated success! *Canary Deployment* enabled.\n```%s```", resp), false))
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("File 'haproxy.cfg' updated success! *Canary Deployment* enabled.\n```%s```", resp), false))
 	} else {
-		s.createAndSendAttachment(
-			ev,
-			"Which Load Balancer you need enable the Canary?",
-			canaryActivate,
-			getLbOptions(),
-			&slack.ConfirmationField{
-				Title:       "Are you sure?",
-				Text:        "You sure to enable Canary? :thinking_face:",
-				OkText:      "Yes",
-				DismissText: "No",
-			},
-		)
+		s.sendPicker(ev, picker{
+			command: canaryActivate,
+			text:    "Which Load Balancer you need enable the Canary?",
+			confirm: confirmation("You sure to enable Canary? :thinking_face:"),
+		})
 	}
 
 }
 
-func (s *SlackListener) slackCanaryDisable(ev *slack.MessageEvent) {
-	args := strings.Split(ev.Msg.Text, " ")
-
-	if len(args) == 3 {
-		lb := args[2]
+func (s *SlackListener) slackCanaryDisable(ev *slack.MessageEvent, args CommandArgs) {
+	if args.Has("lb-id") {
+		lb := args.String("lb-id")
 
-		resp := rancherListener.DisableCanary(lb)
-
-		if resp == "error" {
-			s.client.PostMessage(ev.Channel, slack.MsgOptionText("Error on update haproxy.cfg, check if ID param is right or the body of haproxy.cfg is empty", false))
+		resp, err := s.rancherListener.DisableCanary(lb, ev.User)
+		if err != nil {
+			s.postError(ev, "Error on update haproxy.cfg, check if ID param is right or the body of haproxy.cfg is empty", err)
 			return
 		}
 
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("File 'haproxy.cfg' updated success! *Canary Deployment* disabled.\n```%s```", resp), false))
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("File 'haproxy.cfg' updated success! *Canary Deployment* disabled.\n```%s```", resp), false))
 	} else {
-		s.createAndSendAttachment(
-			ev,
-			"Which Load Balancer you need disable the Canary?",
-			canaryDisable,
-			getLbOptions(),
-			&slack.ConfirmationField{
-				Title:       "Are you sure?",
-				Text:        "You sure to disable Canary? :scream:",
-				OkText:      "Yes",
-				DismissText: "No",
-			},
-		)
+		s.sendPicker(ev, picker{
+			command: canaryDisable,
+			text:    "Which Load Balancer you need disable the Canary?",
+			confirm: confirmation("You sure to disable Canary? :scream:"),
+		})
 	}
 
 }
 
-func (s *SlackListener) slackServiceUpgrade(ev *slack.MessageEvent) {
-	args := strings.Split(ev.Msg.Text, " ")
-
-	if len(args) != 4 {
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Command call error, correct syntax: @name-of-bot %s service-id new-image", upgradeService), false))
+func (s *SlackListener) slackServiceUpgrade(ev *slack.MessageEvent, args CommandArgs) {
+	if !args.Has("service-id") {
+		s.sendPicker(ev, picker{
+			command: upgradeService,
+			text:    "Which service you need to upgrade? The new image is asked after the selection",
+		})
 		return
 	}
 
-	serviceID := args[2]
-	newServiceImage := args[3]
+	serviceID := args.String("service-id")
+	newServiceImage := args.String("new-image")
+
+	if newServiceImage == "" {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Missing `new-image` of the service `%s`. Ex.: docker:ubuntu:14.04", serviceID), false))
+		return
+	}
 
 	if !strings.HasPrefix(newServiceImage, "docker:") {
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText("Image name needs to start with 'docker:'. Ex.: docker:ubuntu:14.04", false))
+		s.reply(ev, slack.MsgOptionText("Image name needs to start with 'docker:'. Ex.: docker:ubuntu:14.04", false))
 		return
 	}
 
-	resp := rancherListener.UpgradeService(serviceID, newServiceImage)
+	// no Rancher 1.6 o upgrade é acompanhado até ser terminado ou revertido
+	if s.rancherListener != nil {
+		s.startServiceUpgrade(ev, serviceID, newServiceImage, args)
+		return
+	}
 
-	if resp == "" {
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText("Service upgrade error. Check:\n*- If service ID really exists*\n*- If service is not in upgrading state*", false))
+	if args.Has("batch-size") || args.Has("interval") || args.Has("auto-finish-after") {
+		s.reply(ev, slack.MsgOptionText("`--batch-size`, `--interval` and `--auto-finish-after` are only available for Rancher 1.6", false))
 		return
 	}
 
-	msg := fmt.Sprintf("Service updated successfuly! New image of the service `%s` is `%s`", serviceID, resp)
+	workload, err := s.orchestrator.UpgradeWorkload(serviceID, newServiceImage)
+	if err != nil {
+		s.postError(ev, "Service upgrade error. Check:\n*- If service ID really exists*\n*- If service is not in upgrading state*", err)
+		return
+	}
+
+	msg := fmt.Sprintf("Service updated successfuly! New image of the service `%s` is `%s`", serviceID, workload.Image)
 
 	log.Printf("[INFO] Service %s updated by %s\n", serviceID, ev.Msg.User)
-	s.client.PostMessage(ev.Channel, slack.MsgOptionText(msg, false))
+	s.reply(ev, slack.MsgOptionText(msg, false))
 }
 
-func (s *SlackListener) slackServicesList(ev *slack.MessageEvent) {
-	resp := rancherListener.ListServices()
+func (s *SlackListener) slackServicesList(ev *slack.MessageEvent, args CommandArgs) {
+	workloads, err := s.orchestrator.ListWorkloads()
+	if err != nil {
+		s.postError(ev, "Error on list services", err)
+		return
+	}
 
 	msg := "*Service List:* \n\n"
 
-	data := gjson.Get(resp, "data")
-	data.ForEach(func(key, value gjson.Result) bool {
-		msg += fmt.Sprintf("`%s | %s`\n", value.Get("id").String(), value.Get("name").String())
-		return true
-	})
+	for _, workload := range workloads {
+		msg += fmt.Sprintf("`%s | %s`\n", workload.ID, workload.Name)
+	}
 
-	s.client.PostMessage(ev.Channel, slack.MsgOptionText(msg, false))
+	s.reply(ev, slack.MsgOptionText(msg, false))
 }
 
-func (s *SlackListener) slackServiceInfo(ev *slack.MessageEvent) {
-	s.createAndSendAttachment(
-		ev,
-		"Which service you need informations? :sunglasses:",
-		getServiceInfo,
-		getServices(),
-		nil,
-	)
-}
+func (s *SlackListener) slackServiceInfo(ev *slack.MessageEvent, args CommandArgs) {
+	if !args.Has("service-id") {
+		s.sendPicker(ev, picker{
+			command: getServiceInfo,
+			text:    "Which service you need informations? :sunglasses:",
+		})
+		return
+	}
 
-func (s *SlackListener) slackCommandHelper(ev *slack.MessageEvent, message string) {
-	var msg string
+	serviceID := args.String("service-id")
 
-	for _, cmd := range Commands {
-		if cmd.Cmd == message {
-			cmd.Usage = strings.Replace(cmd.Usage, "command", cmd.Cmd, 1)
-			msg = fmt.Sprintf("*Command:* `%s`\n*Description:* _%s_\n*Usage:* _%s_\n*Lint:* _%s_", cmd.Cmd, cmd.Description, cmd.Usage, cmd.Lint)
-		}
+	workload, err := findWorkload(s.orchestrator, serviceID)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on get service `%s`", serviceID), err)
+		return
 	}
 
-	if msg == "" {
-		msg = "Command not found."
+	msg := fmt.Sprintf("*ID:* `%s`\n*Name:* `%s`\n*Stack:* `%s`\n*Image:* `%s`\n*Status:* `%s`\n*Health:* `%s`\n*Scale:* `%d`",
+		workload.ID, workload.Name, workload.Group, workload.Image, workload.State, workload.HealthState, workload.Scale)
+
+	s.reply(ev, slack.MsgOptionText(msg, false))
+}
+
+func (s *SlackListener) slackCommandHelper(ev *slack.MessageEvent, message string) {
+	msg := "Command not found."
+
+	if cmd := findCommand(message); cmd != nil {
+		msg = fmt.Sprintf("*Command:* `%s`\n*Description:* _%s_\n*Usage:* _%s_\n*Lint:* _%s_", cmd.Cmd, cmd.Description, cmd.Usage, cmd.lint())
+		switch {
+		case cmd.Restricted && cmd.Global:
+			msg += "\n*Restricted:* _only users with a role that allows this command on the Rancher and environment of what it changes, or on all Ranchers and environments when it acts on all of them_"
+		case cmd.Restricted:
+			msg += "\n*Restricted:* _only users with a role that allows this command on the selected Rancher and environment_"
+		}
+		if cmd.NeedsApproval {
+			msg += "\n*Approval:* _on protected environments another authorized user must approve the command_"
+		}
 	}
 
-	s.client.PostMessage(ev.Channel, slack.MsgOptionText(msg, false))
+	s.reply(ev, slack.MsgOptionText(msg, false))
 }
 
-func (s *SlackListener) slackHelper(ev *slack.MessageEvent) {
+func (s *SlackListener) slackHelper(ev *slack.MessageEvent, args CommandArgs) {
 	msg := "*Commands:*\n\n"
 
 	for _, cmd := range Commands {
-		msg += fmt.Sprintf("`%s` -> %s\n", cmd.Cmd, cmd.Description)
+		if !cmd.IsActive {
+			continue
+		}
+		if cmd.Restricted {
+			msg += fmt.Sprintf("`%s` -> %s _(restricted)_\n", cmd.Cmd, cmd.Description)
+		} else {
+			msg += fmt.Sprintf("`%s` -> %s\n", cmd.Cmd, cmd.Description)
+		}
 	}
 
 	msg += "\n\n_*PS.:* If you need detailed informations for a command, you can call command followed by *help*._\n_*Ex.:* @jeremias command help_"
+	msg += "\n_Commands can also be called from any channel or DM with `/jeremias command`, answered only to you, or to the channel with `--public`._"
+	msg += fmt.Sprintf("\n_Commands run on the Rancher and environment you selected with `%s` and `%s`, any command accepts `--rancher name` and `--env name` to run on another one._", selectRancher, selectEnvironment)
 
-	s.client.PostMessage(ev.Channel, slack.MsgOptionText(msg, false))
+	s.reply(ev, slack.MsgOptionText(msg, false))
 }
 
-func (s *SlackListener) slackListLoadBalancers(ev *slack.MessageEvent) {
-	loadBalancers := rancherListener.GetLoadBalancers()
+func (s *SlackListener) slackListLoadBalancers(ev *slack.MessageEvent, args CommandArgs) {
+	loadBalancers, err := s.rancherListener.GetLoadBalancers()
+	if err != nil {
+		s.postError(ev, "Error on list Load Balancers", err)
+		return
+	}
 
 	var lines []string
 
@@ -1254,131 +1195,97 @@ func (s *SlackListener) slackListLoadBalancers(ev *slack.MessageEvent) {
 		msg += fmt.Sprintf("\n%s", line)
 	}
 
-	s.client.PostMessage(ev.Channel, slack.MsgOptionText(msg, false))
+	s.reply(ev, slack.MsgOptionText(msg, false))
 }
 
-func (s *SlackListener) slackUpdateCanary(ev *slack.MessageEvent) {
-	var channelToSendMessage string
+func (s *SlackListener) slackUpdateCanary(ev *slack.MessageEvent, args CommandArgs) {
+	lb := args.String("lb-id")
+	channelToSendMessage := args.String("channel-to-send-alert")
 
-	args := strings.Split(ev.Msg.Text, " ")
-
-	if len(args) < 5 {
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Command call error, correct syntax: @name-of-bot %s LB-id new-version-weight old-version-weight", canaryUpdate), false))
+	newWeight, err := parseCanaryWeight(args.String("new-version-weight"), canaryNewRef)
+	if err != nil {
+		s.postError(ev, "Invalid new version weight", err)
 		return
 	}
 
-	lb := args[2]
-	newVersionPercent := args[3]
-	oldVersionPercent := args[4]
-
-	if len(args) == 6 {
-		channelToSendMessage = args[5]
+	oldWeight, err := parseCanaryWeight(args.String("old-version-weight"), canaryOldRef)
+	if err != nil {
+		s.postError(ev, "Invalid old version weight", err)
+		return
 	}
 
-	resp := rancherListener.UpdateCustomHaproxyCfg(lb, newVersionPercent, oldVersionPercent)
-
-	if resp == "error" {
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText("Error on update haproxy.cfg, check if ID param is right, the body of haproxy.cfg is empty or if weights not sum 100", false))
+	resp, err := s.rancherListener.UpdateCustomHaproxyCfg(lb, []CanaryWeight{newWeight, oldWeight}, ev.User)
+	if err != nil {
+		s.postError(ev, "Error on update haproxy.cfg, check if ID param is right, the body of haproxy.cfg is empty or if weights not sum 100", err)
 		return
 	}
-	//v := strconv.FormatBool(resp)
-	s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("File 'haproxy.cfg' updated successfuly!\n```%s```", resp), false))
 
-	if channelToSendMessage != "" {
-		resp := rancherListener.GetService(lb)
-
-		serviceName := gjson.Get(resp, "name").String()
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("File 'haproxy.cfg' updated successfuly!\n```%s```", resp), false))
 
-		s.client.PostMessage(channelToSendMessage, slack.MsgOptionText(fmt.Sprintf("Canary of `%s` has been updated.\nNew version: `%s`\nOld version: `%s`", serviceName, newVersionPercent, oldVersionPercent), false))
+	if channelToSendMessage != "" {
+		s.sendCanaryAlert(channelToSendMessage, lb, newWeight.Weight, oldWeight.Weight)
 	}
 }
 
-func (s *SlackListener) slackLogsContainer(ev *slack.MessageEvent) {
-
-	//args := strings.Split(ev.Msg.Text, " ")
-	//
-	//if len(args) == 3 {
-	//	container := args[2]
-	//
-	//	fileName := rancherListener.LogsContainer(container)
-	//
-	//	time.Sleep(2 * time.Second)
-	//
-	//	api := getAPIConnection()
-	//
-	//	_, err := api.client.UploadFile(slack.FileUploadParameters{
-	//		File:     fileName,
-	//		Filename: fileName,
-	//		Filetype: "text",
-	//		Channels: []string{
-	//			api.channelID,
-	//		},
-	//	})
-	//	CheckErr("Upload logs container error", err)
-	//} else {
-	//	s.createAndSendAttachment(
-	//		ev,
-	//		"Which container you need to download logs? :yum:",
-	//		logsContainer,
-	//		getContainers(),
-	//		nil,
-	//	)
-	//
-	//}
-
-}
-
-func (s *SlackListener) slackRestartContainer(ev *slack.MessageEvent) {
-
-	args := strings.Split(ev.Msg.Text, " ")
+// parseCanaryWeight lê um peso do canary, que pode ser só o número (usando a
+// referência padrão) ou `referencia=peso` (ex.: `api_new=30`, `api/new-1=30`)
+func parseCanaryWeight(arg string, defaultRef string) (CanaryWeight, error) {
+	weight := CanaryWeight{Ref: defaultRef}
 
-	if len(args) == 3 {
-		id := args[2]
-
-		rancherListener.RestartContainer(id)
+	value := arg
+	if i := strings.LastIndex(arg, "="); i >= 0 {
+		weight.Ref = arg[:i]
+		value = arg[i+1:]
+	}
 
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Container restarted"), true))
-	} else {
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Parameters is required"), true))
+	var err error
+	if weight.Weight, err = strconv.Atoi(value); err != nil {
+		return weight, fmt.Errorf("`%s` is not a number", value)
 	}
 
-	// s.createAndSendAttachment(
-	// 	ev,
-	// 	"Which container you need restart? :yum:",
-	// 	restartContainer,
-	// 	getContainers(),
-	// 	nil,
-	// )
+	return weight, nil
 }
 
-func (s *SlackListener) slackStartService(ev *slack.MessageEvent) {
-
-	args := strings.Split(ev.Msg.Text, " ")
-
-	if len(args) == 3 {
-		id := args[2]
+func (s *SlackListener) slackRestartContainer(ev *slack.MessageEvent, args CommandArgs) {
+	if !args.Has("container-id") {
+		s.sendPicker(ev, picker{
+			command: restartContainer,
+			text:    "Which container you need restart? :yum:",
+			confirm: confirmation("You sure to restart the container? :scream:"),
+		})
+		return
+	}
 
-		rancherListener.StartService(id)
+	id := args.String("container-id")
 
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Service started"), true))
-	} else {
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Parameters is required"), true))
+	if err := s.orchestrator.RestartInstance(id); err != nil {
+		s.postError(ev, fmt.Sprintf("Error on restart container `%s`", id), err)
+		return
 	}
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Container `%s` restarted by %s", id, mentionUser(ev.User)), false))
 }
 
-func (s *SlackListener) slackStopService(ev *slack.MessageEvent) {
+func (s *SlackListener) slackStartService(ev *slack.MessageEvent, args CommandArgs) {
+	id := args.String("service-id")
 
-	args := strings.Split(ev.Msg.Text, " ")
+	if _, err := s.orchestrator.ActivateWorkload(id); err != nil {
+		s.postError(ev, fmt.Sprintf("Error on start service `%s`", id), err)
+		return
+	}
 
-	if len(args) == 3 {
-		id := args[2]
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Service started"), true))
+}
 
-		rancherListener.StopService(id)
+func (s *SlackListener) slackStopService(ev *slack.MessageEvent, args CommandArgs) {
+	id := args.String("service-id")
 
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Service stopped"), true))
-	} else {
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Parameters is required"), true))
+	if _, err := s.orchestrator.DeactivateWorkload(id); err != nil {
+		s.postError(ev, fmt.Sprintf("Error on stop service `%s`", id), err)
+		return
 	}
+
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Service stopped"), true))
 }
 
 func (s *SlackListener) interactiveMessage(ev *slack.MessageEvent) {
@@ -1398,136 +1305,82 @@ func (s *SlackListener) interactiveMessage(ev *slack.MessageEvent) {
 		var kanye Kanye
 		_ = json.Unmarshal(body, &kanye)
 
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Little Friend, what did you mean? I do not understand, use @jeremias help or @jeremias commands!\n\n So here's a message to make your day better:\n\n\"%s\"", kanye.Quote), true))
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Little Friend, what did you mean? I do not understand, use @jeremias help or @jeremias commands!\n\n So here's a message to make your day better:\n\n\"%s\"", kanye.Quote), true))
 	}
 
 }
 
-func (s *SlackListener) createAndSendAttachment(ev *slack.MessageEvent, text string, callbackID string, options []slack.AttachmentActionOption, confirmation *slack.ConfirmationField) {
-	s.client.PostMessage(ev.Channel, slack.MsgOptionAttachments(slack.Attachment{
-		Text:       text,
-		Color:      "#0C648A",
-		CallbackID: callbackID,
-		Actions: []slack.AttachmentAction{
-			{
-				Name:    "select",
-				Type:    "select",
-				Options: options,
-				Confirm: confirmation,
-			},
-			{
-				Name:  "cancel",
-				Text:  "Cancelar",
-				Type:  "button",
-				Style: "danger",
-			},
-		},
-	}))
-}
+func (s *SlackListener) slackCanaryUpTen(ev *slack.MessageEvent, args CommandArgs) {
+	lb := args.String("lb-id")
+	channelToSendMessage := args.String("channel-to-send-alert")
 
-func getContainers() []slack.AttachmentActionOption {
-	// Pegando a lista de containers lá do rancher.go
-	containersList := rancherListener.ListContainers()
-
-	// Criando uma lista de estruturas
-	containers := []*Container{}
-
-	// Pegando a lista que veio da API do Rancher, convertendo pra String
-	// atribuindo à uma struct e adicionando na lista de structs
-	data := gjson.Get(containersList, "data")
-	data.ForEach(func(key, value gjson.Result) bool {
-		container := new(Container)
-		container.ID = value.Get("id").String()
-		container.ImageUUID = value.Get("imageUuid").String()
-		container.Name = value.Get("name").String()
-		containers = append(containers, container)
-
-		return true
-	})
-
-	// Criando lista de opções, fazendo um ForEach na lista
-	// de structs de containers, criando opcao dentro do ForEach
-	// e adicionando à lista de opcoes
-	opcoes := []slack.AttachmentActionOption{}
-	for _, container := range containers {
-		opcoes = append(opcoes, slack.AttachmentActionOption{
-			Text:  fmt.Sprintf("%s | %s", container.ID, container.Name),
-			Value: container.ID,
-		})
+	new, old, err := s.rancherListener.SearchForLbPercent(lb)
+	if err != nil {
+		s.postError(ev, fmt.Sprintf("Error on get weights of Load Balancer `%s`", lb), err)
+		return
 	}
 
-	return opcoes
-}
-
-func getServices() []slack.AttachmentActionOption {
-	servicesList := rancherListener.ListServices()
+	if new >= 100 || old <= 0 {
+		s.reply(ev, slack.MsgOptionText(fmt.Sprintf("Canary of Load Balancer `%s` is already at 100%% (new: `%d`, old: `%d`)", lb, new, old), false))
+		return
+	}
 
-	opcoes := []slack.AttachmentActionOption{}
+	newMoreTen := new + 10
+	oldLessTen := old - 10
 
-	data := gjson.Get(servicesList, "data")
-	data.ForEach(func(key, value gjson.Result) bool {
-		serviceID := value.Get("id").String()
-		serviceName := value.Get("name").String()
-		opcoes = append(opcoes, slack.AttachmentActionOption{
-			Text:  fmt.Sprintf("%s | %s", serviceID, serviceName),
-			Value: serviceID,
-		})
+	if newMoreTen > 100 || oldLessTen < 0 {
+		newMoreTen, oldLessTen = 100, 0
+	}
 
-		return true
-	})
+	resp, err := s.rancherListener.UpdateCustomHaproxyCfg(lb, []CanaryWeight{
+		{Ref: canaryNewRef, Weight: newMoreTen},
+		{Ref: canaryOldRef, Weight: oldLessTen},
+	}, ev.User)
+	if err != nil {
+		s.postError(ev, "Error on update haproxy.cfg, check if ID param is right, the body of haproxy.cfg is empty or if weights not sum 100", err)
+		return
+	}
 
-	return opcoes
-}
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("File 'haproxy.cfg' updated successfuly!\n```%s```", resp), false))
 
-func getLbOptions() []slack.AttachmentActionOption {
-	opcoes := []slack.AttachmentActionOption{}
-	for _, lb := range rancherListener.GetLoadBalancers() {
-		opcoes = append(opcoes, slack.AttachmentActionOption{
-			Text:  fmt.Sprintf("%s | %s", lb.ID, lb.Name),
-			Value: lb.ID,
-		})
+	if channelToSendMessage != "" {
+		s.sendCanaryAlert(channelToSendMessage, lb, newMoreTen, oldLessTen)
 	}
-
-	return opcoes
 }
-func (s *SlackListener) slackCanaryUpTen(ev *slack.MessageEvent) {
-	var channelToSendMessage string
 
-	args := strings.Split(ev.Msg.Text, " ")
-	if len(args) < 3 {
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("Command call error, correct syntax: @name-of-bot %s canaryUpTen LB-id channel-to-send-alert (optional)", canaryUpTen), false))
+// sendCanaryAlert envia o alerta não técnico de atualização do canary
+func (s *SlackListener) sendCanaryAlert(channel string, lb string, newVersionPercent int, oldVersionPercent int) {
+	svc, err := s.rancherListener.GetService(lb)
+	if err != nil {
+		CheckErr(fmt.Sprintf("Error on get service of Load Balancer %s", lb), err)
 		return
 	}
-	if len(args) == 4 {
-		channelToSendMessage = args[3]
-	}
-	lb := args[2]
-
-	new, old := rancherListener.SearchForLbPercent(lb)
 
-	newToInt, _ := strconv.Atoi(new)
-	oldToInt, _ := strconv.Atoi(old)
-
-	newMoreTen := newToInt + 10
-	oldLessTen := oldToInt - 10
-
-	newToString := strconv.Itoa(newMoreTen)
-	oldToString := strconv.Itoa(oldLessTen)
+	s.client.PostMessage(channel, slack.MsgOptionText(fmt.Sprintf("Canary of `%s` has been updated.\nNew version: `%d`\nOld version: `%d`", svc.Name, newVersionPercent, oldVersionPercent), false))
+}
 
-	resp := rancherListener.UpdateCustomHaproxyCfg(lb, newToString, oldToString)
+// postError envia para o canal a mensagem de erro junto do erro retornado
+func (s *SlackListener) postError(ev *slack.MessageEvent, message string, err error) {
+	log.Printf("[ERROR] %s\n%s", message, err)
+	if s.audit != nil {
+		s.audit.fail(fmt.Errorf("%s: %s", message, err))
+	}
+	s.reply(ev, slack.MsgOptionText(fmt.Sprintf("%s\nError: %s", message, err.Error()), false))
+}
 
-	if resp == "error" {
-		s.client.PostMessage(ev.Channel, slack.MsgOptionText("Error on update haproxy.cfg, check if ID param is right, the body of haproxy.cfg is empty or if weights not sum 100", false))
+// reply responde o comando no canal da mensagem ou, nos slash commands, pelo
+// response_url. Nos comandos da API a resposta é guardada no output
+func (s *SlackListener) reply(ev *slack.MessageEvent, options ...slack.MsgOption) {
+	if s.output != nil {
+		s.output.add(options...)
 		return
 	}
 
-	s.client.PostMessage(ev.Channel, slack.MsgOptionText(fmt.Sprintf("File 'haproxy.cfg' updated successfuly!\n```%s```", resp), false))
-
-	if channelToSendMessage != "" {
-		resp := rancherListener.GetService(lb)
-
-		serviceName := gjson.Get(resp, "name").String()
-
-		s.client.PostMessage(channelToSendMessage, slack.MsgOptionText(fmt.Sprintf("Canary of `%s` has been updated.\nNew version: `%s`\nOld version: `%s`", serviceName, newToString, oldToString), false))
+	if s.responseURL != "" {
+		options = append(options, slack.MsgOptionResponseURL(s.responseURL, s.responseType))
+	} else if ev.ThreadTimestamp != "" {
+		options = append(options, slack.MsgOptionTS(ev.ThreadTimestamp))
 	}
+
+	s.client.PostMessage(ev.Channel, options...)
 }
@@ -0,0 +1,69 @@
+// Slack BOT for Rancher API
+// Created by: https://github.com/magnonta and https://github.com/cayohollanda
+
+package core
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nlopes/slack"
+)
+
+const (
+	// slashPublicFlag faz a resposta do slash command aparecer para todos no canal
+	slashPublicFlag = "--public"
+
+	slashResponseEphemeral = "ephemeral"
+	slashResponseInChannel = "in_channel"
+)
+
+// handleSlashCommand recebe o `/jeremias command args`, de qualquer canal ou
+// DM, e executa o comando como se fosse uma menção ao BOT. As respostas vão
+// pelo response_url, só para quem chamou ou, com --public, para o canal
+func (s *SlackListener) handleSlashCommand(c *gin.Context) {
+	cmd, err := slack.SlashCommandParse(c.Request)
+	if err != nil {
+		log.Printf("[ERROR] Failed to parse slash command: %s", err)
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	listener := *s
+	listener.responseURL = cmd.ResponseURL
+	listener.responseType = slashResponseEphemeral
+
+	var args []string
+	for _, arg := range strings.Fields(cmd.Text) {
+		if arg == slashPublicFlag {
+			listener.responseType = slashResponseInChannel
+			continue
+		}
+		args = append(args, arg)
+	}
+
+	if len(args) == 0 {
+		args = []string{commands}
+	}
+
+	ev := &slack.MessageEvent{}
+	ev.Type = "message"
+	ev.Channel = cmd.ChannelID
+	ev.User = cmd.UserID
+	ev.Text = fmt.Sprintf("<@%s> %s", s.botID, strings.Join(args, " "))
+
+	log.Printf("[INFO] Slash command %s from %s on channel %s: %s", cmd.Command, cmd.UserID, cmd.ChannelID, cmd.Text)
+
+	// o Slack espera a resposta em até 3 segundos, o comando responde depois pelo response_url
+	c.Status(http.StatusOK)
+
+	go func() {
+		slackEventsMutex.Lock()
+		defer slackEventsMutex.Unlock()
+
+		listener.handleCommand(ev)
+	}()
+}
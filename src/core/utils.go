@@ -9,7 +9,9 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
-	"os"
+	"strings"
+
+	"github.com/slack-bot-4all/slack-bot/src/model"
 )
 
 type Kanye struct {
@@ -48,18 +50,6 @@ func ConvertResponseToString(response io.ReadCloser) (converted string) {
 	*/
 }
 
-// WriteOnFile é a função que escreve o que for passado por parâmetro
-// dentro do arquivo passado no path
-func WriteOnFile(path string, text string) {
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, os.ModeAppend)
-	CheckErr("Erro ao abrir arquivo", err)
-
-	_, err = f.WriteString(text)
-	CheckErr("Erro ao escrever linha no arquivo", err)
-
-	defer f.Close()
-}
-
 // RemoveLastCharacter é a função que remove o último caracter de uma string
 func RemoveLastCharacter(s string) string {
 	sz := len(s)
@@ -70,3 +60,84 @@ func RemoveLastCharacter(s string) string {
 
 	return s
 }
+
+// parseFlags separa os argumentos posicionais das flags `--nome valor` de um comando
+func parseFlags(args []string) (positional []string, flags map[string]string) {
+	flags = map[string]string{}
+
+	for i := 0; i < len(args); i++ {
+		// depois do -- tudo é posicional, como o comando do container-exec
+		if args[i] == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+
+		if !strings.HasPrefix(args[i], "--") {
+			if args[i] != "" {
+				positional = append(positional, args[i])
+			}
+			continue
+		}
+
+		name := strings.TrimPrefix(args[i], "--")
+		if j := strings.Index(name, "="); j >= 0 {
+			flags[name[:j]] = name[j+1:]
+			continue
+		}
+
+		if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			flags[name] = args[i+1]
+			i++
+		} else {
+			flags[name] = ""
+		}
+	}
+
+	return positional, flags
+}
+
+// splitArgs separa o texto de um comando por espaços, mantendo juntos os
+// trechos entre aspas (ex.: `--cron "0 9 * * MON-FRI"`). O Slack troca as
+// aspas retas por aspas curvas, então as duas são aceitas
+func splitArgs(text string) []string {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	quoted := false
+
+	closing := map[rune]rune{'"': '"', '\'': '\'', '“': '”', '‘': '’'}
+
+	for _, r := range text {
+		switch {
+		case quote != 0 && r == closing[quote]:
+			quote = 0
+		case quote == 0 && closing[r] != 0:
+			quote = r
+			quoted = true
+		case quote == 0 && r == ' ':
+			if current.Len() > 0 || quoted {
+				args = append(args, current.String())
+			}
+			current.Reset()
+			quoted = false
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if current.Len() > 0 || quoted {
+		args = append(args, current.String())
+	}
+
+	return args
+}
+
+// mentionUser menciona o usuário no Slack, ou mostra o usuário da API
+// (api:username) de um comando executado pela API
+func mentionUser(user string) string {
+	if strings.HasPrefix(user, model.APIUserPrefix) {
+		return fmt.Sprintf("`%s`", user)
+	}
+
+	return fmt.Sprintf("<@%s>", user)
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{`service-scale web/api  3`, []string{"service-scale", "web/api", "3"}},
+		{`task-add --cron "0 9 * * MON-FRI"`, []string{"task-add", "--cron", "0 9 * * MON-FRI"}},
+		{`silence 12 'deploy of v2'`, []string{"silence", "12", "deploy of v2"}},
+		{`silence 12 “deploy of v2”`, []string{"silence", "12", "deploy of v2"}},
+		{`silence 12 ‘deploy’`, []string{"silence", "12", "deploy"}},
+		{`--reason=""`, []string{"--reason="}},
+		{`a "" b`, []string{"a", "", "b"}},
+		{`--grep "a"b`, []string{"--grep", "ab"}},
+	}
+
+	for _, test := range tests {
+		if got := splitArgs(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		args       []string
+		positional []string
+		flags      map[string]string
+	}{
+		{[]string{"web/api", "--for", "2h", "3"}, []string{"web/api", "3"}, map[string]string{"for": "2h"}},
+		{[]string{"--for=2h", "web/api"}, []string{"web/api"}, map[string]string{"for": "2h"}},
+		{[]string{"--follow", "--grep", "error"}, nil, map[string]string{"follow": "", "grep": "error"}},
+		{[]string{"web/api", "-1"}, []string{"web/api", "-1"}, map[string]string{}},
+		{[]string{"1i2", "--", "ls", "--all", "-l"}, []string{"1i2", "ls", "--all", "-l"}, map[string]string{}},
+		{[]string{"1i2", "--"}, []string{"1i2"}, map[string]string{}},
+		{[]string{"a", "", "b"}, []string{"a", "b"}, map[string]string{}},
+	}
+
+	for _, test := range tests {
+		positional, flags := parseFlags(test.args)
+		if !reflect.DeepEqual(positional, test.positional) || !reflect.DeepEqual(flags, test.flags) {
+			t.Errorf("parseFlags(%q) = %q, %v, want %q, %v", test.args, positional, flags, test.positional, test.flags)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// 2024-01-02 is a Tuesday
+	from := time.Date(2024, 1, 2, 0, 7, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		// with both day fields restricted, any of them matches
+		{"0 9 1 * MON", time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)},
+		{"0 0 13 * FRI", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 3 * SUN", time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		// with one of them as *, only the other one restricts the day
+		{"0 9 15 * *", time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * WED", time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC)},
+		{"0 9 ? * WED", time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC)},
+		// 7 and 0 are Sunday
+		{"0 9 * * 7", time.Date(2024, 1, 7, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 0", time.Date(2024, 1, 7, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 6-7", time.Date(2024, 1, 6, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * SUN", time.Date(2024, 1, 7, 9, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 2, 0, 15, 0, 0, time.UTC)},
+		{"0 9 * FEB MON-FRI", time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, test := range tests {
+		schedule, err := Parse(test.spec)
+		if err != nil {
+			t.Errorf("Parse(%q) returned %s", test.spec, err)
+			continue
+		}
+
+		if got := schedule.Next(from); !got.Equal(test.want) {
+			t.Errorf("Parse(%q).Next(%s) = %s, want %s", test.spec, from, got, test.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * * MON-XYZ",
+	}
+
+	for _, spec := range tests {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) = nil error, want an error", spec)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+// Package haproxy parses and serializes haproxy.cfg files, like the custom
+// config of Rancher load balancers (lbConfig.config). Lines that are not
+// edited are written back exactly as they were read, comments included
+package haproxy
+
+import (
+	"bufio"
+	"strings"
+)
+
+// DisabledPrefix marks the lines disabled by Config.Disable, so Config.Enable
+// restores only them and keeps the comments that were already on the file
+const DisabledPrefix = "#disabled# "
+
+var sectionKeywords = map[string]bool{
+	"global":    true,
+	"defaults":  true,
+	"frontend":  true,
+	"backend":   true,
+	"listen":    true,
+	"userlist":  true,
+	"peers":     true,
+	"resolvers": true,
+	"mailers":   true,
+	"cache":     true,
+	"program":   true,
+}
+
+// Config : a parsed haproxy.cfg
+type Config struct {
+	// Sections are in file order. Lines before the first section header are
+	// kept on a section with empty Kind
+	Sections []*Section
+
+	trailingNewline bool
+}
+
+// Section : a section (global, defaults, frontend, backend, listen...) and its lines
+type Section struct {
+	Kind string
+	Name string
+
+	// Header is the line that opens the section (nil for the lines before the first section)
+	Header *Line
+	Lines  []*Line
+}
+
+// Line : a line of the file. Comments and blank lines only have Raw
+type Line struct {
+	Raw string
+
+	Indent   string
+	Keyword  string
+	Args     []string
+	Comment  string
+	Disabled bool
+
+	dirty bool
+}
+
+// Parse : parses the content of a haproxy.cfg
+func Parse(content string) (*Config, error) {
+	cfg := &Config{
+		trailingNewline: strings.HasSuffix(content, "\n"),
+	}
+
+	current := &Section{}
+	cfg.Sections = append(cfg.Sections, current)
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := parseLine(scanner.Text())
+
+		if sectionKeywords[line.Keyword] {
+			current = &Section{Kind: line.Keyword, Header: line}
+			if len(line.Args) > 0 {
+				current.Name = line.Args[0]
+			}
+			cfg.Sections = append(cfg.Sections, current)
+			continue
+		}
+
+		current.Lines = append(current.Lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func parseLine(raw string) *Line {
+	line := &Line{Raw: raw}
+
+	trimmed := strings.TrimLeft(raw, " \t")
+	line.Indent = raw[:len(raw)-len(trimmed)]
+
+	if strings.HasPrefix(trimmed, DisabledPrefix) {
+		line.Disabled = true
+		trimmed = strings.TrimPrefix(trimmed, DisabledPrefix)
+	}
+
+	// comments and blank lines
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		line.Disabled = false
+		return line
+	}
+
+	if i := strings.Index(trimmed, "#"); i >= 0 {
+		line.Comment = strings.TrimSpace(trimmed[i:])
+		trimmed = trimmed[:i]
+	}
+
+	fields := strings.Fields(trimmed)
+	line.Keyword = fields[0]
+	line.Args = fields[1:]
+
+	return line
+}
+
+// IsDirective : checks if the line is a directive (not a comment or a blank line)
+func (l *Line) IsDirective() bool {
+	return l.Keyword != ""
+}
+
+// String : returns the text of the line. Lines that were not changed keep the
+// original text
+func (l *Line) String() string {
+	if !l.dirty {
+		return l.Raw
+	}
+
+	text := strings.Join(append([]string{l.Keyword}, l.Args...), " ")
+	if l.Comment != "" {
+		text += " " + l.Comment
+	}
+	if l.Disabled {
+		text = DisabledPrefix + text
+	}
+
+	return l.Indent + text
+}
+
+// String : serializes the config back to the haproxy.cfg format
+func (c *Config) String() string {
+	var lines []string
+
+	for _, section := range c.Sections {
+		if section.Header != nil {
+			lines = append(lines, section.Header.String())
+		}
+		for _, line := range section.Lines {
+			lines = append(lines, line.String())
+		}
+	}
+
+	content := strings.Join(lines, "\n")
+	if c.trailingNewline && len(lines) > 0 {
+		content += "\n"
+	}
+
+	return content
+}
+
+// Section : returns the first section with the kind and name (ex.: "backend", "api")
+func (c *Config) Section(kind string, name string) *Section {
+	for _, section := range c.Sections {
+		if section.Kind == kind && section.Name == name {
+			return section
+		}
+	}
+
+	return nil
+}
+
+// Backends : returns the backend and listen sections
+func (c *Config) Backends() []*Section {
+	var backends []*Section
+	for _, section := range c.Sections {
+		if section.Kind == "backend" || section.Kind == "listen" {
+			backends = append(backends, section)
+		}
+	}
+
+	return backends
+}
+
+// IsDisabled : checks if the config has no active directive, because it was
+// disabled by Disable (or commented by hand)
+func (c *Config) IsDisabled() bool {
+	for _, line := range c.allLines() {
+		if line.IsDirective() && !line.Disabled {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Disable : comments all active directives with DisabledPrefix. Comments that
+// were already on the file are not changed
+func (c *Config) Disable() {
+	for _, line := range c.allLines() {
+		if line.IsDirective() && !line.Disabled {
+			line.Disabled = true
+			line.dirty = true
+		}
+	}
+}
+
+// Enable : restores the directives disabled by Disable, the ones marked with
+// DisabledPrefix. Other comments, including lines commented by hand or by old
+// versions of the bot, are kept as they are. Returns how many were restored
+func (c *Config) Enable() int {
+	restored := 0
+
+	for _, line := range c.allLines() {
+		if line.Disabled {
+			line.Disabled = false
+			line.dirty = true
+			restored++
+		}
+	}
+
+	return restored
+}
+
+func (c *Config) allLines() []*Line {
+	var lines []*Line
+	for _, section := range c.Sections {
+		if section.Header != nil {
+			lines = append(lines, section.Header)
+		}
+		lines = append(lines, section.Lines...)
+	}
+
+	return lines
+}
@@ -0,0 +1,99 @@
+package haproxy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDisableEnableRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		restored int
+	}{
+		{
+			name: "backend",
+			content: `backend web
+  balance roundrobin
+  server new web-new:80 weight 30
+  server old web-old:80 weight 70
+`,
+			restored: 4,
+		},
+		{
+			name: "comments kept",
+			content: `# canary of web
+frontend http
+  bind *:80 # public
+  # use_backend legacy if is_legacy
+  default_backend web
+
+backend web
+  server new web-new:80 weight 0`,
+			restored: 5,
+		},
+		{
+			name:     "empty",
+			content:  "",
+			restored: 0,
+		},
+	}
+
+	for _, test := range tests {
+		cfg, err := Parse(test.content)
+		if err != nil {
+			t.Fatalf("%s: Parse returned %s", test.name, err)
+		}
+
+		cfg.Disable()
+		disabled := cfg.String()
+
+		if !cfg.IsDisabled() {
+			t.Errorf("%s: IsDisabled after Disable = false", test.name)
+		}
+
+		for _, line := range strings.Split(disabled, "\n") {
+			if strings.TrimSpace(line) != "" && !strings.HasPrefix(strings.TrimSpace(line), "#") {
+				t.Errorf("%s: line %q is still active after Disable", test.name, line)
+			}
+		}
+
+		// the disabled config is saved on the LB and read again by canary-enable
+		cfg, err = Parse(disabled)
+		if err != nil {
+			t.Fatalf("%s: Parse of the disabled config returned %s", test.name, err)
+		}
+
+		if restored := cfg.Enable(); restored != test.restored {
+			t.Errorf("%s: Enable restored %d lines, want %d", test.name, restored, test.restored)
+		}
+
+		if got := cfg.String(); got != test.content {
+			t.Errorf("%s: config after Enable =\n%s\nwant\n%s", test.name, got, test.content)
+		}
+	}
+}
+
+func TestEnableKeepsForeignComments(t *testing.T) {
+	// commented by hand or by old versions of the bot, without DisabledPrefix
+	content := `#backend web
+#  server new web-new:80 weight 30
+`
+
+	cfg, err := Parse(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored := cfg.Enable(); restored != 0 {
+		t.Errorf("Enable restored %d lines, want 0", restored)
+	}
+
+	if !cfg.IsDisabled() {
+		t.Errorf("IsDisabled = false, want true")
+	}
+
+	if got := cfg.String(); got != content {
+		t.Errorf("config after Enable =\n%s\nwant\n%s", got, content)
+	}
+}
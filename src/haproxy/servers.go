@@ -0,0 +1,168 @@
+package haproxy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxWeight is the biggest weight accepted by haproxy
+const MaxWeight = 256
+
+// Server : a `server` line of a backend
+type Server struct {
+	Backend *Section
+	Line    *Line
+}
+
+// Name : name of the server
+func (s *Server) Name() string {
+	if len(s.Line.Args) == 0 {
+		return ""
+	}
+
+	return s.Line.Args[0]
+}
+
+// Address : address of the server (host:port)
+func (s *Server) Address() string {
+	if len(s.Line.Args) < 2 {
+		return ""
+	}
+
+	return s.Line.Args[1]
+}
+
+// Ref : reference of the server in the format backend/server
+func (s *Server) Ref() string {
+	return s.Backend.Name + "/" + s.Name()
+}
+
+// Weight : returns the weight of the server. Servers without the option have
+// the haproxy default weight, 1
+func (s *Server) Weight() int {
+	args := s.Line.Args
+	for i := 2; i < len(args)-1; i++ {
+		if args[i] == "weight" {
+			if weight, err := strconv.Atoi(args[i+1]); err == nil {
+				return weight
+			}
+		}
+	}
+
+	return 1
+}
+
+// SetWeight : changes (or adds) the weight option of the server
+func (s *Server) SetWeight(weight int) error {
+	if weight < 0 || weight > MaxWeight {
+		return fmt.Errorf("weight %d of server %s is out of range 0-%d", weight, s.Ref(), MaxWeight)
+	}
+
+	value := strconv.Itoa(weight)
+	args := s.Line.Args
+
+	for i := 2; i < len(args)-1; i++ {
+		if args[i] == "weight" {
+			if args[i+1] != value {
+				args[i+1] = value
+				s.Line.dirty = true
+			}
+			return nil
+		}
+	}
+
+	s.Line.Args = append(args, "weight", value)
+	s.Line.dirty = true
+
+	return nil
+}
+
+// Servers : returns all servers of the config, disabled ones included
+func (c *Config) Servers() []*Server {
+	var servers []*Server
+
+	for _, backend := range c.Backends() {
+		for _, line := range backend.Lines {
+			if line.Keyword == "server" && len(line.Args) >= 2 {
+				servers = append(servers, &Server{Backend: backend, Line: line})
+			}
+		}
+	}
+
+	return servers
+}
+
+// FindServers : returns the servers of a reference, that may be:
+//
+//   - `backend/server`, the server of a backend
+//   - `backend`, all servers of a backend
+//   - any other word, the servers whose backend or server name contains it
+//     (ex.: `new` and `old` for canary deployments)
+func (c *Config) FindServers(ref string) ([]*Server, error) {
+	servers := c.Servers()
+	var found []*Server
+
+	if i := strings.Index(ref, "/"); i >= 0 {
+		backendName, serverName := ref[:i], ref[i+1:]
+		for _, server := range servers {
+			if server.Backend.Name == backendName && server.Name() == serverName {
+				found = append(found, server)
+			}
+		}
+	} else {
+		for _, server := range servers {
+			if server.Backend.Name == ref {
+				found = append(found, server)
+			}
+		}
+
+		if len(found) == 0 {
+			for _, server := range servers {
+				if strings.Contains(server.Backend.Name, ref) || strings.Contains(server.Name(), ref) {
+					found = append(found, server)
+				}
+			}
+		}
+	}
+
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no server found for `%s`", ref)
+	}
+
+	return found, nil
+}
+
+// Weight : returns the weight of the servers of a reference (see FindServers).
+// All servers of the reference must have the same weight
+func (c *Config) Weight(ref string) (int, error) {
+	servers, err := c.FindServers(ref)
+	if err != nil {
+		return 0, err
+	}
+
+	weight := servers[0].Weight()
+	for _, server := range servers[1:] {
+		if server.Weight() != weight {
+			return 0, fmt.Errorf("servers of `%s` have different weights (%s: %d, %s: %d)", ref, servers[0].Ref(), weight, server.Ref(), server.Weight())
+		}
+	}
+
+	return weight, nil
+}
+
+// SetWeight : changes the weight of all servers of a reference (see FindServers)
+func (c *Config) SetWeight(ref string, weight int) error {
+	servers, err := c.FindServers(ref)
+	if err != nil {
+		return err
+	}
+
+	for _, server := range servers {
+		if err := server.SetWeight(weight); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
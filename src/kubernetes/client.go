@@ -0,0 +1,124 @@
+// Package kubernetes is a small typed client for the Kubernetes API, enough to
+// drive deployments and pods of Rancher 2.x clusters (or any other cluster)
+package kubernetes
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const (
+	// MergePatch is the content type of JSON merge patches
+	MergePatch = "application/merge-patch+json"
+
+	// StrategicMergePatch is the content type of strategic merge patches
+	StrategicMergePatch = "application/strategic-merge-patch+json"
+)
+
+// Client : keeps the API server URL, the bearer token and the namespace used on requests
+type Client struct {
+	BaseURL   string
+	Token     string
+	Namespace string
+
+	HTTPClient *http.Client
+
+	// OnResponse : if not nil, called after every request with its status (0 when it failed)
+	OnResponse func(method string, url string, status int)
+}
+
+// NewClient : creates a client to the Kubernetes API. With Rancher 2.x the
+// baseURL is the cluster proxy (https://rancher/k8s/clusters/<cluster-id>)
+func NewClient(baseURL string, token string, namespace string) *Client {
+	return &Client{
+		BaseURL:   strings.TrimSuffix(baseURL, "/"),
+		Token:     token,
+		Namespace: namespace,
+		HTTPClient: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+}
+
+// URL : returns the full URL of a path on the API server
+func (c *Client) URL(format string, a ...interface{}) string {
+	return c.BaseURL + fmt.Sprintf(format, a...)
+}
+
+// Do : sends a request to the API, encoding `in` as body (if not nil) and
+// decoding the response into `out` (if not nil). Any status >= 300 is returned
+// as *StatusError
+func (c *Client) Do(method string, url string, contentType string, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		c.report(method, url, 0)
+		return err
+	}
+	defer resp.Body.Close()
+
+	c.report(method, url, resp.StatusCode)
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		return parseStatusError(resp.StatusCode, respBody)
+	}
+
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+
+	if raw, ok := out.(*[]byte); ok {
+		*raw = respBody
+		return nil
+	}
+
+	return json.Unmarshal(respBody, out)
+}
+
+func (c *Client) report(method string, url string, status int) {
+	if c.OnResponse != nil {
+		c.OnResponse(method, url, status)
+	}
+}
@@ -0,0 +1,86 @@
+package kubernetes
+
+import (
+	"net/http"
+	"time"
+)
+
+type deploymentList struct {
+	Items []Deployment `json:"items"`
+}
+
+// ListDeployments : lists the deployments of the namespace
+func (c *Client) ListDeployments() ([]Deployment, error) {
+	var list deploymentList
+	err := c.Do(http.MethodGet, c.URL("/apis/apps/v1/namespaces/%s/deployments", c.Namespace), "", nil, &list)
+
+	return list.Items, err
+}
+
+// GetDeployment : gets one deployment of the namespace by name
+func (c *Client) GetDeployment(name string) (Deployment, error) {
+	var deployment Deployment
+	err := c.Do(http.MethodGet, c.URL("/apis/apps/v1/namespaces/%s/deployments/%s", c.Namespace, name), "", nil, &deployment)
+
+	return deployment, err
+}
+
+// PatchDeployment : applies a patch (merge or strategic merge) to a deployment
+func (c *Client) PatchDeployment(name string, patchType string, patch interface{}) (Deployment, error) {
+	var deployment Deployment
+	err := c.Do(http.MethodPatch, c.URL("/apis/apps/v1/namespaces/%s/deployments/%s", c.Namespace, name), patchType, patch, &deployment)
+
+	return deployment, err
+}
+
+// ScaleDeployment : changes the number of replicas of a deployment
+func (c *Client) ScaleDeployment(name string, replicas int, annotations map[string]string) (Deployment, error) {
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+		},
+	}
+	if len(annotations) > 0 {
+		patch["metadata"] = map[string]interface{}{
+			"annotations": annotations,
+		}
+	}
+
+	return c.PatchDeployment(name, MergePatch, patch)
+}
+
+// SetDeploymentImage : changes the image of a container of the deployment,
+// which starts a rolling update
+func (c *Client) SetDeploymentImage(name string, container string, image string) (Deployment, error) {
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []Container{
+						{Name: container, Image: image},
+					},
+				},
+			},
+		},
+	}
+
+	return c.PatchDeployment(name, StrategicMergePatch, patch)
+}
+
+// RestartDeployment : recreates all pods of a deployment, the same as
+// `kubectl rollout restart`
+func (c *Client) RestartDeployment(name string) (Deployment, error) {
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{
+						"kubectl.kubernetes.io/restartedAt": time.Now().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	}
+
+	return c.PatchDeployment(name, StrategicMergePatch, patch)
+}
@@ -0,0 +1,55 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// StatusError : `Status` object returned by the API server on failures
+type StatusError struct {
+	Kind    string `json:"kind"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+	Code    int    `json:"code"`
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("kubernetes: %d", e.Code)
+	if e.Reason != "" {
+		msg += " " + e.Reason
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+
+	return msg
+}
+
+// IsNotFound : checks if the error is a 404 from the API server
+func IsNotFound(err error) bool {
+	statusErr, ok := err.(*StatusError)
+	return ok && statusErr.Code == http.StatusNotFound
+}
+
+func parseStatusError(code int, body []byte) *StatusError {
+	var statusErr StatusError
+	if err := json.Unmarshal(body, &statusErr); err != nil || statusErr.Kind != "Status" {
+		statusErr = StatusError{
+			Kind:    "Status",
+			Status:  "Failure",
+			Message: strings.TrimSpace(string(body)),
+		}
+	}
+
+	if statusErr.Code == 0 {
+		statusErr.Code = code
+	}
+	if statusErr.Message == "" {
+		statusErr.Message = http.StatusText(code)
+	}
+
+	return &statusErr
+}
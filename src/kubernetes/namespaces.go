@@ -0,0 +1,15 @@
+package kubernetes
+
+import "net/http"
+
+type namespaceList struct {
+	Items []Namespace `json:"items"`
+}
+
+// ListNamespaces : lists all namespaces of the cluster
+func (c *Client) ListNamespaces() ([]Namespace, error) {
+	var list namespaceList
+	err := c.Do(http.MethodGet, c.URL("/api/v1/namespaces"), "", nil, &list)
+
+	return list.Items, err
+}
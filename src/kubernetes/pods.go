@@ -0,0 +1,121 @@
+package kubernetes
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type podList struct {
+	Items []Pod `json:"items"`
+}
+
+// LogsOptions : query of the pod logs request
+type LogsOptions struct {
+	Container    string
+	TailLines    int
+	SinceSeconds int
+	Follow       bool
+}
+
+// ListPods : lists the pods of the namespace, filtered by the labels (if any)
+func (c *Client) ListPods(labels map[string]string) ([]Pod, error) {
+	var list podList
+
+	path := c.URL("/api/v1/namespaces/%s/pods", c.Namespace)
+	if len(labels) > 0 {
+		var selector []string
+		for key, value := range labels {
+			selector = append(selector, key+"="+value)
+		}
+		path += "?labelSelector=" + url.QueryEscape(strings.Join(selector, ","))
+	}
+
+	err := c.Do(http.MethodGet, path, "", nil, &list)
+
+	return list.Items, err
+}
+
+// GetPod : gets one pod of the namespace by name
+func (c *Client) GetPod(name string) (Pod, error) {
+	var pod Pod
+	err := c.Do(http.MethodGet, c.URL("/api/v1/namespaces/%s/pods/%s", c.Namespace, name), "", nil, &pod)
+
+	return pod, err
+}
+
+// DeletePod : deletes a pod, that will be recreated by its controller
+func (c *Client) DeletePod(name string) error {
+	return c.Do(http.MethodDelete, c.URL("/api/v1/namespaces/%s/pods/%s", c.Namespace, name), "", nil, nil)
+}
+
+// PodLogs : returns the logs of a pod
+func (c *Client) PodLogs(name string, opts LogsOptions) ([]byte, error) {
+	var logs []byte
+	err := c.Do(http.MethodGet, c.podLogsURL(name, opts), "", nil, &logs)
+
+	return logs, err
+}
+
+// StreamPodLogs : opens the logs of a pod as a stream, receiving the new lines
+// while opts.Follow is set. The stream must always be closed by the caller
+func (c *Client) StreamPodLogs(name string, opts LogsOptions) (io.ReadCloser, error) {
+	path := c.podLogsURL(name, opts)
+
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		c.report(http.MethodGet, path, 0)
+		return nil, err
+	}
+
+	c.report(http.MethodGet, path, resp.StatusCode)
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, parseStatusError(resp.StatusCode, body)
+	}
+
+	return resp.Body, nil
+}
+
+func (c *Client) podLogsURL(name string, opts LogsOptions) string {
+	query := url.Values{}
+	if opts.Container != "" {
+		query.Set("container", opts.Container)
+	}
+	if opts.TailLines > 0 {
+		query.Set("tailLines", strconv.Itoa(opts.TailLines))
+	}
+	if opts.SinceSeconds > 0 {
+		query.Set("sinceSeconds", strconv.Itoa(opts.SinceSeconds))
+	}
+	if opts.Follow {
+		query.Set("follow", "true")
+	}
+
+	path := c.URL("/api/v1/namespaces/%s/pods/%s/log", c.Namespace, name)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	return path
+}
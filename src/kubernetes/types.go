@@ -0,0 +1,113 @@
+package kubernetes
+
+// ObjectMeta : metadata of every object
+type ObjectMeta struct {
+	Name              string            `json:"name,omitempty"`
+	Namespace         string            `json:"namespace,omitempty"`
+	UID               string            `json:"uid,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+	CreationTimestamp string            `json:"creationTimestamp,omitempty"`
+	Generation        int64             `json:"generation,omitempty"`
+}
+
+// Namespace : a Kubernetes namespace
+type Namespace struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Status   struct {
+		Phase string `json:"phase"`
+	} `json:"status"`
+}
+
+// Container : a container of a pod spec
+type Container struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+// PodSpec : spec of a pod (only the fields used by the bot)
+type PodSpec struct {
+	NodeName   string      `json:"nodeName,omitempty"`
+	Containers []Container `json:"containers"`
+}
+
+// PodTemplateSpec : template used by a deployment to create pods
+type PodTemplateSpec struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     PodSpec    `json:"spec"`
+}
+
+// LabelSelector : selector of a deployment
+type LabelSelector struct {
+	MatchLabels map[string]string `json:"matchLabels"`
+}
+
+// DeploymentSpec : desired state of a deployment
+type DeploymentSpec struct {
+	Replicas int             `json:"replicas"`
+	Selector LabelSelector   `json:"selector"`
+	Template PodTemplateSpec `json:"template"`
+}
+
+// Condition : a condition of a deployment or pod
+type Condition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// DeploymentStatus : observed state of a deployment
+type DeploymentStatus struct {
+	ObservedGeneration  int64       `json:"observedGeneration"`
+	Replicas            int         `json:"replicas"`
+	UpdatedReplicas     int         `json:"updatedReplicas"`
+	ReadyReplicas       int         `json:"readyReplicas"`
+	AvailableReplicas   int         `json:"availableReplicas"`
+	UnavailableReplicas int         `json:"unavailableReplicas"`
+	Conditions          []Condition `json:"conditions"`
+}
+
+// Deployment : an apps/v1 deployment
+type Deployment struct {
+	Metadata ObjectMeta       `json:"metadata"`
+	Spec     DeploymentSpec   `json:"spec"`
+	Status   DeploymentStatus `json:"status"`
+}
+
+// ContainerStatus : status of a container of a pod
+type ContainerStatus struct {
+	Name         string `json:"name"`
+	Ready        bool   `json:"ready"`
+	RestartCount int    `json:"restartCount"`
+	Image        string `json:"image"`
+}
+
+// PodStatus : observed state of a pod
+type PodStatus struct {
+	Phase             string            `json:"phase"`
+	Conditions        []Condition       `json:"conditions"`
+	ContainerStatuses []ContainerStatus `json:"containerStatuses"`
+}
+
+// Pod : a Kubernetes pod
+type Pod struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     PodSpec    `json:"spec"`
+	Status   PodStatus  `json:"status"`
+}
+
+// Ready : checks if the pod is running and all of its containers are ready
+func (p Pod) Ready() bool {
+	if p.Status.Phase != "Running" || len(p.Status.ContainerStatuses) == 0 {
+		return false
+	}
+
+	for _, status := range p.Status.ContainerStatuses {
+		if !status.Ready {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// APITokenPrefix : beginning of every API token, to tell them apart from the JWT
+	APITokenPrefix = "jrm_"
+
+	// APITokenScopeRead : GET on the resources of /v1
+	APITokenScopeRead = "read"
+
+	// APITokenScopeWrite : POST, PUT and DELETE on the resources of /v1, except
+	// the roles, role bindings and policies of the environments
+	APITokenScopeWrite = "write"
+
+	// APITokenScopeCommands : execution of the commands of the BOT on /v1/commands
+	APITokenScopeCommands = "commands"
+)
+
+// APIToken : long-lived token of a user for systems calling the API, like CI.
+// Only the hash is saved, the token is shown once when it is created. Tokens
+// can't manage users nor other tokens
+type APIToken struct {
+	gorm.Model
+	Name string `json:"name" gorm:"not null;type:varchar(50)"`
+	User string `json:"user" gorm:"not null"`
+
+	// KeyID : public part of the token, used to find it before comparing the hash
+	KeyID string `json:"keyId" gorm:"unique;not null;type:varchar(20)"`
+	Hash  string `json:"-" gorm:"not null"`
+
+	// Scopes : comma separated scopes (read, write, commands)
+	Scopes     string     `json:"scopes" gorm:"not null"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+}
+
+// TableName : setting the tablename on migrate
+func (APIToken) TableName() string {
+	return "api_token"
+}
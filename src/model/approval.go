@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// ApprovalPending : waiting for another user to approve or reject
+	ApprovalPending = "pending"
+
+	// ApprovalApproved : approved, the command was executed
+	ApprovalApproved = "approved"
+
+	// ApprovalRejected : rejected by an authorized user or cancelled by the requester
+	ApprovalRejected = "rejected"
+
+	// ApprovalExpired : nobody approved it before ExpiresAt
+	ApprovalExpired = "expired"
+)
+
+// ApprovalRequest : a command on a protected environment waiting for the
+// approval of a second user. Text is the message of the command, executed as
+// the requester after the approval. Channel and MessageTS identify the Slack
+// message with the Approve/Reject buttons
+type ApprovalRequest struct {
+	gorm.Model
+	Command    string     `json:"command" gorm:"not null"`
+	Text       string     `json:"text" gorm:"not null;type:text"`
+	User       string     `json:"user" gorm:"not null"`
+	RancherID  uint       `json:"rancherId"`
+	ProjectID  string     `json:"projectId"`
+	Status     string     `json:"status" gorm:"not null;type:varchar(20)"`
+	Channel    string     `json:"channel"`
+	MessageTS  string     `json:"messageTs"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	DecidedAt  *time.Time `json:"decidedAt"`
+	DecidedBy  string     `json:"decidedBy"`
+	ExecutedAt *time.Time `json:"executedAt"`
+}
+
+// TableName : setting the tablename on migrate
+func (ApprovalRequest) TableName() string {
+	return "approval_request"
+}
+
+// ProtectedEnvironment : environment where the commands that change services
+// need the approval of a second user. RancherID 0 is the Rancher of the
+// environment variables of the BOT
+type ProtectedEnvironment struct {
+	gorm.Model
+	RancherID uint   `json:"rancherId"`
+	ProjectID string `json:"projectId" gorm:"not null"`
+	Reason    string `json:"reason"`
+}
+
+// TableName : setting the tablename on migrate
+func (ProtectedEnvironment) TableName() string {
+	return "protected_environment"
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// AuditSuccess : the command was executed without errors
+	AuditSuccess = "success"
+
+	// AuditError : the command was executed and failed, or a request to the API failed
+	AuditError = "error"
+
+	// AuditDenied : the user has no role with the command, or the command was
+	// refused by a policy of the environment (e.g. a program out of the exec policy)
+	AuditDenied = "denied"
+
+	// AuditInvalid : the arguments of the command are invalid, nothing was executed
+	AuditInvalid = "invalid"
+
+	// AuditPendingApproval : the environment is protected, an approval request was created
+	AuditPendingApproval = "pending_approval"
+)
+
+// AuditEvent : a command called on Slack, or a change made by the BOT in
+// background, whose User is the process (e.g. system:monitor). Args is a JSON object with the
+// arguments and flags, Requests a JSON list of the AuditRequest made to the
+// API of the Rancher and Status the status of the last one that changed something
+type AuditEvent struct {
+	gorm.Model
+	User      string `json:"user" gorm:"index"`
+	Channel   string `json:"channel"`
+	Command   string `json:"command" gorm:"index"`
+	Text      string `json:"text" gorm:"type:text"`
+	Args      string `json:"args" gorm:"type:text"`
+	RancherID uint   `json:"rancherId"`
+	ProjectID string `json:"projectId"`
+	Resource  string `json:"resource" gorm:"index"`
+	Requests  string `json:"requests" gorm:"type:text"`
+	Status    int    `json:"status"`
+	Outcome   string `json:"outcome" gorm:"not null;type:varchar(20)"`
+	Detail    string `json:"detail" gorm:"type:text"`
+}
+
+// TableName : setting the tablename on migrate
+func (AuditEvent) TableName() string {
+	return "audit_event"
+}
+
+// AuditRequest : a request made to the API of the Rancher by a command
+type AuditRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+}
+
+// AuditFilter : filters to list the audit events, the empty ones are ignored
+type AuditFilter struct {
+	User     string
+	Resource string
+	Command  string
+	Outcome  string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// RolloutRunning : rollout waiting for the next step
+	RolloutRunning = "running"
+
+	// RolloutCompleted : all steps were applied and the new version stayed healthy
+	RolloutCompleted = "completed"
+
+	// RolloutRolledBack : the new version turned unhealthy and the haproxy.cfg went back
+	RolloutRolledBack = "rolledback"
+
+	// RolloutFailed : the rollout stopped because of errors on Rancher API
+	RolloutFailed = "failed"
+
+	// RolloutCanceled : the rollout was canceled by a user
+	RolloutCanceled = "canceled"
+)
+
+// CanaryRollout : progressive canary of a load balancer, executed step by step in background
+type CanaryRollout struct {
+	gorm.Model
+	LoadBalancerID    string    `json:"loadBalancerId" gorm:"not null"`
+	ServiceID         string    `json:"serviceId" gorm:"not null"`
+	Steps             string    `json:"steps" gorm:"not null"`
+	CurrentStep       int       `json:"currentStep" gorm:"not null"`
+	IntervalSeconds   int64     `json:"intervalSeconds" gorm:"not null"`
+	NextStepAt        time.Time `json:"nextStepAt"`
+	PreviousNewWeight int       `json:"previousNewWeight" gorm:"not null"`
+	PreviousOldWeight int       `json:"previousOldWeight" gorm:"not null"`
+	PreviousConfig    string    `json:"previousConfig" gorm:"type:text"`
+	Status            string    `json:"status" gorm:"not null;type:varchar(20)"`
+	Message           string    `json:"message"`
+	Failures          int       `json:"failures" gorm:"not null"`
+	Channel           string    `json:"channel" gorm:"not null"`
+	User              string    `json:"user" gorm:"not null"`
+	RancherID         uint      `json:"rancherId" gorm:"not null;default:0"`
+	RancherProjectID  string    `json:"rancherProjectId" gorm:"not null"`
+}
+
+// TableName : setting the tablename on migrate
+func (CanaryRollout) TableName() string {
+	return "canary_rollout"
+}
@@ -0,0 +1,33 @@
+package model
+
+import "encoding/json"
+
+// CommandExecution : a command of the BOT executed by the API, as it would be
+// called on Slack
+type CommandExecution struct {
+	// Args : the text after the command name, like "app/api --env production"
+	Args string `json:"args"`
+
+	// Channel : Slack channel of the messages that are not the reply of the
+	// command, like approval requests. By default the channel of the BOT
+	Channel string `json:"channel"`
+}
+
+// CommandResult : the outcome and the replies of a command executed by the API
+type CommandResult struct {
+	Command      string           `json:"command"`
+	User         string           `json:"user"`
+	Outcome      string           `json:"outcome"`
+	Detail       string           `json:"detail"`
+	RancherID    uint             `json:"rancherId"`
+	ProjectID    string           `json:"projectId"`
+	AuditEventID uint             `json:"auditEventId"`
+	Messages     []CommandMessage `json:"messages"`
+}
+
+// CommandMessage : a reply of the command, with the attachments and blocks as Slack sends them
+type CommandMessage struct {
+	Text        string          `json:"text"`
+	Attachments json.RawMessage `json:"attachments,omitempty"`
+	Blocks      json.RawMessage `json:"blocks,omitempty"`
+}
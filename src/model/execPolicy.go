@@ -0,0 +1,35 @@
+package model
+
+import "github.com/jinzhu/gorm"
+
+const (
+	// ExecAllCommands : Commands of an exec policy that allows any program
+	ExecAllCommands = "*"
+
+	// ExecDefaultTimeout : seconds that container-exec waits for the command
+	// when the policy has no timeout
+	ExecDefaultTimeout = 30
+
+	// ExecMaxTimeout : the biggest timeout of an exec policy, in seconds
+	ExecMaxTimeout = 300
+)
+
+// ExecPolicy : the programs that container-exec can run on the containers of
+// an environment. Environments without a policy don't allow container-exec.
+// RancherID 0 is the Rancher of the environment variables of the BOT
+type ExecPolicy struct {
+	gorm.Model
+	RancherID uint   `json:"rancherId"`
+	ProjectID string `json:"projectId" gorm:"not null"`
+
+	// Commands : comma separated programs (e.g. ls,cat,env,ps), or *
+	Commands string `json:"commands" gorm:"not null"`
+
+	// TimeoutSeconds : how long the BOT waits for the command, 0 is the default
+	TimeoutSeconds int `json:"timeoutSeconds"`
+}
+
+// TableName : setting the tablename on migrate
+func (ExecPolicy) TableName() string {
+	return "exec_policy"
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// IncidentOpen : the task is failing and nobody acknowledged the incident yet
+	IncidentOpen = "open"
+
+	// IncidentAcknowledged : someone is working on the incident
+	IncidentAcknowledged = "acknowledged"
+
+	// IncidentResolved : the task recovered, or the incident was resolved on Slack
+	IncidentResolved = "resolved"
+)
+
+// Incident : a failure of a task, from the alert until it is resolved. Channel
+// and MessageTS identify the Slack message of the alert, where every event of
+// the incident is replied in thread
+type Incident struct {
+	gorm.Model
+	TaskID      uint       `json:"taskId" gorm:"not null"`
+	Service     string     `json:"service"`
+	RancherID   uint       `json:"rancherId"`
+	ProjectID   string     `json:"projectId"`
+	Environment string     `json:"environment"`
+	Status      string     `json:"status" gorm:"not null;type:varchar(20)"`
+	Channel     string     `json:"channel"`
+	MessageTS   string     `json:"messageTs"`
+	OpenedAt    time.Time  `json:"openedAt"`
+	AckedAt     *time.Time `json:"ackedAt"`
+	AckedBy     string     `json:"ackedBy"`
+	ResolvedAt  *time.Time `json:"resolvedAt"`
+	ResolvedBy  string     `json:"resolvedBy"`
+}
+
+// TableName : setting the tablename on migrate
+func (Incident) TableName() string {
+	return "incident"
+}
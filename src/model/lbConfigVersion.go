@@ -0,0 +1,41 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// LbConfigInitial : the config found on the LB before the first change made by the bot
+	LbConfigInitial = "initial"
+
+	// LbConfigWeights : change of the canary weights (canary-update, canary-up, canary-rollout)
+	LbConfigWeights = "weights"
+
+	// LbConfigEnable : canary-enable
+	LbConfigEnable = "enable"
+
+	// LbConfigDisable : canary-disable
+	LbConfigDisable = "disable"
+
+	// LbConfigRollback : canary-rollback to a previous version
+	LbConfigRollback = "rollback"
+)
+
+// LbConfigVersion : snapshot of the lbConfig.config of a load balancer after a change
+type LbConfigVersion struct {
+	gorm.Model
+	RancherID      uint   `json:"rancherId"`
+	ProjectID      string `json:"projectId" gorm:"not null"`
+	LoadBalancerID string `json:"loadBalancerId" gorm:"not null"`
+	Version        int    `json:"version" gorm:"not null"`
+	Action         string `json:"action" gorm:"not null;type:varchar(20)"`
+	User           string `json:"user"`
+	OldWeights     string `json:"oldWeights"`
+	NewWeights     string `json:"newWeights"`
+	Config         string `json:"config" gorm:"type:text"`
+}
+
+// TableName : setting the tablename on migrate
+func (LbConfigVersion) TableName() string {
+	return "lb_config_version"
+}
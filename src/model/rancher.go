@@ -2,13 +2,33 @@ package model
 
 import "github.com/jinzhu/gorm"
 
+const (
+	// RancherTypeRancher is a Rancher 1.6 (Cattle) server, using the v2-beta API
+	RancherTypeRancher = "rancher"
+
+	// RancherTypeKubernetes is a Kubernetes API server, like a Rancher 2.x cluster
+	// (https://rancher/k8s/clusters/<cluster-id>)
+	RancherTypeKubernetes = "kubernetes"
+
+	// DefaultMaxConcurrency : checks of tasks running at the same time on a Rancher
+	DefaultMaxConcurrency = 4
+
+	// UnresolvedRancherID : RancherID of the rows of old versions whose Rancher
+	// URL matches no registered Rancher. They are migrated again on the next
+	// start, after the Rancher is registered
+	UnresolvedRancherID = 1<<32 - 1
+)
+
 // Rancher : model to w&r on db
 type Rancher struct {
 	gorm.Model
 	Name      string `json:"name" gorm:"unique;not null;type:varchar(50)"`
+	Type      string `json:"type" gorm:"not null;type:varchar(20);default:'rancher'"`
 	URL       string `json:"url" gorm:"not null"`
 	AccessKey string `json:"accessKey" gorm:"not null"`
-	SecretKey string `json:"secretKey" gorm:"not null"`
+	SecretKey string `json:"secretKey" gorm:"not null"` // encrypted on db by the repository
+
+	MaxConcurrency int `json:"maxConcurrency" gorm:"not null;default:4"`
 }
 
 // TableName : setting the tablename on migrate
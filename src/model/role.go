@@ -0,0 +1,48 @@
+package model
+
+import "github.com/jinzhu/gorm"
+
+const (
+	// RoleAllCommands : Commands of a role that allows every command
+	RoleAllCommands = "*"
+
+	// RoleSubjectUser : binding to a Slack user (U0123ABCD), or to an API user
+	// (api:username) running commands on /v1/commands
+	RoleSubjectUser = "user"
+
+	// RoleSubjectGroup : binding to a Slack user group (S0123ABCD)
+	RoleSubjectGroup = "group"
+)
+
+// Role : commands that the users bound to the role can run
+type Role struct {
+	gorm.Model
+	Name        string `json:"name" gorm:"unique;not null;type:varchar(50)"`
+	Description string `json:"description"`
+
+	// Commands : comma separated commands (e.g. service-stop,service-start), or *
+	Commands string `json:"commands" gorm:"not null"`
+}
+
+// TableName : setting the tablename on migrate
+func (Role) TableName() string {
+	return "role"
+}
+
+// RoleBinding : gives a Role to a Slack user or user group, on every Rancher
+// (AllRanchers) or only on one Rancher (RancherID, 0 is the Rancher of the
+// environment variables), and on every environment or only on one (ProjectID)
+type RoleBinding struct {
+	gorm.Model
+	RoleID      uint   `json:"roleId" gorm:"not null"`
+	SubjectType string `json:"subjectType" gorm:"not null;type:varchar(20)"`
+	Subject     string `json:"subject" gorm:"not null;type:varchar(50)"`
+	AllRanchers bool   `json:"allRanchers" gorm:"not null;default:false"`
+	RancherID   uint   `json:"rancherId"`
+	ProjectID   string `json:"projectId"`
+}
+
+// TableName : setting the tablename on migrate
+func (RoleBinding) TableName() string {
+	return "role_binding"
+}
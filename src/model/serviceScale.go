@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// ScaleDefaultMin : the minimum scale on environments without a ScaleLimit
+	ScaleDefaultMin = 1
+
+	// ScaleDefaultMax : the maximum scale on environments without a ScaleLimit
+	ScaleDefaultMax = 10
+
+	// ScaleRevertPending : waiting for RevertAt to go back to the previous scale
+	ScaleRevertPending = "pending"
+
+	// ScaleRevertDone : the service went back to the previous scale
+	ScaleRevertDone = "reverted"
+
+	// ScaleRevertCanceled : the service was scaled again before RevertAt, by
+	// the BOT or outside of it
+	ScaleRevertCanceled = "canceled"
+
+	// ScaleRevertFailed : the scale couldn't be reverted because of errors on Rancher API
+	ScaleRevertFailed = "failed"
+)
+
+// ScaleLimit : the minimum and maximum scale that service-scale accepts on the
+// services of an environment. RancherID 0 is the Rancher of the environment
+// variables of the BOT
+type ScaleLimit struct {
+	gorm.Model
+	RancherID uint   `json:"rancherId"`
+	ProjectID string `json:"projectId" gorm:"not null"`
+	Min       int    `json:"min"`
+	Max       int    `json:"max" gorm:"not null"`
+}
+
+// TableName : setting the tablename on migrate
+func (ScaleLimit) TableName() string {
+	return "scale_limit"
+}
+
+// ScaleRevert : a timed scale (service-scale --for), the service goes back to
+// Scale at RevertAt if it is still at TemporaryScale
+type ScaleRevert struct {
+	gorm.Model
+	RancherID      uint      `json:"rancherId"`
+	ProjectID      string    `json:"projectId"`
+	ServiceID      string    `json:"serviceId" gorm:"index;not null"`
+	ServiceName    string    `json:"serviceName"`
+	Scale          int       `json:"scale"`
+	TemporaryScale int       `json:"temporaryScale"`
+	RevertAt       time.Time `json:"revertAt" gorm:"index"`
+	User           string    `json:"user"`
+	Channel        string    `json:"channel"`
+	ThreadTS       string    `json:"threadTs"`
+	Status         string    `json:"status" gorm:"not null;type:varchar(20)"`
+	Detail         string    `json:"detail"`
+}
+
+// TableName : setting the tablename on migrate
+func (ScaleRevert) TableName() string {
+	return "scale_revert"
+}
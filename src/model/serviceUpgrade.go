@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// UpgradeUpgrading : Rancher is replacing the containers batch by batch
+	UpgradeUpgrading = "upgrading"
+
+	// UpgradeUpgraded : all containers are on the new image, waiting for finish or rollback
+	UpgradeUpgraded = "upgraded"
+
+	// UpgradeFinished : the upgrade was finished and the previous containers removed
+	UpgradeFinished = "finished"
+
+	// UpgradeRolledBack : the service went back to the previous image
+	UpgradeRolledBack = "rolledback"
+
+	// UpgradeUntracked : the BOT stopped tracking the upgrade, it must be
+	// finished or rolled back by hand
+	UpgradeUntracked = "untracked"
+)
+
+// ServiceUpgrade : an in-service upgrade started by service-upgrade, tracked
+// until it is finished or rolled back. With AutoFinishSeconds the upgrade is
+// finished after the service stays healthy for that long
+type ServiceUpgrade struct {
+	gorm.Model
+	RancherID         uint       `json:"rancherId"`
+	ProjectID         string     `json:"projectId"`
+	ServiceID         string     `json:"serviceId" gorm:"index;not null"`
+	ServiceName       string     `json:"serviceName"`
+	Image             string     `json:"image"`
+	PreviousImage     string     `json:"previousImage"`
+	BatchSize         int        `json:"batchSize"`
+	IntervalMillis    int64      `json:"intervalMillis"`
+	AutoFinishSeconds int64      `json:"autoFinishSeconds"`
+	User              string     `json:"user"`
+	Channel           string     `json:"channel"`
+	ThreadTS          string     `json:"threadTs"`
+	Status            string     `json:"status" gorm:"not null;type:varchar(20)"`
+	Progress          string     `json:"progress"`
+	UpgradedAt        *time.Time `json:"upgradedAt"`
+	HealthySince      *time.Time `json:"healthySince"`
+
+	// ClosedBy : the user that finished or rolled back the upgrade, empty
+	// when it was auto finished or closed on the Rancher UI
+	ClosedBy string `json:"closedBy"`
+	Detail   string `json:"detail"`
+}
+
+// TableName : setting the tablename on migrate
+func (ServiceUpgrade) TableName() string {
+	return "service_upgrade"
+}
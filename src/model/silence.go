@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// SilenceKindSilence : silence created with `silence`, from now until EndsAt
+	SilenceKindSilence = "silence"
+
+	// SilenceKindMaintenance : scheduled maintenance window, once (StartsAt/EndsAt)
+	// or recurring (Cron + DurationMinutes)
+	SilenceKindMaintenance = "maintenance"
+
+	// SilenceAllTasks : Service of the silences of all tasks of the environment
+	SilenceAllTasks = "all"
+)
+
+// Silence : suppresses the alerts and the auto restarts of tasks, while their
+// health keeps being checked. The target is a task (TaskID) or a service
+// (`stack/service`, or `all`) of an environment
+type Silence struct {
+	gorm.Model
+	Kind            string    `json:"kind" gorm:"not null;type:varchar(20)"`
+	TaskID          uint      `json:"taskId"`
+	Service         string    `json:"service"`
+	RancherID       uint      `json:"rancherId"`
+	ProjectID       string    `json:"projectId"`
+	StartsAt        time.Time `json:"startsAt"`
+	EndsAt          time.Time `json:"endsAt"`
+	Cron            string    `json:"cron"`
+	DurationMinutes int       `json:"durationMinutes"`
+	Reason          string    `json:"reason"`
+	User            string    `json:"user"`
+}
+
+// TableName : setting the tablename on migrate
+func (Silence) TableName() string {
+	return "silence"
+}
@@ -0,0 +1,20 @@
+package model
+
+import "github.com/jinzhu/gorm"
+
+// SlackContext : Rancher and environment selected by a Slack user with
+// rancher-set and env-set, in every channel (Channel empty) or only in one.
+// RancherID 0 is the Rancher of the environment variables of the BOT
+type SlackContext struct {
+	gorm.Model
+	User        string `json:"user" gorm:"not null;type:varchar(50)"`
+	Channel     string `json:"channel" gorm:"type:varchar(50)"`
+	RancherID   uint   `json:"rancherId"`
+	ProjectID   string `json:"projectId"`
+	Environment string `json:"environment"`
+}
+
+// TableName : setting the tablename on migrate
+func (SlackContext) TableName() string {
+	return "slack_context"
+}
@@ -2,19 +2,43 @@ package model
 
 import "github.com/jinzhu/gorm"
 
-// Task : system user
+// Task : system user. RancherID 0 is the Rancher of the environment variables
 type Task struct {
 	gorm.Model
 	Service            string `json:"service" gorm:"not null"`
 	ChannelToSendAlert string `json:"channelToSendAlert" gorm:"not null"`
-	RancherURL         string `json:"rancherUrl" gorm:"not null"`
-	RancherAccessKey   string `json:"rancherAccessKey" gorm:"not null"`
-	RancherSecretKey   string `json:"rancherSecretKey" gorm:"not null"`
+	RancherID          uint   `json:"rancherId" gorm:"not null;default:0"`
 	RancherProjectID   string `json:"rancherProjectId" gorm:"not null"`
 	IsRestartEnabled   bool   `json:"isRestartEnabled" gorm:"not null"`
 	IsOnlyCheck        bool   `json:"isOnlyCheck" gorm:"not null"`
+	FailingAfter       int    `json:"failingAfter" gorm:"not null;default:3"`
+	RecoveredAfter     int    `json:"recoveredAfter" gorm:"not null;default:2"`
+	FlapThreshold      int    `json:"flapThreshold" gorm:"not null;default:3"`
+	FlapWindowMinutes  int    `json:"flapWindowMinutes" gorm:"not null;default:60"`
+	IntervalSeconds    int64  `json:"intervalSeconds" gorm:"not null"`
+	Cron               string `json:"cron"`
+
+	// Paused : the task is not checked until resumed, keeping its health state
+	Paused bool `json:"paused" gorm:"not null;default:false"`
 }
 
+const (
+	// DefaultFailingAfter : unhealthy checks in a row until the task alerts
+	DefaultFailingAfter = 3
+
+	// DefaultRecoveredAfter : healthy checks in a row until the task is recovered
+	DefaultRecoveredAfter = 2
+
+	// DefaultFlapThreshold : failures inside the flap window that make the task flapping
+	DefaultFlapThreshold = 3
+
+	// DefaultFlapWindowMinutes : window of the flap detection
+	DefaultFlapWindowMinutes = 60
+
+	// MinTaskIntervalSeconds : smallest interval accepted on --every
+	MinTaskIntervalSeconds = 30
+)
+
 // TableNane : setting the tablename on migrate
 func (Task) TableName() string {
 	return "task"
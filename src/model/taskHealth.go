@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// HealthHealthy : the service is healthy
+	HealthHealthy = "healthy"
+
+	// HealthDegraded : the service failed some checks, but less than Task.FailingAfter
+	HealthDegraded = "degraded"
+
+	// HealthFailing : the service failed Task.FailingAfter checks in a row, the alert was sent
+	HealthFailing = "failing"
+
+	// HealthRecovering : the service is healthy again, but less than Task.RecoveredAfter checks
+	HealthRecovering = "recovering"
+)
+
+// TaskHealth : state of the health check of a task
+type TaskHealth struct {
+	gorm.Model
+	TaskID           uint      `json:"taskId" gorm:"unique;not null"`
+	ServiceID        string    `json:"serviceId"`
+	State            string    `json:"state" gorm:"not null;type:varchar(20)"`
+	FailedChecks     int       `json:"failedChecks" gorm:"not null"`
+	HealthyChecks    int       `json:"healthyChecks" gorm:"not null"`
+	RecoveryAttempts int       `json:"recoveryAttempts" gorm:"not null"`
+	Alerted          bool      `json:"alerted" gorm:"not null"`
+	Flapping         bool      `json:"flapping" gorm:"not null"`
+	FlapCount        int       `json:"flapCount" gorm:"not null"`
+	FlapWindowStart  time.Time `json:"flapWindowStart"`
+	LastFailingAt    time.Time `json:"lastFailingAt"`
+	LastChangeAt     time.Time `json:"lastChangeAt"`
+
+	// IncidentID : the open incident of the task, its Slack message is updated
+	// in place while the task doesn't recover
+	IncidentID uint `json:"incidentId"`
+}
+
+// TableName : setting the tablename on migrate
+func (TaskHealth) TableName() string {
+	return "task_health"
+}
@@ -2,14 +2,33 @@ package model
 
 import "github.com/jinzhu/gorm"
 
+// APIUserPrefix : prefix of the API users on the commands executed by the API,
+// like on the audit events and role bindings (api:username)
+const APIUserPrefix = "api:"
+
 // User : system user
 type User struct {
 	gorm.Model
 	Username string `json:"username" gorm:"not null"`
-	Password string `json:"password" gorm:"not null"`
+	Password string `json:"password,omitempty" gorm:"not null"`
 }
 
 // TableName : setting the tablename on migrate
 func (User) TableName() string {
 	return "user"
 }
+
+// Bootstrap : creation of the first user of the API, with the bootstrap token
+// logged by the BOT while there is no user
+type Bootstrap struct {
+	Token    string `json:"token"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// PasswordChange : new password of a user, with the current one when the user
+// changes its own password
+type PasswordChange struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
@@ -0,0 +1,135 @@
+// Package rancher is a small typed client for the Rancher 1.6 v2-beta API
+package rancher
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Client : keeps the credentials and the project (environment) used on requests
+type Client struct {
+	BaseURL   string
+	AccessKey string
+	SecretKey string
+	ProjectID string
+
+	HTTPClient *http.Client
+
+	// OnResponse : if not nil, called after every request with its status (0 when it failed)
+	OnResponse func(method string, url string, status int)
+}
+
+// NewClient : creates a client to the Rancher API
+func NewClient(baseURL string, accessKey string, secretKey string, projectID string) *Client {
+	return &Client{
+		BaseURL:   strings.TrimSuffix(baseURL, "/"),
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		ProjectID: projectID,
+		HTTPClient: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+}
+
+// collection : envelope of every list returned by the API
+type collection struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// URL : returns the full URL of a path under /v2-beta
+func (c *Client) URL(format string, a ...interface{}) string {
+	return c.BaseURL + "/v2-beta" + fmt.Sprintf(format, a...)
+}
+
+// ProjectURL : returns the full URL of a path under the selected project
+func (c *Client) ProjectURL(format string, a ...interface{}) string {
+	return c.URL("/projects/%s", c.ProjectID) + fmt.Sprintf(format, a...)
+}
+
+func (c *Client) list(url string, out interface{}) error {
+	return c.Do(http.MethodGet, url, nil, &collection{Data: out})
+}
+
+// Do : sends a request to the API, encoding `in` as body (if not nil) and
+// decoding the response into `out` (if not nil). Any status >= 300 or body
+// with `type: error` is returned as *APIError
+func (c *Client) Do(method string, url string, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		switch v := in.(type) {
+		case []byte:
+			body = bytes.NewReader(v)
+		case string:
+			body = strings.NewReader(v)
+		default:
+			b, err := json.Marshal(in)
+			if err != nil {
+				return err
+			}
+			body = bytes.NewReader(b)
+		}
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+
+	if c.AccessKey != "" && c.SecretKey != "" {
+		req.SetBasicAuth(c.AccessKey, c.SecretKey)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		c.report(method, url, 0)
+		return err
+	}
+	defer resp.Body.Close()
+
+	c.report(method, url, resp.StatusCode)
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if apiErr := parseAPIError(resp.StatusCode, respBody); apiErr != nil {
+		return apiErr
+	}
+
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+
+	if raw, ok := out.(*[]byte); ok {
+		*raw = respBody
+		return nil
+	}
+
+	return json.Unmarshal(respBody, out)
+}
+
+func (c *Client) report(method string, url string, status int) {
+	if c.OnResponse != nil {
+		c.OnResponse(method, url, status)
+	}
+}
@@ -0,0 +1,66 @@
+package rancher
+
+import "net/http"
+
+// LogsOptions : body of the `logs` action
+type LogsOptions struct {
+	Follow bool `json:"follow"`
+	Lines  int  `json:"lines"`
+}
+
+// ExecOptions : body of the `execute` action
+type ExecOptions struct {
+	AttachStdin  bool     `json:"attachStdin"`
+	AttachStdout bool     `json:"attachStdout"`
+	Command      []string `json:"command"`
+	Tty          bool     `json:"tty"`
+}
+
+// ListContainers : lists all containers of the project
+func (c *Client) ListContainers() ([]Container, error) {
+	var containers []Container
+	err := c.list(c.ProjectURL("/containers?limit=0"), &containers)
+
+	return containers, err
+}
+
+// GetContainer : gets one container by ID
+func (c *Client) GetContainer(ID string) (Container, error) {
+	var container Container
+	err := c.Do(http.MethodGet, c.ProjectURL("/containers/%s", ID), nil, &container)
+
+	return container, err
+}
+
+// RestartContainer : calls the `restart` action of a container
+func (c *Client) RestartContainer(ID string) (Container, error) {
+	var container Container
+	err := c.Do(http.MethodPost, c.ProjectURL("/containers/%s?action=restart", ID), nil, &container)
+
+	return container, err
+}
+
+// DeleteContainer : removes a container
+func (c *Client) DeleteContainer(ID string) (Container, error) {
+	var container Container
+	err := c.Do(http.MethodDelete, c.ProjectURL("/containers/%s", ID), nil, &container)
+
+	return container, err
+}
+
+// ContainerLogs : calls the `logs` action, that returns the websocket to read the logs
+func (c *Client) ContainerLogs(ID string, opts LogsOptions) (HostAccess, error) {
+	var access HostAccess
+	err := c.Do(http.MethodPost, c.ProjectURL("/containers/%s?action=logs", ID), opts, &access)
+
+	return access, err
+}
+
+// ExecuteContainer : calls the `execute` action, that returns the websocket
+// with the output of the command
+func (c *Client) ExecuteContainer(ID string, opts ExecOptions) (HostAccess, error) {
+	var access HostAccess
+	err := c.Do(http.MethodPost, c.ProjectURL("/containers/%s?action=execute", ID), opts, &access)
+
+	return access, err
+}
@@ -0,0 +1,67 @@
+package rancher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError : error body returned by Rancher (`type: error`)
+type APIError struct {
+	Type    string `json:"type"`
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Detail  string `json:"detail"`
+	Field   string `json:"fieldName"`
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("rancher: %d", e.Status)
+	if e.Code != "" {
+		msg += " " + e.Code
+	}
+	if e.Field != "" {
+		msg += fmt.Sprintf(" (field %s)", e.Field)
+	}
+	if e.Message != "" && e.Message != e.Code {
+		msg += ": " + e.Message
+	}
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+
+	return msg
+}
+
+// IsNotFound : checks if the error is a 404 from Rancher
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(*APIError)
+	return ok && apiErr.Status == http.StatusNotFound
+}
+
+func parseAPIError(status int, body []byte) *APIError {
+	var apiErr APIError
+	jsonErr := json.Unmarshal(body, &apiErr)
+
+	if status < 300 && (jsonErr != nil || apiErr.Type != "error") {
+		return nil
+	}
+
+	if jsonErr != nil || apiErr.Type != "error" {
+		apiErr = APIError{
+			Type:    "error",
+			Message: strings.TrimSpace(string(body)),
+		}
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(status)
+		}
+	}
+
+	if apiErr.Status == 0 {
+		apiErr.Status = status
+	}
+
+	return &apiErr
+}
@@ -0,0 +1,125 @@
+package rancher
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// EventResourceChange : event sent when a resource of the project changes state
+const EventResourceChange = "resource.change"
+
+// subscriptionTimeout : Rancher sends a ping every few seconds, so a connection
+// without messages for this long is considered dead
+const subscriptionTimeout = time.Minute
+
+// Event : a message of the subscribe websocket
+type Event struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	ResourceType string `json:"resourceType"`
+	ResourceID   string `json:"resourceId"`
+	Data         struct {
+		Resource json.RawMessage `json:"resource"`
+	} `json:"data"`
+}
+
+// Service : decodes the resource of the event as a service
+func (e Event) Service() (Service, error) {
+	var service Service
+	err := json.Unmarshal(e.Data.Resource, &service)
+	return service, err
+}
+
+// Container : decodes the resource of the event as a container
+func (e Event) Container() (Container, error) {
+	var container Container
+	err := json.Unmarshal(e.Data.Resource, &container)
+	return container, err
+}
+
+// Subscription : websocket connection receiving the events of the project
+type Subscription struct {
+	conn *websocket.Conn
+}
+
+// Subscribe : opens the /subscribe websocket of the project, receiving only
+// the events with the names sent (ex.: EventResourceChange)
+func (c *Client) Subscribe(eventNames ...string) (*Subscription, error) {
+	query := url.Values{}
+	for _, name := range eventNames {
+		query.Add("eventNames", name)
+	}
+
+	wsURL := c.ProjectURL("/subscribe?%s", query.Encode())
+	wsURL = "ws" + strings.TrimPrefix(wsURL, "http")
+
+	header := http.Header{}
+	if c.AccessKey != "" && c.SecretKey != "" {
+		req := &http.Request{Header: header}
+		req.SetBasicAuth(c.AccessKey, c.SecretKey)
+	}
+
+	conn, err := dialWebsocket(wsURL, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Subscription{conn: conn}, nil
+}
+
+// dialWebsocket : opens a websocket of Rancher, returning the API error when
+// the handshake is refused
+func dialWebsocket(wsURL string, header http.Header) (*websocket.Conn, error) {
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 30 * time.Second,
+		TLSClientConfig:  &tls.Config{InsecureSkipVerify: true},
+	}
+
+	conn, resp, err := dialer.Dial(wsURL, header)
+	if err != nil {
+		if resp != nil {
+			if apiErr := parseAPIError(resp.StatusCode, nil); apiErr != nil {
+				return nil, apiErr
+			}
+		}
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// Next : waits for the next event. Pings sent by Rancher are skipped
+func (s *Subscription) Next() (Event, error) {
+	for {
+		var event Event
+
+		s.conn.SetReadDeadline(time.Now().Add(subscriptionTimeout))
+
+		_, msg, err := s.conn.ReadMessage()
+		if err != nil {
+			return event, err
+		}
+
+		if err := json.Unmarshal(msg, &event); err != nil {
+			continue
+		}
+
+		if event.Name == "ping" {
+			continue
+		}
+
+		return event, nil
+	}
+}
+
+// Close : closes the websocket, making a blocked Next return an error
+func (s *Subscription) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,62 @@
+package rancher
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/gorilla/websocket"
+)
+
+// ExecStream : websocket connection receiving the output of a command
+// running in a container
+type ExecStream struct {
+	conn *websocket.Conn
+}
+
+// StreamContainerExec : runs the command in the container, without stdin and
+// tty, and opens the websocket of its output. The stream ends when the command
+// exits, closing it before doesn't stop the command
+func (c *Client) StreamContainerExec(ID string, command []string) (*ExecStream, error) {
+	access, err := c.ExecuteContainer(ID, ExecOptions{AttachStdout: true, Command: command})
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dialWebsocket(fmt.Sprintf("%s?token=%s", access.URL, url.QueryEscape(access.Token)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExecStream{conn: conn}, nil
+}
+
+// Next : waits for the next chunk of the output, stdout and stderr together.
+// io.EOF when the command exited
+func (s *ExecStream) Next() ([]byte, error) {
+	for {
+		_, msg, err := s.conn.ReadMessage()
+		if err != nil {
+			if _, ok := err.(*websocket.CloseError); ok {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+
+		// the output comes encoded in base64
+		data, err := base64.StdEncoding.DecodeString(string(msg))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(data) > 0 {
+			return data, nil
+		}
+	}
+}
+
+// Close : closes the websocket, making a blocked Next return an error
+func (s *ExecStream) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,19 @@
+package rancher
+
+import "net/http"
+
+// ListHosts : lists all hosts of the project
+func (c *Client) ListHosts() ([]Host, error) {
+	var hosts []Host
+	err := c.list(c.ProjectURL("/hosts"), &hosts)
+
+	return hosts, err
+}
+
+// GetHost : gets one host by ID
+func (c *Client) GetHost(ID string) (Host, error) {
+	var host Host
+	err := c.Do(http.MethodGet, c.ProjectURL("/hosts/%s", ID), nil, &host)
+
+	return host, err
+}
@@ -0,0 +1,44 @@
+package rancher
+
+import (
+	"net/http"
+
+	"github.com/tidwall/sjson"
+)
+
+// ListLoadBalancerServices : lists all load balancers of the project
+func (c *Client) ListLoadBalancerServices() ([]LoadBalancerService, error) {
+	var lbs []LoadBalancerService
+	err := c.list(c.ProjectURL("/loadBalancerServices"), &lbs)
+
+	return lbs, err
+}
+
+// GetLoadBalancerService : gets one load balancer by ID
+func (c *Client) GetLoadBalancerService(ID string) (LoadBalancerService, error) {
+	var lb LoadBalancerService
+	err := c.Do(http.MethodGet, c.ProjectURL("/loadBalancerServices/%s", ID), nil, &lb)
+
+	return lb, err
+}
+
+// UpdateLoadBalancerConfig : replaces the custom haproxy.cfg (lbConfig.config)
+// of a load balancer. The whole resource is sent back, so nothing else changes
+func (c *Client) UpdateLoadBalancerConfig(ID string, config string) (LoadBalancerService, error) {
+	var lb LoadBalancerService
+	var raw []byte
+
+	url := c.ProjectURL("/loadBalancerServices/%s", ID)
+	if err := c.Do(http.MethodGet, url, nil, &raw); err != nil {
+		return lb, err
+	}
+
+	raw, err := sjson.SetBytes(raw, "lbConfig.config", config)
+	if err != nil {
+		return lb, err
+	}
+
+	err = c.Do(http.MethodPut, url, raw, &lb)
+
+	return lb, err
+}
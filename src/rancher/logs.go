@@ -0,0 +1,94 @@
+package rancher
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// LogLine : a line of the logs of a container
+type LogLine struct {
+	Stderr bool
+	// Time : when the line was written, zero if the line has no timestamp
+	Time time.Time
+	Text string
+}
+
+// LogStream : websocket connection receiving the logs of a container
+type LogStream struct {
+	conn    *websocket.Conn
+	pending []LogLine
+}
+
+// StreamContainerLogs : calls the `logs` action of a container and opens its
+// websocket. With opts.Follow the stream only ends when closed, so it must
+// always be closed by the caller
+func (c *Client) StreamContainerLogs(ID string, opts LogsOptions) (*LogStream, error) {
+	access, err := c.ContainerLogs(ID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dialWebsocket(fmt.Sprintf("%s?token=%s", access.URL, url.QueryEscape(access.Token)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LogStream{conn: conn}, nil
+}
+
+// Next : waits for the next line, io.EOF when the container has no more logs
+func (s *LogStream) Next() (LogLine, error) {
+	for len(s.pending) == 0 {
+		_, msg, err := s.conn.ReadMessage()
+		if err != nil {
+			if _, ok := err.(*websocket.CloseError); ok {
+				return LogLine{}, io.EOF
+			}
+			return LogLine{}, err
+		}
+
+		s.pending = parseLogMessage(string(msg))
+	}
+
+	line := s.pending[0]
+	s.pending = s.pending[1:]
+
+	return line, nil
+}
+
+// Close : closes the websocket, making a blocked Next return an error
+func (s *LogStream) Close() error {
+	return s.conn.Close()
+}
+
+// parseLogMessage : splits a message of the logs websocket in lines. Each
+// message starts with 01 (stdout) or 02 (stderr) and the lines with the
+// timestamp written by Docker
+func parseLogMessage(msg string) []LogLine {
+	var stderr bool
+	if strings.HasPrefix(msg, "01") || strings.HasPrefix(msg, "02") {
+		stderr = msg[:2] == "02"
+		msg = msg[2:]
+	}
+
+	var lines []LogLine
+	for _, text := range strings.Split(strings.TrimRight(msg, "\r\n"), "\n") {
+		line := LogLine{Stderr: stderr, Text: strings.TrimLeft(text, " ")}
+
+		if i := strings.Index(line.Text, " "); i > 0 {
+			if t, err := time.Parse(time.RFC3339Nano, strings.Trim(line.Text[:i], "[]")); err == nil {
+				line.Time = t
+				line.Text = line.Text[i+1:]
+			}
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
@@ -0,0 +1,19 @@
+package rancher
+
+import "net/http"
+
+// ListProjects : lists all projects (environments) of the Rancher
+func (c *Client) ListProjects() ([]Project, error) {
+	var projects []Project
+	err := c.list(c.URL("/projects"), &projects)
+
+	return projects, err
+}
+
+// GetProject : gets one project (environment) by ID
+func (c *Client) GetProject(ID string) (Project, error) {
+	var project Project
+	err := c.Do(http.MethodGet, c.URL("/projects/%s", ID), nil, &project)
+
+	return project, err
+}
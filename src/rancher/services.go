@@ -0,0 +1,115 @@
+package rancher
+
+import "net/http"
+
+// ListServices : lists all services of the project
+func (c *Client) ListServices() ([]Service, error) {
+	var services []Service
+	err := c.list(c.ProjectURL("/services"), &services)
+
+	return services, err
+}
+
+// GetService : gets one service by ID
+func (c *Client) GetService(ID string) (Service, error) {
+	var service Service
+	err := c.Do(http.MethodGet, c.ProjectURL("/services/%s", ID), nil, &service)
+
+	return service, err
+}
+
+// GetServiceStack : gets the stack of a service
+func (c *Client) GetServiceStack(ID string) (Stack, error) {
+	var stack Stack
+	err := c.Do(http.MethodGet, c.ProjectURL("/services/%s/stack", ID), nil, &stack)
+
+	return stack, err
+}
+
+// ListServiceInstances : lists the containers of a service
+func (c *Client) ListServiceInstances(ID string) ([]Container, error) {
+	var containers []Container
+	err := c.list(c.ProjectURL("/services/%s/instances", ID), &containers)
+
+	return containers, err
+}
+
+// ActivateService : calls the `activate` action of a service
+func (c *Client) ActivateService(ID string) (Service, error) {
+	return c.serviceAction(ID, "activate", nil)
+}
+
+// DeactivateService : calls the `deactivate` action of a service
+func (c *Client) DeactivateService(ID string) (Service, error) {
+	return c.serviceAction(ID, "deactivate", nil)
+}
+
+// UpdateService : updates fields of a service (ex.: scale)
+func (c *Client) UpdateService(ID string, fields map[string]interface{}) (Service, error) {
+	var service Service
+	err := c.Do(http.MethodPut, c.ProjectURL("/services/%s", ID), fields, &service)
+
+	return service, err
+}
+
+// ScaleService : changes the number of containers of a service
+func (c *Client) ScaleService(ID string, scale int) (Service, error) {
+	return c.UpdateService(ID, map[string]interface{}{"scale": scale})
+}
+
+// UpgradeOptions : the in-service strategy of an upgrade, Rancher replaces
+// BatchSize containers at a time waiting IntervalMillis between the batches
+type UpgradeOptions struct {
+	BatchSize      int
+	IntervalMillis int64
+}
+
+// UpgradeService : starts an in-service upgrade changing the image of the
+// service. The service stays `upgraded` until FinishUpgradeService or RollbackService
+func (c *Client) UpgradeService(ID string, newImage string, opts UpgradeOptions) (Service, error) {
+	service, err := c.GetService(ID)
+	if err != nil {
+		return service, err
+	}
+
+	launchConfig := service.LaunchConfig
+	if launchConfig == nil {
+		launchConfig = LaunchConfig{}
+	}
+	launchConfig["imageUuid"] = newImage
+
+	strategy := map[string]interface{}{
+		"launchConfig": launchConfig,
+	}
+	if opts.BatchSize > 0 {
+		strategy["batchSize"] = opts.BatchSize
+	}
+	if opts.IntervalMillis > 0 {
+		strategy["intervalMillis"] = opts.IntervalMillis
+	}
+
+	body := map[string]interface{}{
+		"inServiceStrategy": strategy,
+	}
+
+	return c.serviceAction(ID, "upgrade", body)
+}
+
+// FinishUpgradeService : calls the `finishupgrade` action, removing the
+// containers of the previous version
+func (c *Client) FinishUpgradeService(ID string) (Service, error) {
+	return c.serviceAction(ID, "finishupgrade", nil)
+}
+
+// RollbackService : calls the `rollback` action, going back to the previous
+// version of an upgraded service
+func (c *Client) RollbackService(ID string) (Service, error) {
+	return c.serviceAction(ID, "rollback", nil)
+}
+
+func (c *Client) serviceAction(ID string, action string, body interface{}) (Service, error) {
+	var service Service
+	err := c.Do(http.MethodPost, c.ProjectURL("/services/%s?action=%s", ID, action), body, &service)
+
+	return service, err
+}
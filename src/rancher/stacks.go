@@ -0,0 +1,27 @@
+package rancher
+
+import "net/http"
+
+// ListStacks : lists all stacks of the project
+func (c *Client) ListStacks() ([]Stack, error) {
+	var stacks []Stack
+	err := c.list(c.ProjectURL("/stacks"), &stacks)
+
+	return stacks, err
+}
+
+// GetStack : gets one stack by ID
+func (c *Client) GetStack(ID string) (Stack, error) {
+	var stack Stack
+	err := c.Do(http.MethodGet, c.ProjectURL("/stacks/%s", ID), nil, &stack)
+
+	return stack, err
+}
+
+// ListStackServices : lists the services of a stack
+func (c *Client) ListStackServices(stackID string) ([]Service, error) {
+	var services []Service
+	err := c.list(c.ProjectURL("/stacks/%s/services", stackID), &services)
+
+	return services, err
+}
@@ -0,0 +1,91 @@
+package rancher
+
+// Resource : fields present on every resource of the API
+type Resource struct {
+	ID      string            `json:"id,omitempty"`
+	Type    string            `json:"type,omitempty"`
+	Links   map[string]string `json:"links,omitempty"`
+	Actions map[string]string `json:"actions,omitempty"`
+}
+
+// Project : a Rancher environment
+type Project struct {
+	Resource
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	State         string `json:"state"`
+	Orchestration string `json:"orchestration"`
+}
+
+// Stack : a group of services
+type Stack struct {
+	Resource
+	Name        string   `json:"name"`
+	State       string   `json:"state"`
+	HealthState string   `json:"healthState"`
+	ServiceIDs  []string `json:"serviceIds"`
+}
+
+// LaunchConfig : launch configuration of a service. It is kept as a map so
+// fields the bot doesn't know about survive an upgrade
+type LaunchConfig map[string]interface{}
+
+// ImageUUID : returns the image of the launch config (ex.: docker:ubuntu:14.04)
+func (l LaunchConfig) ImageUUID() string {
+	image, _ := l["imageUuid"].(string)
+	return image
+}
+
+// Service : a Rancher service
+type Service struct {
+	Resource
+	Name         string       `json:"name"`
+	Kind         string       `json:"kind"`
+	State        string       `json:"state"`
+	HealthState  string       `json:"healthState"`
+	StackID      string       `json:"stackId"`
+	Scale        int          `json:"scale"`
+	InstanceIDs  []string     `json:"instanceIds"`
+	LaunchConfig LaunchConfig `json:"launchConfig"`
+	Created      string       `json:"created"`
+}
+
+// Container : an instance of a service (or a standalone container)
+type Container struct {
+	Resource
+	Name        string   `json:"name"`
+	State       string   `json:"state"`
+	HealthState string   `json:"healthState"`
+	ImageUUID   string   `json:"imageUuid"`
+	HostID      string   `json:"hostId"`
+	ServiceIDs  []string `json:"serviceIds"`
+	Created     string   `json:"created"`
+}
+
+// Host : a machine registered on the environment
+type Host struct {
+	Resource
+	Name           string `json:"name"`
+	Hostname       string `json:"hostname"`
+	State          string `json:"state"`
+	AgentState     string `json:"agentState"`
+	AgentIPAddress string `json:"agentIpAddress"`
+}
+
+// LbConfig : configuration of a load balancer service
+type LbConfig struct {
+	Config    string                   `json:"config"`
+	PortRules []map[string]interface{} `json:"portRules"`
+}
+
+// LoadBalancerService : a Rancher load balancer (haproxy)
+type LoadBalancerService struct {
+	Service
+	LbConfig *LbConfig `json:"lbConfig"`
+}
+
+// HostAccess : token and websocket URL returned by actions like logs and execute
+type HostAccess struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
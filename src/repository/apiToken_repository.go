@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"github.com/slack-bot-4all/slack-bot/src/config"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// AddAPIToken : add an APIToken to database
+func AddAPIToken(t *model.APIToken) error {
+	if err := config.DB.Create(t).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveAPIToken :
+func SaveAPIToken(t *model.APIToken) error {
+	if err := config.DB.Save(t).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListAPIToken :
+func ListAPIToken(t *[]model.APIToken) error {
+	if err := config.DB.Order("id desc").Find(t).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindAPITokenByID :
+func FindAPITokenByID(t *model.APIToken, ID uint) error {
+	if err := config.DB.Where("id = ?", ID).First(t).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindAPITokenByKeyID : consults the db with the public part of the token
+func FindAPITokenByKeyID(t *model.APIToken, keyID string) error {
+	if err := config.DB.Where("key_id = ?", keyID).First(t).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"github.com/slack-bot-4all/slack-bot/src/config"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// AddApprovalRequest : add an ApprovalRequest to database
+func AddApprovalRequest(a *model.ApprovalRequest) error {
+	if err := config.DB.Create(a).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveApprovalRequest : updates all fields of the ApprovalRequest
+func SaveApprovalRequest(a *model.ApprovalRequest) error {
+	if err := config.DB.Save(a).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindApprovalRequestByID :
+func FindApprovalRequestByID(a *model.ApprovalRequest, ID uint) error {
+	if err := config.DB.Where("id = ?", ID).First(a).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListApprovalRequestsByStatus :
+func ListApprovalRequestsByStatus(a *[]model.ApprovalRequest, status string) error {
+	if err := config.DB.Where("status = ?", status).Find(a).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddProtectedEnvironment : add a ProtectedEnvironment to database
+func AddProtectedEnvironment(p *model.ProtectedEnvironment) error {
+	if err := config.DB.Create(p).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListProtectedEnvironment :
+func ListProtectedEnvironment(p *[]model.ProtectedEnvironment) error {
+	if err := config.DB.Find(p).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CountProtectedEnvironment : protected environments with the Rancher and project
+func CountProtectedEnvironment(rancherID uint, projectID string) (count int, err error) {
+	err = config.DB.Model(&model.ProtectedEnvironment{}).Where("rancher_id = ? AND project_id = ?", rancherID, projectID).Count(&count).Error
+
+	return count, err
+}
+
+// FindProtectedEnvironmentByID :
+func FindProtectedEnvironmentByID(p *model.ProtectedEnvironment, ID uint) error {
+	if err := config.DB.Where("id = ?", ID).First(p).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteProtectedEnvironment :
+func DeleteProtectedEnvironment(p *model.ProtectedEnvironment) error {
+	if err := config.DB.Where("id = ?", p.ID).Delete(p).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
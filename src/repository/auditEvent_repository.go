@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"github.com/slack-bot-4all/slack-bot/src/config"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// AddAuditEvent : add an AuditEvent to database
+func AddAuditEvent(a *model.AuditEvent) error {
+	if err := config.DB.Create(a).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListAuditEvents : newest first
+func ListAuditEvents(a *[]model.AuditEvent, filter model.AuditFilter) error {
+	query := config.DB.Order("created_at desc")
+
+	if filter.User != "" {
+		query = query.Where("user = ?", filter.User)
+	}
+	if filter.Resource != "" {
+		query = query.Where("resource = ?", filter.Resource)
+	}
+	if filter.Command != "" {
+		query = query.Where("command = ?", filter.Command)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at <= ?", filter.Until)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	if err := query.Find(a).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/slack-bot-4all/slack-bot/src/config"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// AddCanaryRollout : add a CanaryRollout to database
+func AddCanaryRollout(r *model.CanaryRollout) error {
+	if err := config.DB.Create(r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveCanaryRollout : updates all fields of a CanaryRollout
+func SaveCanaryRollout(r *model.CanaryRollout) error {
+	if err := config.DB.Save(r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindCanaryRolloutByID :
+func FindCanaryRolloutByID(r *model.CanaryRollout, ID uint) error {
+	if err := config.DB.Where("id = ?", ID).First(r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListCanaryRolloutsByStatus :
+func ListCanaryRolloutsByStatus(r *[]model.CanaryRollout, status string) error {
+	if err := config.DB.Where("status = ?", status).Find(r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListDueCanaryRollouts : running rollouts where the next step time has come
+func ListDueCanaryRollouts(r *[]model.CanaryRollout, now time.Time) error {
+	if err := config.DB.Where("status = ? AND next_step_at <= ?", model.RolloutRunning, now).Find(r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"github.com/slack-bot-4all/slack-bot/src/config"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// AddExecPolicy : add an ExecPolicy to database
+func AddExecPolicy(p *model.ExecPolicy) error {
+	if err := config.DB.Create(p).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveExecPolicy : updates an ExecPolicy on database
+func SaveExecPolicy(p *model.ExecPolicy) error {
+	if err := config.DB.Save(p).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListExecPolicy :
+func ListExecPolicy(p *[]model.ExecPolicy) error {
+	if err := config.DB.Find(p).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindExecPolicyByID :
+func FindExecPolicyByID(p *model.ExecPolicy, ID uint) error {
+	if err := config.DB.Where("id = ?", ID).First(p).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindExecPolicyByEnvironment : the policy of the Rancher and project
+func FindExecPolicyByEnvironment(p *model.ExecPolicy, rancherID uint, projectID string) error {
+	if err := config.DB.Where("rancher_id = ? AND project_id = ?", rancherID, projectID).First(p).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteExecPolicy :
+func DeleteExecPolicy(p *model.ExecPolicy) error {
+	if err := config.DB.Where("id = ?", p.ID).Delete(p).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"github.com/slack-bot-4all/slack-bot/src/config"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// AddIncident : add an Incident to database
+func AddIncident(i *model.Incident) error {
+	if err := config.DB.Create(i).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveIncident :
+func SaveIncident(i *model.Incident) error {
+	if err := config.DB.Save(i).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindIncidentByID :
+func FindIncidentByID(i *model.Incident, ID uint) error {
+	if err := config.DB.Where("id = ?", ID).First(i).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListIncidents : newest first
+func ListIncidents(i *[]model.Incident, limit int) error {
+	if err := config.DB.Order("opened_at desc").Limit(limit).Find(i).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListIncidentsByStatus : newest first
+func ListIncidentsByStatus(i *[]model.Incident, status []string, limit int) error {
+	if err := config.DB.Where("status in (?)", status).Order("opened_at desc").Limit(limit).Find(i).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
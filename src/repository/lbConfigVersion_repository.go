@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"github.com/slack-bot-4all/slack-bot/src/config"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// AddLbConfigVersion : add a LbConfigVersion to database
+func AddLbConfigVersion(v *model.LbConfigVersion) error {
+	if err := config.DB.Create(v).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListLbConfigVersions : the last versions of a load balancer, newest first
+func ListLbConfigVersions(v *[]model.LbConfigVersion, rancherID uint, projectID string, lbID string, limit int) error {
+	if err := config.DB.Where("rancher_id = ? AND project_id = ? AND load_balancer_id = ?", rancherID, projectID, lbID).Order("version desc").Limit(limit).Find(v).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindLbConfigVersion :
+func FindLbConfigVersion(v *model.LbConfigVersion, rancherID uint, projectID string, lbID string, version int) error {
+	if err := config.DB.Where("rancher_id = ? AND project_id = ? AND load_balancer_id = ? AND version = ?", rancherID, projectID, lbID, version).First(v).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
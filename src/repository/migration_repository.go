@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/slack-bot-4all/slack-bot/src/config"
+)
+
+// LegacyRancherRef : a row that copied the URL (and the keys) of a Rancher,
+// before the tables referenced the Rancher by ID
+type LegacyRancherRef struct {
+	ID               uint
+	RancherURL       string
+	RancherAccessKey string
+	RancherSecretKey string
+}
+
+// HasLegacyRancherRefs : checks if the table still has the copied Rancher URL
+func HasLegacyRancherRefs(table string) bool {
+	return config.DB.Dialect().HasColumn(table, "rancher_url")
+}
+
+// ListLegacyRancherRefs : the rows of the table with the copied Rancher, with
+// the keys when the table had them
+func ListLegacyRancherRefs(table string, withKeys bool, refs *[]LegacyRancherRef) error {
+	columns := "id, rancher_url"
+	if withKeys {
+		columns += ", rancher_access_key, rancher_secret_key"
+	}
+
+	return config.DB.Table(table).Select(columns).Scan(refs).Error
+}
+
+// SetRancherID : points the row of the table to the registered Rancher
+func SetRancherID(table string, ID uint, rancherID uint) error {
+	return config.DB.Table(table).Where("id = ?", ID).UpdateColumn("rancher_id", rancherID).Error
+}
+
+// DropLegacyRancherRefs : removes the copied Rancher columns of the table
+func DropLegacyRancherRefs(table string, withKeys bool) error {
+	columns := []string{"rancher_url"}
+	if withKeys {
+		columns = append(columns, "rancher_access_key", "rancher_secret_key")
+	}
+
+	for _, column := range columns {
+		if err := config.DB.Table(table).DropColumn(column).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RenameLegacyTable : renames a table created by an old version with another
+// name, unless the table with the new name already exists
+func RenameLegacyTable(old string, table string) error {
+	if !config.DB.HasTable(old) || config.DB.HasTable(table) {
+		return nil
+	}
+
+	return config.DB.Exec(fmt.Sprintf("RENAME TABLE `%s` TO `%s`", old, table)).Error
+}
@@ -1,17 +1,26 @@
 package repository
 
 import (
+	"fmt"
+
 	"github.com/slack-bot-4all/slack-bot/src/config"
 	"github.com/slack-bot-4all/slack-bot/src/model"
 )
 
-// AddRancher : add a Rancher to database
+// AddRancher : add a Rancher to database, with the secret key encrypted
 func AddRancher(r *model.Rancher) error {
-	if err := config.DB.Create(r).Error; err != nil {
+	secretKey := r.SecretKey
+
+	encrypted, err := config.Secrets.Encrypt(secretKey)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	r.SecretKey = encrypted
+	err = config.DB.Create(r).Error
+	r.SecretKey = secretKey
+
+	return err
 }
 
 // ListRancher :
@@ -20,14 +29,71 @@ func ListRancher(r *[]model.Rancher) (err error) {
 		return err
 	}
 
+	for i := range *r {
+		if err := decryptRancher(&(*r)[i]); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// FindRancherByID : consults the db with the ID
+func FindRancherByID(r *model.Rancher, ID uint) (err error) {
+	if err := config.DB.Where("id = ?", ID).First(r).Error; err != nil {
+		return err
+	}
+
+	return decryptRancher(r)
+}
+
 // FindRancherByName : consults the db with the name
 func FindRancherByName(r *model.Rancher) (err error) {
 	if err := config.DB.Where("name = ?", r.Name).First(r).Error; err != nil {
 		return err
 	}
 
+	return decryptRancher(r)
+}
+
+// ListRancherSecrets : the ranchers with the secret keys as they are on db
+func ListRancherSecrets(r *[]model.Rancher) error {
+	return config.DB.Find(r).Error
+}
+
+// UpdateRancherSecret : replaces the secret key on db by an already encrypted one
+func UpdateRancherSecret(ID uint, secretKey string) error {
+	return config.DB.Model(&model.Rancher{}).Where("id = ?", ID).UpdateColumn("secret_key", secretKey).Error
+}
+
+func decryptRancher(r *model.Rancher) error {
+	secretKey, err := config.Secrets.Decrypt(r.SecretKey)
+	if err != nil {
+		return fmt.Errorf("error on decrypt the secret key of Rancher %s: %s", r.Name, err)
+	}
+
+	r.SecretKey = secretKey
+
 	return nil
 }
+
+// SaveRancher : updates a Rancher, with the secret key encrypted
+func SaveRancher(r *model.Rancher) error {
+	secretKey := r.SecretKey
+
+	encrypted, err := config.Secrets.Encrypt(secretKey)
+	if err != nil {
+		return err
+	}
+
+	r.SecretKey = encrypted
+	err = config.DB.Save(r).Error
+	r.SecretKey = secretKey
+
+	return err
+}
+
+// DeleteRancher :
+func DeleteRancher(r *model.Rancher) error {
+	return config.DB.Where("id = ?", r.ID).Delete(r).Error
+}
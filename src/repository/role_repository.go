@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"github.com/slack-bot-4all/slack-bot/src/config"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// AddRole : add a Role to database
+func AddRole(r *model.Role) error {
+	if err := config.DB.Create(r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveRole : updates all fields of the Role
+func SaveRole(r *model.Role) error {
+	if err := config.DB.Save(r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListRole :
+func ListRole(r *[]model.Role) error {
+	if err := config.DB.Find(r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindRoleByID :
+func FindRoleByID(r *model.Role, ID uint) error {
+	if err := config.DB.Where("id = ?", ID).First(r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteRole : deletes the Role and its bindings
+func DeleteRole(r *model.Role) error {
+	if err := config.DB.Where("role_id = ?", r.ID).Delete(&model.RoleBinding{}).Error; err != nil {
+		return err
+	}
+
+	if err := config.DB.Where("id = ?", r.ID).Delete(r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddRoleBinding : add a RoleBinding to database
+func AddRoleBinding(b *model.RoleBinding) error {
+	if err := config.DB.Create(b).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveRoleBinding :
+func SaveRoleBinding(b *model.RoleBinding) error {
+	if err := config.DB.Save(b).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListRoleBinding :
+func ListRoleBinding(b *[]model.RoleBinding) error {
+	if err := config.DB.Find(b).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListRoleBindingBySubjects : bindings of the user and of the user groups
+func ListRoleBindingBySubjects(b *[]model.RoleBinding, user string, groups []string) error {
+	query := config.DB.Where("subject_type = ? AND subject = ?", model.RoleSubjectUser, user)
+	if len(groups) > 0 {
+		query = query.Or("subject_type = ? AND subject IN (?)", model.RoleSubjectGroup, groups)
+	}
+
+	if err := query.Find(b).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetAllRanchersOnLegacyRoleBindings : sets AllRanchers on the bindings with RancherID 0
+func SetAllRanchersOnLegacyRoleBindings() (int, error) {
+	query := config.DB.Model(&model.RoleBinding{}).Where("rancher_id = 0").Update("all_ranchers", true)
+	if err := query.Error; err != nil {
+		return 0, err
+	}
+
+	return int(query.RowsAffected), nil
+}
+
+// FindRoleBindingByID :
+func FindRoleBindingByID(b *model.RoleBinding, ID uint) error {
+	if err := config.DB.Where("id = ?", ID).First(b).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteRoleBinding :
+func DeleteRoleBinding(b *model.RoleBinding) error {
+	if err := config.DB.Where("id = ?", b.ID).Delete(b).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/slack-bot-4all/slack-bot/src/config"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// AddScaleLimit : add a ScaleLimit to database
+func AddScaleLimit(l *model.ScaleLimit) error {
+	if err := config.DB.Create(l).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveScaleLimit : updates a ScaleLimit on database
+func SaveScaleLimit(l *model.ScaleLimit) error {
+	if err := config.DB.Save(l).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListScaleLimit :
+func ListScaleLimit(l *[]model.ScaleLimit) error {
+	if err := config.DB.Find(l).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindScaleLimitByID :
+func FindScaleLimitByID(l *model.ScaleLimit, ID uint) error {
+	if err := config.DB.Where("id = ?", ID).First(l).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindScaleLimitByEnvironment : the limit of the Rancher and project
+func FindScaleLimitByEnvironment(l *model.ScaleLimit, rancherID uint, projectID string) error {
+	if err := config.DB.Where("rancher_id = ? AND project_id = ?", rancherID, projectID).First(l).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteScaleLimit :
+func DeleteScaleLimit(l *model.ScaleLimit) error {
+	if err := config.DB.Where("id = ?", l.ID).Delete(l).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddScaleRevert : add a ScaleRevert to database
+func AddScaleRevert(r *model.ScaleRevert) error {
+	if err := config.DB.Create(r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveScaleRevert : updates a ScaleRevert on database
+func SaveScaleRevert(r *model.ScaleRevert) error {
+	if err := config.DB.Save(r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindPendingScaleRevert : the timed scale of the service waiting to be reverted
+func FindPendingScaleRevert(r *model.ScaleRevert, rancherID uint, serviceID string) error {
+	if err := config.DB.Where("rancher_id = ? AND service_id = ? AND status = ?", rancherID, serviceID, model.ScaleRevertPending).First(r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListDueScaleReverts : pending timed scales with RevertAt before now
+func ListDueScaleReverts(r *[]model.ScaleRevert, now time.Time) error {
+	if err := config.DB.Where("status = ? AND revert_at <= ?", model.ScaleRevertPending, now).Find(r).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
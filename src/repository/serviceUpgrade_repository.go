@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"github.com/slack-bot-4all/slack-bot/src/config"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// AddServiceUpgrade : add a ServiceUpgrade to database
+func AddServiceUpgrade(u *model.ServiceUpgrade) error {
+	if err := config.DB.Create(u).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveServiceUpgrade : updates a ServiceUpgrade on database
+func SaveServiceUpgrade(u *model.ServiceUpgrade) error {
+	if err := config.DB.Save(u).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListTrackedServiceUpgrades : upgrades still upgrading or waiting for finish or rollback
+func ListTrackedServiceUpgrades(u *[]model.ServiceUpgrade) error {
+	if err := config.DB.Where("status IN (?)", []string{model.UpgradeUpgrading, model.UpgradeUpgraded}).Find(u).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindTrackedServiceUpgrade : the tracked upgrade of the service
+func FindTrackedServiceUpgrade(u *model.ServiceUpgrade, rancherID uint, serviceID string) error {
+	if err := config.DB.Where("rancher_id = ? AND service_id = ? AND status IN (?)", rancherID, serviceID, []string{model.UpgradeUpgrading, model.UpgradeUpgraded}).First(u).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
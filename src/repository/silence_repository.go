@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"github.com/slack-bot-4all/slack-bot/src/config"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// AddSilence : add a Silence to database
+func AddSilence(s *model.Silence) error {
+	if err := config.DB.Create(s).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListSilence :
+func ListSilence(s *[]model.Silence) error {
+	if err := config.DB.Find(s).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindSilenceByID :
+func FindSilenceByID(s *model.Silence, ID uint) error {
+	if err := config.DB.Where("id = ?", ID).First(s).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteSilence :
+func DeleteSilence(s *model.Silence) error {
+	if err := config.DB.Where("id = ?", s.ID).Delete(s).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
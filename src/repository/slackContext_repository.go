@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"github.com/slack-bot-4all/slack-bot/src/config"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// FindSlackContext : the context of the user in the channel, or in every channel with channel empty
+func FindSlackContext(c *model.SlackContext, user string, channel string) error {
+	if err := config.DB.Where("user = ? AND channel = ?", user, channel).First(c).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveSlackContext : creates or updates the context
+func SaveSlackContext(c *model.SlackContext) error {
+	if err := config.DB.Save(c).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"github.com/slack-bot-4all/slack-bot/src/config"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// FindTaskHealthByTaskID :
+func FindTaskHealthByTaskID(h *model.TaskHealth, taskID uint) error {
+	if err := config.DB.Where("task_id = ?", taskID).First(h).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListTaskHealth :
+func ListTaskHealth(h *[]model.TaskHealth) error {
+	if err := config.DB.Find(h).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveTaskHealth : creates or updates the health of a task
+func SaveTaskHealth(h *model.TaskHealth) error {
+	if err := config.DB.Save(h).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteTaskHealthByTaskID :
+func DeleteTaskHealthByTaskID(taskID uint) error {
+	if err := config.DB.Unscoped().Where("task_id = ?", taskID).Delete(&model.TaskHealth{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
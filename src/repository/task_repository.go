@@ -31,3 +31,27 @@ func DeleteTask(t *model.Task) (err error) {
 
 	return nil
 }
+
+// FindTaskByID : consults the db with the ID
+func FindTaskByID(t *model.Task, ID uint) error {
+	if err := config.DB.Where("id = ?", ID).First(t).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveTask :
+func SaveTask(t *model.Task) error {
+	if err := config.DB.Save(t).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CountTasksByRancher : tasks that check services of the Rancher
+func CountTasksByRancher(rancherID uint) (count int, err error) {
+	err = config.DB.Model(&model.Task{}).Where("rancher_id = ?", rancherID).Count(&count).Error
+	return count, err
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/slack-bot-4all/slack-bot/src/config"
 	"github.com/slack-bot-4all/slack-bot/src/model"
 )
@@ -27,3 +29,54 @@ func FindUserByUsername(u *model.User) (err error) {
 
 	return nil
 }
+
+// ListUser :
+func ListUser(u *[]model.User) error {
+	if err := config.DB.Find(u).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindUserByID : consults the db with the ID
+func FindUserByID(u *model.User, ID uint) error {
+	if err := config.DB.Where("id = ?", ID).First(u).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CountUsers :
+func CountUsers() (count int, err error) {
+	err = config.DB.Model(&model.User{}).Count(&count).Error
+	return count, err
+}
+
+// DeleteUser : deletes the user and revokes its API tokens, in one transaction
+func DeleteUser(u *model.User, revokedAt time.Time) error {
+	tx := config.DB.Begin()
+
+	if err := tx.Model(&model.APIToken{}).Where("user = ? AND revoked_at IS NULL", u.Username).Update("revoked_at", revokedAt).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Where("id = ?", u.ID).Delete(u).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// UpdateUserPassword : saves the hash of the new password of the user
+func UpdateUserPassword(u *model.User, password string) (err error) {
+	var hash config.Hash
+	if u.Password, err = hash.Generate(password); err != nil {
+		return err
+	}
+
+	return config.DB.Model(u).UpdateColumn("password", u.Password).Error
+}
@@ -0,0 +1,87 @@
+package resource
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+// CreatedAPIToken : a new API token, the only time the token is returned
+type CreatedAPIToken struct {
+	Token    string         `json:"token"`
+	APIToken model.APIToken `json:"apiToken"`
+}
+
+// AddAPIToken : creates an API token of the logged user
+// @Summary Create an API token of the logged user
+// @Description The token is returned only on this response, send it as "Authorization: Bearer jrm_...". Scopes: read, write and commands
+// @Tags tokens
+// @Accept json
+// @Produce json
+// @Param token body model.APIToken true "Name, comma separated scopes and optional expiresAt"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /tokens [post]
+func AddAPIToken(c *gin.Context) {
+	var t model.APIToken
+	if err := c.BindJSON(&t); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	t.User = loggedUser(c)
+	t.LastUsedAt, t.RevokedAt = nil, nil
+
+	token, err := service.CreateAPIToken(&t)
+	if err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, CreatedAPIToken{Token: token, APIToken: t})
+}
+
+// ListAPITokens : list all API tokens, without the tokens
+// @Summary List the API tokens
+// @Tags tokens
+// @Produce json
+// @Success 200 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /tokens [get]
+func ListAPITokens(c *gin.Context) {
+	tokens, err := service.ListAPITokens()
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, tokens)
+}
+
+// RevokeAPIToken : the token stops being accepted
+// @Summary Revoke an API token
+// @Tags tokens
+// @Produce json
+// @Param id path int true "API token ID"
+// @Success 200 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /tokens/{id} [delete]
+func RevokeAPIToken(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	token, err := service.RevokeAPIToken(uint(ID))
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, token)
+}
@@ -0,0 +1,131 @@
+package resource
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+// defaultAuditLimit : audit events returned when the limit is not informed
+const defaultAuditLimit = 100
+
+// auditCSVHeader : columns of the CSV export of the audit events
+var auditCSVHeader = []string{"id", "createdAt", "user", "channel", "command", "text", "args", "rancherId", "projectId", "resource", "requests", "status", "outcome", "detail"}
+
+// ListAuditEvents : list the last audit events, filtered by ?user=, ?resource=,
+// ?command=, ?outcome=, ?since= and ?until= (RFC 3339, or a duration like 24h
+// before now). With ?format=csv or ?format=json the events are exported as a file
+// @Summary List or export the audit events
+// @Tags audit
+// @Produce json
+// @Produce text/csv
+// @Param user query string false "Slack user ID, or api:username"
+// @Param resource query string false "Target resource, like stack/service or a load balancer ID"
+// @Param command query string false "Command name"
+// @Param outcome query string false "success, error, denied, invalid or pending_approval"
+// @Param since query string false "RFC 3339 time or a duration before now, like 24h"
+// @Param until query string false "RFC 3339 time or a duration before now"
+// @Param limit query int false "Maximum of events"
+// @Param format query string false "csv or json to export as a file"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /audit [get]
+func ListAuditEvents(c *gin.Context) {
+	filter := model.AuditFilter{
+		User:     c.Query("user"),
+		Resource: c.Query("resource"),
+		Command:  c.Query("command"),
+		Outcome:  c.Query("outcome"),
+	}
+
+	var err error
+	if filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultAuditLimit))); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if filter.Since, err = parseAuditTime(c.Query("since")); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	if filter.Until, err = parseAuditTime(c.Query("until")); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	events, err := service.ListAuditEvents(filter)
+	if err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	if events == nil {
+		events = []model.AuditEvent{}
+	}
+
+	switch c.Query("format") {
+	case "":
+		ResponseJSON(c, 200, events)
+	case "json":
+		c.Header("Content-Disposition", "attachment; filename=audit.json")
+		c.JSON(200, events)
+	case "csv":
+		c.Header("Content-Disposition", "attachment; filename=audit.csv")
+		c.Header("Content-Type", "text/csv")
+		c.Status(200)
+		writeAuditCSV(c, events)
+	default:
+		ResponseJSON(c, 400, "invalid format, use csv or json")
+	}
+}
+
+// parseAuditTime : an RFC 3339 time or a duration before now, zero when empty
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, fmt.Errorf("invalid time `%s`, use RFC 3339 (2006-01-02T15:04:05Z) or a duration like 24h", value)
+	}
+
+	return t, nil
+}
+
+func writeAuditCSV(c *gin.Context, events []model.AuditEvent) {
+	w := csv.NewWriter(c.Writer)
+
+	w.Write(auditCSVHeader)
+	for _, event := range events {
+		w.Write([]string{
+			strconv.Itoa(int(event.ID)),
+			event.CreatedAt.Format(time.RFC3339),
+			event.User,
+			event.Channel,
+			event.Command,
+			event.Text,
+			event.Args,
+			strconv.Itoa(int(event.RancherID)),
+			event.ProjectID,
+			event.Resource,
+			event.Requests,
+			strconv.Itoa(event.Status),
+			event.Outcome,
+			event.Detail,
+		})
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,45 @@
+package resource
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+)
+
+// CommandExecutor : runs the commands of the BOT for the API, implemented by
+// the Slack listener of the core
+type CommandExecutor interface {
+	ExecuteCommand(name string, user string, execution model.CommandExecution) (model.CommandResult, error)
+}
+
+// ExecuteCommand : runs a command of the BOT as the logged API user (api:username),
+// with the same permissions, approvals and audit of the commands called on Slack
+// @Summary Execute a command of the BOT
+// @Description The replies of the command are returned instead of posted on Slack. Restricted commands need a role binding to the user api:username
+// @Tags commands
+// @Accept json
+// @Produce json
+// @Param name path string true "Command name, like service-list"
+// @Param execution body model.CommandExecution false "Arguments of the command"
+// @Success 200 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /commands/{name}/execute [post]
+func ExecuteCommand(executor CommandExecutor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var execution model.CommandExecution
+		if c.Request.ContentLength > 0 {
+			if err := c.BindJSON(&execution); err != nil {
+				ResponseJSON(c, 400, nil)
+				return
+			}
+		}
+
+		result, err := executor.ExecuteCommand(c.Param("name"), loggedUser(c), execution)
+		if err != nil {
+			ResponseJSON(c, 404, err.Error())
+			return
+		}
+
+		ResponseJSON(c, 200, result)
+	}
+}
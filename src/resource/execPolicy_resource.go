@@ -0,0 +1,115 @@
+package resource
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+// AddExecPolicy : allows container-exec on the environment, only with the
+// programs of the policy
+// @Summary Allow container-exec on an environment
+// @Tags exec-policies
+// @Accept json
+// @Produce json
+// @Param policy body model.ExecPolicy true "Environment, allowed programs and timeout"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /exec-policies [post]
+func AddExecPolicy(c *gin.Context) {
+	var p model.ExecPolicy
+	if err := c.BindJSON(&p); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if err := service.AddExecPolicy(&p); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, p)
+}
+
+// UpdateExecPolicy : changes the allowed programs and the timeout of an ExecPolicy
+// @Summary Update an exec policy
+// @Tags exec-policies
+// @Accept json
+// @Produce json
+// @Param id path int true "Exec policy ID"
+// @Param policy body model.ExecPolicy true "Allowed programs and timeout"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /exec-policies/{id} [put]
+func UpdateExecPolicy(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	var p model.ExecPolicy
+	if err := c.BindJSON(&p); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if _, err := service.FindExecPolicy(uint(ID)); err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	if err := service.UpdateExecPolicy(uint(ID), &p); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, p)
+}
+
+// ListExecPolicies : list all exec policies
+// @Summary List the exec policies
+// @Tags exec-policies
+// @Produce json
+// @Success 200 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /exec-policies [get]
+func ListExecPolicies(c *gin.Context) {
+	policies, err := service.ListExecPolicies()
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, policies)
+}
+
+// DeleteExecPolicy : container-exec stops being allowed on the environment
+// @Summary Delete an exec policy
+// @Tags exec-policies
+// @Produce json
+// @Param id path int true "Exec policy ID"
+// @Success 200 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /exec-policies/{id} [delete]
+func DeleteExecPolicy(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	policy, err := service.DeleteExecPolicy(uint(ID))
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, policy)
+}
@@ -2,6 +2,7 @@ package resource
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/slack-bot-4all/slack-bot/src/model"
 )
 
 // Response : Struct default to responses
@@ -28,3 +29,14 @@ func ResponseJSON(w *gin.Context, status int, payload interface{}) {
 
 	w.JSON(status, resp)
 }
+
+// loggedUser : username of the logged user or of the owner of the API token
+func loggedUser(c *gin.Context) string {
+	if user, ok := c.Get("user"); ok {
+		if u, ok := user.(*model.User); ok {
+			return u.Username
+		}
+	}
+
+	return ""
+}
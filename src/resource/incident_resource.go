@@ -0,0 +1,68 @@
+package resource
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+// defaultIncidentsLimit : incidents returned when the limit is not informed
+const defaultIncidentsLimit = 100
+
+// ListIncidents : list the last incidents, filtered by ?status=open|acknowledged|resolved|active,
+// with the time to acknowledge and to resolve
+// @Summary List the incidents of the tasks
+// @Tags incidents
+// @Produce json
+// @Param status query string false "open, acknowledged, resolved or active"
+// @Param limit query int false "Maximum of incidents"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /incidents [get]
+func ListIncidents(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultIncidentsLimit)))
+	if err != nil || limit <= 0 {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	incidents, err := service.ListIncidents(c.Query("status"), limit)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	reports := []service.IncidentReport{}
+	for _, incident := range incidents {
+		reports = append(reports, service.NewIncidentReport(incident))
+	}
+
+	ResponseJSON(c, 200, reports)
+}
+
+// GetIncident : an incident with the time to acknowledge and to resolve
+// @Summary Get an incident
+// @Tags incidents
+// @Produce json
+// @Param id path int true "Incident ID"
+// @Success 200 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /incidents/{id} [get]
+func GetIncident(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	incident, err := service.FindIncident(uint(ID))
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, service.NewIncidentReport(incident))
+}
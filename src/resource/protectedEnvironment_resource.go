@@ -0,0 +1,77 @@
+package resource
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+// AddProtectedEnvironment : the commands that change services on the environment
+// start needing the approval of a second user
+// @Summary Protect an environment
+// @Tags protected-environments
+// @Accept json
+// @Produce json
+// @Param environment body model.ProtectedEnvironment true "Rancher ID and project ID of the environment"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /protected-environments [post]
+func AddProtectedEnvironment(c *gin.Context) {
+	var p model.ProtectedEnvironment
+	if err := c.BindJSON(&p); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if err := service.AddProtectedEnvironment(&p); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, p)
+}
+
+// ListProtectedEnvironments : list all protected environments
+// @Summary List the protected environments
+// @Tags protected-environments
+// @Produce json
+// @Success 200 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /protected-environments [get]
+func ListProtectedEnvironments(c *gin.Context) {
+	environments, err := service.ListProtectedEnvironments()
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, environments)
+}
+
+// DeleteProtectedEnvironment : the environment stops needing approvals
+// @Summary Unprotect an environment
+// @Tags protected-environments
+// @Produce json
+// @Param id path int true "Protected environment ID"
+// @Success 200 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /protected-environments/{id} [delete]
+func DeleteProtectedEnvironment(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	environment, err := service.DeleteProtectedEnvironment(uint(ID))
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, environment)
+}
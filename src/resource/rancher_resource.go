@@ -1,31 +1,164 @@
 package resource
 
 import (
+	"strconv"
+
 	"github.com/gin-gonic/gin"
 	"github.com/slack-bot-4all/slack-bot/src/model"
 	"github.com/slack-bot-4all/slack-bot/src/service"
 )
 
+// redactedSecret : replaces the secret keys of the Ranchers on the responses
+const redactedSecret = "********"
+
 // AddRancher : add a new Rancher to db
+// @Summary Register a Rancher
+// @Tags ranchers
+// @Accept json
+// @Produce json
+// @Param rancher body model.Rancher true "Rancher, with type rancher (1.6) or kubernetes"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /ranchers [post]
 func AddRancher(c *gin.Context) {
 	var r model.Rancher
-	c.BindJSON(&r)
-
-	err := service.AddRancher(&r)
-	if err != nil {
+	if err := c.BindJSON(&r); err != nil {
 		ResponseJSON(c, 400, nil)
-	} else {
-		ResponseJSON(c, 200, r)
+		return
 	}
+
+	if err := service.AddRancher(&r); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, redactRancher(r))
 }
 
 // ListRancher : list all ranchers
+// @Summary List the Ranchers, with the secret keys redacted
+// @Tags ranchers
+// @Produce json
+// @Success 200 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /ranchers [get]
 func ListRancher(c *gin.Context) {
 	ranchers, err := service.ListRancher()
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	for i := range ranchers {
+		ranchers[i] = redactRancher(ranchers[i])
+	}
+
+	ResponseJSON(c, 200, ranchers)
+}
+
+// GetRancher : a Rancher, with the secret key redacted
+// @Summary Get a Rancher, with the secret key redacted
+// @Tags ranchers
+// @Produce json
+// @Param id path int true "Rancher ID"
+// @Success 200 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /ranchers/{id} [get]
+func GetRancher(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	rancher, err := service.FindRancher(uint(ID))
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, redactRancher(rancher))
+}
+
+// UpdateRancher : changes a Rancher, the secret key is kept when it is not sent
+// @Summary Update a Rancher, keeping the secret key when it is empty
+// @Tags ranchers
+// @Accept json
+// @Produce json
+// @Param id path int true "Rancher ID"
+// @Param rancher body model.Rancher true "Rancher"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /ranchers/{id} [put]
+func UpdateRancher(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	var r model.Rancher
+	if err := c.BindJSON(&r); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if r.SecretKey == redactedSecret {
+		r.SecretKey = ""
+	}
+
+	if _, err := service.FindRancher(uint(ID)); err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	if err := service.UpdateRancher(uint(ID), &r); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
 
+	ResponseJSON(c, 200, redactRancher(r))
+}
+
+// DeleteRancher : removes a Rancher without tasks nor canary rollouts running
+// @Summary Delete a Rancher that has no tasks nor canary rollouts running
+// @Tags ranchers
+// @Produce json
+// @Param id path int true "Rancher ID"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /ranchers/{id} [delete]
+func DeleteRancher(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if _, err := service.FindRancher(uint(ID)); err != nil {
 		ResponseJSON(c, 404, nil)
-	} else {
-		ResponseJSON(c, 200, ranchers)
+		return
 	}
+
+	rancher, err := service.DeleteRancher(uint(ID))
+	if err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, redactRancher(rancher))
+}
+
+func redactRancher(r model.Rancher) model.Rancher {
+	if r.SecretKey != "" {
+		r.SecretKey = redactedSecret
+	}
+
+	return r
 }
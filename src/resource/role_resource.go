@@ -0,0 +1,219 @@
+package resource
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+// AddRole : add a new Role to db
+// @Summary Create a role
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param role body model.Role true "Role, with comma separated commands or *"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /roles [post]
+func AddRole(c *gin.Context) {
+	var r model.Role
+	if err := c.BindJSON(&r); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if err := service.AddRole(&r); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, r)
+}
+
+// UpdateRole : changes the name, description and commands of a Role
+// @Summary Update a role
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path int true "Role ID"
+// @Param role body model.Role true "Role"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /roles/{id} [put]
+func UpdateRole(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	var r model.Role
+	if err := c.BindJSON(&r); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if _, err := service.FindRole(uint(ID)); err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	if err := service.UpdateRole(uint(ID), &r); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, r)
+}
+
+// ListRoles : list all roles
+// @Summary List the roles
+// @Tags roles
+// @Produce json
+// @Success 200 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /roles [get]
+func ListRoles(c *gin.Context) {
+	roles, err := service.ListRoles()
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, roles)
+}
+
+// DeleteRole : removes a Role and its bindings
+// @Summary Delete a role and its bindings
+// @Tags roles
+// @Produce json
+// @Param id path int true "Role ID"
+// @Success 200 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /roles/{id} [delete]
+func DeleteRole(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	role, err := service.DeleteRole(uint(ID))
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, role)
+}
+
+// AddRoleBinding : gives a Role to a Slack user, user group or API user (api:username)
+// @Summary Give a role to a Slack user, user group or API user
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param binding body model.RoleBinding true "Role binding, allRanchers true and empty projectId are any Rancher and environment, rancherId 0 is the Rancher of the environment variables"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /role-bindings [post]
+func AddRoleBinding(c *gin.Context) {
+	var b model.RoleBinding
+	if err := c.BindJSON(&b); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if err := service.AddRoleBinding(&b); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, b)
+}
+
+// UpdateRoleBinding : changes the role, subject and scope of a role binding
+// @Summary Update a role binding
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path int true "Role binding ID"
+// @Param binding body model.RoleBinding true "Role binding"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /role-bindings/{id} [put]
+func UpdateRoleBinding(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	var b model.RoleBinding
+	if err := c.BindJSON(&b); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if _, err := service.FindRoleBinding(uint(ID)); err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	if err := service.UpdateRoleBinding(uint(ID), &b); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, b)
+}
+
+// ListRoleBindings : list all role bindings
+// @Summary List the role bindings
+// @Tags roles
+// @Produce json
+// @Success 200 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /role-bindings [get]
+func ListRoleBindings(c *gin.Context) {
+	bindings, err := service.ListRoleBindings()
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, bindings)
+}
+
+// DeleteRoleBinding : removes a role binding
+// @Summary Delete a role binding
+// @Tags roles
+// @Produce json
+// @Param id path int true "Role binding ID"
+// @Success 200 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /role-bindings/{id} [delete]
+func DeleteRoleBinding(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	binding, err := service.DeleteRoleBinding(uint(ID))
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, binding)
+}
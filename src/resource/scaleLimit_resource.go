@@ -0,0 +1,115 @@
+package resource
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+// AddScaleLimit : the minimum and maximum scale that service-scale accepts on
+// the services of the environment
+// @Summary Limit the scale of the services of an environment
+// @Tags scale-limits
+// @Accept json
+// @Produce json
+// @Param limit body model.ScaleLimit true "Environment, minimum and maximum scale"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /scale-limits [post]
+func AddScaleLimit(c *gin.Context) {
+	var l model.ScaleLimit
+	if err := c.BindJSON(&l); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if err := service.AddScaleLimit(&l); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, l)
+}
+
+// UpdateScaleLimit : changes the minimum and maximum of a ScaleLimit
+// @Summary Update a scale limit
+// @Tags scale-limits
+// @Accept json
+// @Produce json
+// @Param id path int true "Scale limit ID"
+// @Param limit body model.ScaleLimit true "Minimum and maximum scale"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /scale-limits/{id} [put]
+func UpdateScaleLimit(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	var l model.ScaleLimit
+	if err := c.BindJSON(&l); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if _, err := service.FindScaleLimit(uint(ID)); err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	if err := service.UpdateScaleLimit(uint(ID), &l); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, l)
+}
+
+// ListScaleLimits : list all scale limits
+// @Summary List the scale limits
+// @Tags scale-limits
+// @Produce json
+// @Success 200 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /scale-limits [get]
+func ListScaleLimits(c *gin.Context) {
+	limits, err := service.ListScaleLimits()
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, limits)
+}
+
+// DeleteScaleLimit : the environment goes back to the default limits
+// @Summary Delete a scale limit
+// @Tags scale-limits
+// @Produce json
+// @Param id path int true "Scale limit ID"
+// @Success 200 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /scale-limits/{id} [delete]
+func DeleteScaleLimit(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	limit, err := service.DeleteScaleLimit(uint(ID))
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, limit)
+}
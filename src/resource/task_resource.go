@@ -0,0 +1,174 @@
+package resource
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+// TaskStatus : a task with the state of its health check, nil while it was not checked
+type TaskStatus struct {
+	model.Task
+	Health *model.TaskHealth `json:"health"`
+}
+
+// AddTask : add a new Task to db, checked on the next reload of the scheduler
+// @Summary Create a task, of self-healing or only check (isOnlyCheck)
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param task body model.Task true "Task, rancherId 0 is the Rancher of the environment variables"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /tasks [post]
+func AddTask(c *gin.Context) {
+	var t model.Task
+	if err := c.BindJSON(&t); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if err := service.AddTask(&t); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, t)
+}
+
+// ListTasks : list all tasks with their health state
+// @Summary List the tasks with the state of their health checks
+// @Tags tasks
+// @Produce json
+// @Success 200 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /tasks [get]
+func ListTasks(c *gin.Context) {
+	tasks, err := service.ListTask()
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	health, err := service.ListTaskHealth()
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	statuses := []TaskStatus{}
+	for _, task := range tasks {
+		status := TaskStatus{Task: task}
+		if h, ok := health[task.ID]; ok {
+			status.Health = &h
+		}
+		statuses = append(statuses, status)
+	}
+
+	ResponseJSON(c, 200, statuses)
+}
+
+// GetTask : a task with its health state
+// @Summary Get a task with the state of its health check
+// @Tags tasks
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /tasks/{id} [get]
+func GetTask(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	task, err := service.FindTask(uint(ID))
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	status := TaskStatus{Task: task}
+	if health, err := service.GetTaskHealth(task.ID); err == nil && health.ID != 0 {
+		status.Health = &health
+	}
+
+	ResponseJSON(c, 200, status)
+}
+
+// PauseTask : stops the checks of a task until it is resumed
+// @Summary Pause the checks of a task
+// @Tags tasks
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /tasks/{id}/pause [post]
+func PauseTask(c *gin.Context) {
+	setTaskPaused(c, true)
+}
+
+// ResumeTask : starts again the checks of a paused task
+// @Summary Resume the checks of a paused task
+// @Tags tasks
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /tasks/{id}/resume [post]
+func ResumeTask(c *gin.Context) {
+	setTaskPaused(c, false)
+}
+
+func setTaskPaused(c *gin.Context, paused bool) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	task, err := service.PauseTask(uint(ID), paused)
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, task)
+}
+
+// DeleteTask : removes a task and its health state
+// @Summary Delete a task
+// @Tags tasks
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /tasks/{id} [delete]
+func DeleteTask(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	task, err := service.FindTask(uint(ID))
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	if err := service.DeleteTask(task); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, task)
+}
@@ -0,0 +1,186 @@
+package resource
+
+import (
+	"crypto/subtle"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/service"
+)
+
+// AddUser : add a new API user to db
+// @Summary Create an API user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body model.User true "Username and password"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /users [post]
+func AddUser(c *gin.Context) {
+	var u model.User
+	if err := c.BindJSON(&u); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if err := service.AddUser(&u); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, u)
+}
+
+// ListUsers : list all API users, without the passwords
+// @Summary List the API users
+// @Tags users
+// @Produce json
+// @Success 200 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /users [get]
+func ListUsers(c *gin.Context) {
+	users, err := service.ListUsers()
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	ResponseJSON(c, 200, users)
+}
+
+// DeleteUser : removes an API user, except the last one
+// @Summary Delete an API user, except the last one
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /users/{id} [delete]
+func DeleteUser(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if _, err := service.FindUser(uint(ID)); err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	user, err := service.DeleteUser(uint(ID))
+	if err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, user)
+}
+
+// SetUserPassword : replaces the password of a user, like when it was forgotten
+// @Summary Set the password of a user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param password body model.PasswordChange true "Only newPassword is used"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /users/{id}/password [put]
+func SetUserPassword(c *gin.Context) {
+	ID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	var p model.PasswordChange
+	if err := c.BindJSON(&p); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	user, err := service.FindUser(uint(ID))
+	if err != nil {
+		ResponseJSON(c, 404, nil)
+		return
+	}
+
+	if err := service.SetPassword(user.ID, p.NewPassword); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, user)
+}
+
+// ChangePassword : changes the password of the logged user
+// @Summary Change the password of the logged user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param password body model.PasswordChange true "Current and new password"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Security ApiKeyAuth
+// @Router /account/password [put]
+func ChangePassword(c *gin.Context) {
+	var p model.PasswordChange
+	if err := c.BindJSON(&p); err != nil {
+		ResponseJSON(c, 400, nil)
+		return
+	}
+
+	if err := service.ChangePassword(loggedUser(c), p.CurrentPassword, p.NewPassword); err != nil {
+		ResponseJSON(c, 400, err.Error())
+		return
+	}
+
+	ResponseJSON(c, 200, nil)
+}
+
+// Bootstrap : creates the first user with the bootstrap token logged by the
+// BOT, while there is no user
+// @Summary Create the first user of the API
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param bootstrap body model.Bootstrap true "Bootstrap token, username and password"
+// @Success 200 {object} resource.Response
+// @Failure 400 {object} resource.Response
+// @Failure 404 {object} resource.Response
+// @Router /auth/bootstrap [post]
+func Bootstrap(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var b model.Bootstrap
+		if err := c.BindJSON(&b); err != nil {
+			ResponseJSON(c, 400, nil)
+			return
+		}
+
+		if token == "" {
+			ResponseJSON(c, 404, "bootstrap is disabled, the API already has users")
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(b.Token), []byte(token)) != 1 {
+			ResponseJSON(c, 400, "invalid bootstrap token")
+			return
+		}
+
+		u := model.User{Username: b.Username, Password: b.Password}
+		if err := service.BootstrapUser(&u); err != nil {
+			ResponseJSON(c, 400, err.Error())
+			return
+		}
+
+		ResponseJSON(c, 200, u)
+	}
+}
@@ -2,6 +2,8 @@ package routes
 
 import (
 	"log"
+	"net/http"
+	"strings"
 	"time"
 
 	jwt "github.com/appleboy/gin-jwt"
@@ -11,6 +13,7 @@ import (
 	"github.com/slack-bot-4all/slack-bot/src/model"
 	"github.com/slack-bot-4all/slack-bot/src/repository"
 	"github.com/slack-bot-4all/slack-bot/src/resource"
+	"github.com/slack-bot-4all/slack-bot/src/service"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"github.com/swaggo/gin-swagger/swaggerFiles"
 )
@@ -21,15 +24,29 @@ type ErrorMap struct {
 	Message string `json:"message"`
 }
 
-// GetRoutes : function to map all security and routes permissions
-func GetRoutes() *gin.Engine {
+// AuthConfig : signing of the JWT of the logged users and the bootstrap of the API
+type AuthConfig struct {
+	// Secret : key that signs the JWT
+	Secret []byte
+
+	// Timeout : how long a JWT is valid, and can be refreshed
+	Timeout time.Duration
+
+	// BootstrapToken : allows to create the first user on /auth/bootstrap,
+	// empty when there are users
+	BootstrapToken string
+}
+
+// GetRoutes : function to map all security and routes permissions, the
+// commands of the BOT are executed on /v1/commands by the executor
+func GetRoutes(executor resource.CommandExecutor, auth AuthConfig) *gin.Engine {
 	r := gin.Default()
 
 	authMiddleware, err := jwt.New(&jwt.GinJWTMiddleware{
-		Realm:       "test zone",
-		Key:         []byte("jeremyBOT-github"),
-		Timeout:     time.Hour,
-		MaxRefresh:  time.Hour,
+		Realm:       "jeremias",
+		Key:         auth.Secret,
+		Timeout:     auth.Timeout,
+		MaxRefresh:  auth.Timeout,
 		IdentityKey: "user",
 		PayloadFunc: func(data interface{}) jwt.MapClaims {
 			if v, ok := data.(*model.User); ok {
@@ -66,13 +83,14 @@ func GetRoutes() *gin.Engine {
 		authGroup := r.Group("/auth")
 		authGroup.POST("/login", authMiddleware.LoginHandler)
 		authGroup.GET("/refresh_token", authMiddleware.RefreshHandler)
+		authGroup.POST("/bootstrap", resource.Bootstrap(auth.BootstrapToken))
 	}
 
 	// v1 Group
 	v1 := r.Group("/v1")
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	v1.Use(authMiddleware.MiddlewareFunc())
+	v1.Use(authenticate(authMiddleware.MiddlewareFunc()))
 
 	docs.SwaggerInfo.Title = "Swagger Jeremias API"
 	docs.SwaggerInfo.Description = "This is a sample server."
@@ -81,11 +99,30 @@ func GetRoutes() *gin.Engine {
 	docs.SwaggerInfo.BasePath = "/v1"
 
 	// Users Group
-	// {
-	// 	usersGroup := v1.Group("/users")
+	{
+		usersGroup := v1.Group("/users")
+
+		usersGroup.GET("/", resource.ListUsers)
+		usersGroup.POST("/", resource.AddUser)
+		usersGroup.DELETE("/:id", resource.DeleteUser)
+		usersGroup.PUT("/:id/password", resource.SetUserPassword)
+	}
 
-	// 	usersGroup.GET("/", resource.ListUsers)
-	// }
+	// Account Group
+	{
+		accountGroup := v1.Group("/account")
+
+		accountGroup.PUT("/password", resource.ChangePassword)
+	}
+
+	// API Tokens Group
+	{
+		tokensGroup := v1.Group("/tokens")
+
+		tokensGroup.GET("/", resource.ListAPITokens)
+		tokensGroup.POST("/", resource.AddAPIToken)
+		tokensGroup.DELETE("/:id", resource.RevokeAPIToken)
+	}
 
 	// Ranchers Group
 	{
@@ -93,11 +130,123 @@ func GetRoutes() *gin.Engine {
 
 		ranchersGroup.GET("/", resource.ListRancher)
 		ranchersGroup.POST("/", resource.AddRancher)
+		ranchersGroup.GET("/:id", resource.GetRancher)
+		ranchersGroup.PUT("/:id", resource.UpdateRancher)
+		ranchersGroup.DELETE("/:id", resource.DeleteRancher)
+	}
+
+	// Tasks Group
+	{
+		tasksGroup := v1.Group("/tasks")
+
+		tasksGroup.GET("/", resource.ListTasks)
+		tasksGroup.POST("/", resource.AddTask)
+		tasksGroup.GET("/:id", resource.GetTask)
+		tasksGroup.POST("/:id/pause", resource.PauseTask)
+		tasksGroup.POST("/:id/resume", resource.ResumeTask)
+		tasksGroup.DELETE("/:id", resource.DeleteTask)
+	}
+
+	// Commands Group
+	{
+		commandsGroup := v1.Group("/commands")
+
+		commandsGroup.POST("/:name/execute", resource.ExecuteCommand(executor))
+	}
+
+	// Incidents Group
+	{
+		incidentsGroup := v1.Group("/incidents")
+
+		incidentsGroup.GET("/", resource.ListIncidents)
+		incidentsGroup.GET("/:id", resource.GetIncident)
+	}
+
+	// Audit Group
+	{
+		auditGroup := v1.Group("/audit")
+
+		auditGroup.GET("/", resource.ListAuditEvents)
+	}
+
+	// Roles Group
+	{
+		rolesGroup := v1.Group("/roles")
+
+		rolesGroup.GET("/", resource.ListRoles)
+		rolesGroup.POST("/", resource.AddRole)
+		rolesGroup.PUT("/:id", resource.UpdateRole)
+		rolesGroup.DELETE("/:id", resource.DeleteRole)
+	}
+
+	// Role Bindings Group
+	{
+		roleBindingsGroup := v1.Group("/role-bindings")
+
+		roleBindingsGroup.GET("/", resource.ListRoleBindings)
+		roleBindingsGroup.POST("/", resource.AddRoleBinding)
+		roleBindingsGroup.PUT("/:id", resource.UpdateRoleBinding)
+		roleBindingsGroup.DELETE("/:id", resource.DeleteRoleBinding)
+	}
+
+	// Protected Environments Group
+	{
+		protectedEnvironmentsGroup := v1.Group("/protected-environments")
+
+		protectedEnvironmentsGroup.GET("/", resource.ListProtectedEnvironments)
+		protectedEnvironmentsGroup.POST("/", resource.AddProtectedEnvironment)
+		protectedEnvironmentsGroup.DELETE("/:id", resource.DeleteProtectedEnvironment)
+	}
+
+	// Exec Policies Group
+	{
+		execPoliciesGroup := v1.Group("/exec-policies")
+
+		execPoliciesGroup.GET("/", resource.ListExecPolicies)
+		execPoliciesGroup.POST("/", resource.AddExecPolicy)
+		execPoliciesGroup.PUT("/:id", resource.UpdateExecPolicy)
+		execPoliciesGroup.DELETE("/:id", resource.DeleteExecPolicy)
+	}
+
+	// Scale Limits Group
+	{
+		scaleLimitsGroup := v1.Group("/scale-limits")
+
+		scaleLimitsGroup.GET("/", resource.ListScaleLimits)
+		scaleLimitsGroup.POST("/", resource.AddScaleLimit)
+		scaleLimitsGroup.PUT("/:id", resource.UpdateScaleLimit)
+		scaleLimitsGroup.DELETE("/:id", resource.DeleteScaleLimit)
 	}
 
 	return r
 }
 
+// authenticate : accepts the API tokens (Bearer jrm_...) when their scopes
+// allow the request, or the JWT of the logged users
+func authenticate(jwtMiddleware gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !strings.HasPrefix(value, model.APITokenPrefix) {
+			jwtMiddleware(c)
+			return
+		}
+
+		token, err := service.AuthenticateAPIToken(value)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorMap{Code: http.StatusUnauthorized, Message: err.Error()})
+			return
+		}
+
+		if !service.APITokenAllows(token, c.Request.Method, c.Request.URL.Path) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorMap{Code: http.StatusForbidden, Message: "the scopes of the token don't allow this request"})
+			return
+		}
+
+		c.Set("user", &model.User{Username: token.User})
+		c.Next()
+	}
+}
+
 // Authenticator ::
 func Authenticator(c *gin.Context) (interface{}, error) {
 	var userLogin model.User
@@ -106,7 +255,6 @@ func Authenticator(c *gin.Context) (interface{}, error) {
 	}
 
 	password := userLogin.Password
-	log.Printf("User: %s / Pass: %s", userLogin.Username, userLogin.Password)
 	if err := repository.FindUserByUsername(&userLogin); err != nil {
 		return nil, jwt.ErrFailedAuthentication
 	} else {
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/slack-bot-4all/slack-bot/src/config"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+)
+
+// apiTokenScopes : the scopes accepted on the tokens
+var apiTokenScopes = []string{model.APITokenScopeRead, model.APITokenScopeWrite, model.APITokenScopeCommands}
+
+// apiTokenReadOnlyPaths : resources that decide who can run what and where. The
+// tokens can read them, but only logged users change them
+var apiTokenReadOnlyPaths = []string{"/v1/roles", "/v1/role-bindings", "/v1/exec-policies", "/v1/protected-environments", "/v1/scale-limits"}
+
+// CreateAPIToken : creates a token for the user, returning it in plaintext. It
+// is not possible to see it again, only its hash is saved
+func CreateAPIToken(t *model.APIToken) (string, error) {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" || t.User == "" {
+		return "", fmt.Errorf("name is required")
+	}
+
+	scopes, err := parseAPITokenScopes(t.Scopes)
+	if err != nil {
+		return "", err
+	}
+	t.Scopes = strings.Join(scopes, ",")
+
+	if t.ExpiresAt != nil && !t.ExpiresAt.After(time.Now()) {
+		return "", fmt.Errorf("expiresAt must be in the future")
+	}
+
+	keyID, err := randomHex(6)
+	if err != nil {
+		return "", err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return "", err
+	}
+
+	token := fmt.Sprintf("%s%s.%s", model.APITokenPrefix, keyID, secret)
+
+	var hash config.Hash
+	if t.Hash, err = hash.Generate(token); err != nil {
+		return "", err
+	}
+	t.KeyID = keyID
+
+	if err := repository.AddAPIToken(t); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// ListAPITokens : list all tokens, without the hashes
+func ListAPITokens() ([]model.APIToken, error) {
+	var tokens []model.APIToken
+
+	if err := repository.ListAPIToken(&tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// FindAPIToken : the token with the ID
+func FindAPIToken(ID uint) (model.APIToken, error) {
+	var token model.APIToken
+
+	err := repository.FindAPITokenByID(&token, ID)
+
+	return token, err
+}
+
+// RevokeAPIToken : the token stops being accepted, kept on db for the audit
+func RevokeAPIToken(ID uint) (model.APIToken, error) {
+	token, err := FindAPIToken(ID)
+	if err != nil {
+		return token, err
+	}
+
+	if token.RevokedAt == nil {
+		now := time.Now()
+		token.RevokedAt = &now
+	}
+
+	return token, repository.SaveAPIToken(&token)
+}
+
+// AuthenticateAPIToken : the token when it exists, matches the hash, is not
+// expired nor revoked and its user still exists
+func AuthenticateAPIToken(value string) (model.APIToken, error) {
+	var token model.APIToken
+
+	parts := strings.SplitN(strings.TrimPrefix(value, model.APITokenPrefix), ".", 2)
+	if !strings.HasPrefix(value, model.APITokenPrefix) || len(parts) != 2 {
+		return token, fmt.Errorf("malformed token")
+	}
+
+	if err := repository.FindAPITokenByKeyID(&token, parts[0]); err != nil {
+		return token, fmt.Errorf("invalid token")
+	}
+
+	var hash config.Hash
+	if err := hash.Compare(token.Hash, value); err != nil {
+		return token, fmt.Errorf("invalid token")
+	}
+
+	now := time.Now()
+	if token.RevokedAt != nil {
+		return token, fmt.Errorf("token was revoked")
+	}
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return token, fmt.Errorf("token expired")
+	}
+
+	// tokens of a removed user, or of an older user with the same username, are not accepted
+	user := model.User{Username: token.User}
+	if err := repository.FindUserByUsername(&user); err != nil || user.CreatedAt.After(token.CreatedAt) {
+		return token, fmt.Errorf("the user of the token no longer exists")
+	}
+
+	token.LastUsedAt = &now
+
+	return token, repository.SaveAPIToken(&token)
+}
+
+// APITokenAllows : checks if the scopes of the token allow the request. Users
+// and tokens are managed only by logged users, as the roles and the policies
+// of the environments
+func APITokenAllows(t model.APIToken, method string, path string) bool {
+	for _, prefix := range []string{"/v1/users", "/v1/tokens", "/v1/account"} {
+		if strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+
+	if method != "GET" {
+		for _, prefix := range apiTokenReadOnlyPaths {
+			if strings.HasPrefix(path, prefix) {
+				return false
+			}
+		}
+	}
+
+	scope := model.APITokenScopeWrite
+	switch {
+	case strings.HasPrefix(path, "/v1/commands"):
+		scope = model.APITokenScopeCommands
+	case method == "GET":
+		scope = model.APITokenScopeRead
+	}
+
+	for _, s := range strings.Split(t.Scopes, ",") {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseAPITokenScopes(scopes string) ([]string, error) {
+	var parsed []string
+
+	for _, scope := range strings.Split(scopes, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+
+		valid := false
+		for _, s := range apiTokenScopes {
+			valid = valid || s == scope
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid scope `%s`, use %s", scope, strings.Join(apiTokenScopes, ", "))
+		}
+
+		parsed = append(parsed, scope)
+	}
+
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("at least one scope is required (%s)", strings.Join(apiTokenScopes, ", "))
+	}
+
+	return parsed, nil
+}
+
+func randomHex(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+)
+
+// the requests are decided by the buttons on Slack and expired by the BOT at
+// the same time
+var approvalMutex sync.Mutex
+
+// OpenApprovalRequest : have a business rules to add an ApprovalRequest to db
+func OpenApprovalRequest(a *model.ApprovalRequest) error {
+	if a.Command == "" || a.Text == "" || a.User == "" {
+		return fmt.Errorf("the approval request needs the command, its text and the requester")
+	}
+
+	if !a.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("the approval request is already expired")
+	}
+
+	a.Status = model.ApprovalPending
+
+	return repository.AddApprovalRequest(a)
+}
+
+// SaveApprovalRequest :
+func SaveApprovalRequest(a *model.ApprovalRequest) error {
+	approvalMutex.Lock()
+	defer approvalMutex.Unlock()
+
+	return repository.SaveApprovalRequest(a)
+}
+
+// FindApprovalRequest :
+func FindApprovalRequest(ID uint) (model.ApprovalRequest, error) {
+	var request model.ApprovalRequest
+	err := repository.FindApprovalRequestByID(&request, ID)
+
+	return request, err
+}
+
+// ApproveRequest : approves a pending request, by a user that is not the requester
+func ApproveRequest(ID uint, user string, now time.Time) (model.ApprovalRequest, error) {
+	return decideRequest(ID, user, now, model.ApprovalApproved)
+}
+
+// RejectRequest : rejects a pending request, the requester can reject to cancel it
+func RejectRequest(ID uint, user string, now time.Time) (model.ApprovalRequest, error) {
+	return decideRequest(ID, user, now, model.ApprovalRejected)
+}
+
+func decideRequest(ID uint, user string, now time.Time, status string) (model.ApprovalRequest, error) {
+	approvalMutex.Lock()
+	defer approvalMutex.Unlock()
+
+	var request model.ApprovalRequest
+	if err := repository.FindApprovalRequestByID(&request, ID); err != nil {
+		return request, err
+	}
+
+	if request.Status != model.ApprovalPending {
+		return request, fmt.Errorf("request %d is already %s", ID, request.Status)
+	}
+
+	if !now.Before(request.ExpiresAt) {
+		request.Status = model.ApprovalExpired
+		if err := repository.SaveApprovalRequest(&request); err != nil {
+			return request, err
+		}
+
+		return request, fmt.Errorf("request %d expired at %s", ID, request.ExpiresAt.Format("2006-01-02 15:04:05"))
+	}
+
+	if status == model.ApprovalApproved && user == request.User {
+		return request, fmt.Errorf("the request must be approved by another user")
+	}
+
+	request.Status = status
+	request.DecidedAt = &now
+	request.DecidedBy = user
+
+	return request, repository.SaveApprovalRequest(&request)
+}
+
+// ExpireApprovalRequests : expires the pending requests not decided before
+// ExpiresAt, returning them
+func ExpireApprovalRequests(now time.Time) ([]model.ApprovalRequest, error) {
+	approvalMutex.Lock()
+	defer approvalMutex.Unlock()
+
+	var pending []model.ApprovalRequest
+	if err := repository.ListApprovalRequestsByStatus(&pending, model.ApprovalPending); err != nil {
+		return nil, err
+	}
+
+	var expired []model.ApprovalRequest
+	for _, request := range pending {
+		if now.Before(request.ExpiresAt) {
+			continue
+		}
+
+		request.Status = model.ApprovalExpired
+		if err := repository.SaveApprovalRequest(&request); err != nil {
+			return expired, err
+		}
+		expired = append(expired, request)
+	}
+
+	return expired, nil
+}
+
+// AddProtectedEnvironment : have a business rules to add a ProtectedEnvironment to db
+func AddProtectedEnvironment(p *model.ProtectedEnvironment) error {
+	p.ProjectID = strings.TrimSpace(p.ProjectID)
+	if p.ProjectID == "" {
+		return fmt.Errorf("the project ID (or namespace) of the environment is required")
+	}
+
+	protected, err := EnvironmentProtected(p.RancherID, p.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	if protected {
+		return fmt.Errorf("environment `%s` is already protected", p.ProjectID)
+	}
+
+	return repository.AddProtectedEnvironment(p)
+}
+
+// ListProtectedEnvironments : list all protected environments
+func ListProtectedEnvironments() ([]model.ProtectedEnvironment, error) {
+	var environments []model.ProtectedEnvironment
+
+	if err := repository.ListProtectedEnvironment(&environments); err != nil {
+		return nil, err
+	}
+
+	return environments, nil
+}
+
+// DeleteProtectedEnvironment : the environment stops needing approvals
+func DeleteProtectedEnvironment(ID uint) (model.ProtectedEnvironment, error) {
+	var environment model.ProtectedEnvironment
+
+	if err := repository.FindProtectedEnvironmentByID(&environment, ID); err != nil {
+		return environment, err
+	}
+
+	return environment, repository.DeleteProtectedEnvironment(&environment)
+}
+
+// EnvironmentProtected : checks if the environment of the Rancher needs approvals
+func EnvironmentProtected(rancherID uint, projectID string) (bool, error) {
+	if projectID == "" {
+		return false, nil
+	}
+
+	count, err := repository.CountProtectedEnvironment(rancherID, projectID)
+
+	return count > 0, err
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+)
+
+// MaxAuditEvents : the most audit events returned by a list
+const MaxAuditEvents = 10000
+
+// RecordAuditEvent : have a business rules to add an AuditEvent to db
+func RecordAuditEvent(a *model.AuditEvent) error {
+	if a.Command == "" || a.Outcome == "" {
+		return fmt.Errorf("the audit event needs the command and the outcome")
+	}
+
+	return repository.AddAuditEvent(a)
+}
+
+// ListAuditEvents : the last audit events that match the filter
+func ListAuditEvents(filter model.AuditFilter) ([]model.AuditEvent, error) {
+	if filter.Limit <= 0 || filter.Limit > MaxAuditEvents {
+		return nil, fmt.Errorf("the limit must be between 1 and %d", MaxAuditEvents)
+	}
+
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+		return nil, fmt.Errorf("the end of the period must be after the start")
+	}
+
+	var events []model.AuditEvent
+	if err := repository.ListAuditEvents(&events, filter); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
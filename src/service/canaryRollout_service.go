@@ -0,0 +1,103 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+)
+
+// AddCanaryRollout : have a business rules to add a CanaryRollout to db
+func AddCanaryRollout(r *model.CanaryRollout) error {
+	if r.LoadBalancerID == "" || r.ServiceID == "" || r.RancherProjectID == "" {
+		return fmt.Errorf("load balancer, service and environment are required")
+	}
+
+	if _, err := ParseRolloutSteps(r.Steps); err != nil {
+		return err
+	}
+
+	if r.IntervalSeconds <= 0 {
+		return fmt.Errorf("interval must be greater than zero")
+	}
+
+	var running []model.CanaryRollout
+	if err := repository.ListCanaryRolloutsByStatus(&running, model.RolloutRunning); err != nil {
+		return err
+	}
+
+	for _, rollout := range running {
+		if rollout.LoadBalancerID == r.LoadBalancerID && rollout.RancherID == r.RancherID && rollout.RancherProjectID == r.RancherProjectID {
+			return fmt.Errorf("load balancer %s already has the rollout %d running", r.LoadBalancerID, rollout.ID)
+		}
+	}
+
+	r.Status = model.RolloutRunning
+
+	return repository.AddCanaryRollout(r)
+}
+
+// ListCanaryRollouts : list the rollouts with a status
+func ListCanaryRollouts(status string) ([]model.CanaryRollout, error) {
+	var rollouts []model.CanaryRollout
+
+	if err := repository.ListCanaryRolloutsByStatus(&rollouts, status); err != nil {
+		return nil, err
+	}
+
+	return rollouts, nil
+}
+
+// FindCanaryRollout : a rollout by ID
+func FindCanaryRollout(ID uint) (model.CanaryRollout, error) {
+	var rollout model.CanaryRollout
+
+	err := repository.FindCanaryRolloutByID(&rollout, ID)
+
+	return rollout, err
+}
+
+// CancelCanaryRollout : stops a running rollout, keeping the current weights
+func CancelCanaryRollout(ID uint, user string) (model.CanaryRollout, error) {
+	var rollout model.CanaryRollout
+
+	if err := repository.FindCanaryRolloutByID(&rollout, ID); err != nil {
+		return rollout, err
+	}
+
+	if rollout.Status != model.RolloutRunning {
+		return rollout, fmt.Errorf("rollout %d is not running (%s)", ID, rollout.Status)
+	}
+
+	rollout.Status = model.RolloutCanceled
+	rollout.Message = fmt.Sprintf("canceled by %s", user)
+
+	return rollout, repository.SaveCanaryRollout(&rollout)
+}
+
+// ParseRolloutSteps : parses the weights of the new version on each step
+// (ex.: 10,25,50,100). Steps must be ascending and between 1 and 100
+func ParseRolloutSteps(steps string) ([]int, error) {
+	var weights []int
+
+	for _, step := range strings.Split(steps, ",") {
+		weight, err := strconv.Atoi(strings.TrimSpace(step))
+		if err != nil {
+			return nil, fmt.Errorf("step `%s` is not a number", step)
+		}
+
+		if weight < 1 || weight > 100 {
+			return nil, fmt.Errorf("step %d must be between 1 and 100", weight)
+		}
+
+		if len(weights) > 0 && weight <= weights[len(weights)-1] {
+			return nil, fmt.Errorf("steps must be ascending")
+		}
+
+		weights = append(weights, weight)
+	}
+
+	return weights, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+)
+
+// AddExecPolicy : have a business rules to add an ExecPolicy to db
+func AddExecPolicy(p *model.ExecPolicy) error {
+	if err := validateExecPolicy(p); err != nil {
+		return err
+	}
+
+	_, found, err := FindEnvironmentExecPolicy(p.RancherID, p.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	if found {
+		return fmt.Errorf("environment `%s` already has an exec policy", p.ProjectID)
+	}
+
+	return repository.AddExecPolicy(p)
+}
+
+// UpdateExecPolicy : changes the commands and the timeout of an ExecPolicy,
+// the environment can't be changed
+func UpdateExecPolicy(ID uint, p *model.ExecPolicy) error {
+	var policy model.ExecPolicy
+	if err := repository.FindExecPolicyByID(&policy, ID); err != nil {
+		return err
+	}
+
+	p.RancherID = policy.RancherID
+	p.ProjectID = policy.ProjectID
+	if err := validateExecPolicy(p); err != nil {
+		return err
+	}
+
+	policy.Commands = p.Commands
+	policy.TimeoutSeconds = p.TimeoutSeconds
+
+	if err := repository.SaveExecPolicy(&policy); err != nil {
+		return err
+	}
+
+	*p = policy
+
+	return nil
+}
+
+// validateExecPolicy : checks the environment and the timeout and normalizes
+// the list of commands
+func validateExecPolicy(p *model.ExecPolicy) error {
+	p.ProjectID = strings.TrimSpace(p.ProjectID)
+	if p.ProjectID == "" {
+		return fmt.Errorf("the project ID of the environment is required")
+	}
+
+	commands := ExecPolicyCommands(*p)
+	if len(commands) == 0 {
+		return fmt.Errorf("the exec policy needs at least one command, or `%s` for all", model.ExecAllCommands)
+	}
+	p.Commands = strings.Join(commands, ",")
+
+	if p.TimeoutSeconds < 0 || p.TimeoutSeconds > model.ExecMaxTimeout {
+		return fmt.Errorf("the timeout must be between 1 and %d seconds, or 0 for the default of %d", model.ExecMaxTimeout, model.ExecDefaultTimeout)
+	}
+
+	return nil
+}
+
+// ListExecPolicies : list all exec policies
+func ListExecPolicies() ([]model.ExecPolicy, error) {
+	var policies []model.ExecPolicy
+
+	if err := repository.ListExecPolicy(&policies); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// FindExecPolicy : an exec policy by ID
+func FindExecPolicy(ID uint) (model.ExecPolicy, error) {
+	var policy model.ExecPolicy
+
+	err := repository.FindExecPolicyByID(&policy, ID)
+
+	return policy, err
+}
+
+// DeleteExecPolicy : container-exec stops being allowed on the environment
+func DeleteExecPolicy(ID uint) (model.ExecPolicy, error) {
+	var policy model.ExecPolicy
+
+	if err := repository.FindExecPolicyByID(&policy, ID); err != nil {
+		return policy, err
+	}
+
+	return policy, repository.DeleteExecPolicy(&policy)
+}
+
+// FindEnvironmentExecPolicy : the exec policy of the environment of the
+// Rancher, found is false when the environment has none
+func FindEnvironmentExecPolicy(rancherID uint, projectID string) (policy model.ExecPolicy, found bool, err error) {
+	if projectID == "" {
+		return policy, false, nil
+	}
+
+	err = repository.FindExecPolicyByEnvironment(&policy, rancherID, projectID)
+	if err == gorm.ErrRecordNotFound {
+		return policy, false, nil
+	}
+
+	return policy, err == nil, err
+}
+
+// ExecPolicyCommands : programs of the policy, without spaces and empty items
+func ExecPolicyCommands(p model.ExecPolicy) []string {
+	var commands []string
+
+	for _, command := range strings.Split(p.Commands, ",") {
+		if command = strings.TrimSpace(command); command != "" {
+			commands = append(commands, command)
+		}
+	}
+
+	return commands
+}
+
+// ExecAllows : checks if the program is one of the commands of the policy.
+// The program is compared as typed, allowing `cat` doesn't allow `/tmp/cat`
+func ExecAllows(p model.ExecPolicy, program string) bool {
+	for _, allowed := range ExecPolicyCommands(p) {
+		if allowed == model.ExecAllCommands || allowed == program {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExecTimeout : how long container-exec waits for the command on the environment
+func ExecTimeout(p model.ExecPolicy) time.Duration {
+	if p.TimeoutSeconds <= 0 {
+		return model.ExecDefaultTimeout * time.Second
+	}
+
+	return time.Duration(p.TimeoutSeconds) * time.Second
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+)
+
+// IncidentListActive : status used to list the incidents not resolved yet
+const IncidentListActive = "active"
+
+// the incidents are changed by the checks of the tasks and by the buttons on
+// Slack at the same time
+var incidentMutex sync.Mutex
+
+// IncidentReport : the incident with the time to acknowledge and to resolve, in
+// seconds, nil while it wasn't acknowledged or resolved
+type IncidentReport struct {
+	model.Incident
+	TimeToAck     *int64 `json:"timeToAckSeconds"`
+	TimeToResolve *int64 `json:"timeToResolveSeconds"`
+}
+
+// OpenIncident : have a business rules to add an Incident to db
+func OpenIncident(i *model.Incident) error {
+	if i.TaskID == 0 {
+		return fmt.Errorf("the incident needs a task")
+	}
+
+	i.Status = model.IncidentOpen
+	if i.OpenedAt.IsZero() {
+		i.OpenedAt = time.Now()
+	}
+
+	return repository.AddIncident(i)
+}
+
+// FindIncident :
+func FindIncident(ID uint) (model.Incident, error) {
+	var incident model.Incident
+	err := repository.FindIncidentByID(&incident, ID)
+
+	return incident, err
+}
+
+// SaveIncident :
+func SaveIncident(i *model.Incident) error {
+	incidentMutex.Lock()
+	defer incidentMutex.Unlock()
+
+	return repository.SaveIncident(i)
+}
+
+// AcknowledgeIncident : someone is working on the incident
+func AcknowledgeIncident(ID uint, user string, now time.Time) (model.Incident, error) {
+	incidentMutex.Lock()
+	defer incidentMutex.Unlock()
+
+	var incident model.Incident
+	if err := repository.FindIncidentByID(&incident, ID); err != nil {
+		return incident, err
+	}
+
+	switch incident.Status {
+	case model.IncidentResolved:
+		return incident, fmt.Errorf("incident %d is already resolved", ID)
+	case model.IncidentAcknowledged:
+		return incident, fmt.Errorf("incident %d was already acknowledged by <@%s>", ID, incident.AckedBy)
+	}
+
+	incident.Status = model.IncidentAcknowledged
+	incident.AckedAt = &now
+	incident.AckedBy = user
+
+	return incident, repository.SaveIncident(&incident)
+}
+
+// ResolveIncident : closes the incident. Without user it was resolved by the
+// recovery of the task
+func ResolveIncident(ID uint, user string, now time.Time) (model.Incident, error) {
+	incidentMutex.Lock()
+	defer incidentMutex.Unlock()
+
+	var incident model.Incident
+	if err := repository.FindIncidentByID(&incident, ID); err != nil {
+		return incident, err
+	}
+
+	if incident.Status == model.IncidentResolved {
+		return incident, fmt.Errorf("incident %d is already resolved", ID)
+	}
+
+	incident.Status = model.IncidentResolved
+	incident.ResolvedAt = &now
+	incident.ResolvedBy = user
+
+	return incident, repository.SaveIncident(&incident)
+}
+
+// ListIncidents : the last incidents, all of them or only the ones with the
+// status (open, acknowledged, resolved or active)
+func ListIncidents(status string, limit int) ([]model.Incident, error) {
+	var incidents []model.Incident
+
+	switch status {
+	case "":
+		return incidents, repository.ListIncidents(&incidents, limit)
+	case IncidentListActive:
+		return incidents, repository.ListIncidentsByStatus(&incidents, []string{model.IncidentOpen, model.IncidentAcknowledged}, limit)
+	case model.IncidentOpen, model.IncidentAcknowledged, model.IncidentResolved:
+		return incidents, repository.ListIncidentsByStatus(&incidents, []string{status}, limit)
+	}
+
+	return nil, fmt.Errorf("invalid status `%s`, use %s, %s, %s or %s", status, model.IncidentOpen, model.IncidentAcknowledged, model.IncidentResolved, IncidentListActive)
+}
+
+// NewIncidentReport : calculates the time to acknowledge and to resolve of the incident
+func NewIncidentReport(i model.Incident) IncidentReport {
+	report := IncidentReport{Incident: i}
+
+	if d, ok := TimeToAck(i); ok {
+		seconds := int64(d.Seconds())
+		report.TimeToAck = &seconds
+	}
+
+	if d, ok := TimeToResolve(i); ok {
+		seconds := int64(d.Seconds())
+		report.TimeToResolve = &seconds
+	}
+
+	return report
+}
+
+// TimeToAck : time from the alert until the incident was acknowledged
+func TimeToAck(i model.Incident) (time.Duration, bool) {
+	if i.AckedAt == nil {
+		return 0, false
+	}
+
+	return i.AckedAt.Sub(i.OpenedAt), true
+}
+
+// TimeToResolve : time from the alert until the incident was resolved
+func TimeToResolve(i model.Incident) (time.Duration, bool) {
+	if i.ResolvedAt == nil {
+		return 0, false
+	}
+
+	return i.ResolvedAt.Sub(i.OpenedAt), true
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+)
+
+// AddLbConfigVersion : saves the config after a change as the next version of
+// the load balancer. On the first change of a LB the config before it is saved
+// too (as the version 1), so the change can be rolled back
+func AddLbConfigVersion(v *model.LbConfigVersion, previousConfig string) error {
+	if v.LoadBalancerID == "" {
+		return fmt.Errorf("load balancer is required")
+	}
+
+	var last []model.LbConfigVersion
+	if err := repository.ListLbConfigVersions(&last, v.RancherID, v.ProjectID, v.LoadBalancerID, 1); err != nil {
+		return err
+	}
+
+	if len(last) == 0 {
+		initial := model.LbConfigVersion{
+			RancherID:      v.RancherID,
+			ProjectID:      v.ProjectID,
+			LoadBalancerID: v.LoadBalancerID,
+			Version:        1,
+			Action:         model.LbConfigInitial,
+			NewWeights:     v.OldWeights,
+			Config:         previousConfig,
+		}
+
+		if err := repository.AddLbConfigVersion(&initial); err != nil {
+			return err
+		}
+
+		last = append(last, initial)
+	}
+
+	v.Version = last[0].Version + 1
+
+	return repository.AddLbConfigVersion(v)
+}
+
+// ListLbConfigVersions : the last versions of a load balancer, newest first
+func ListLbConfigVersions(rancherID uint, projectID string, lbID string, limit int) ([]model.LbConfigVersion, error) {
+	var versions []model.LbConfigVersion
+
+	if err := repository.ListLbConfigVersions(&versions, rancherID, projectID, lbID, limit); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// FindLbConfigVersion : a version of a load balancer
+func FindLbConfigVersion(rancherID uint, projectID string, lbID string, version int) (model.LbConfigVersion, error) {
+	var v model.LbConfigVersion
+
+	if err := repository.FindLbConfigVersion(&v, rancherID, projectID, lbID, version); err != nil {
+		return v, fmt.Errorf("version %d of load balancer %s not found: %s", version, lbID, err)
+	}
+
+	return v, nil
+}
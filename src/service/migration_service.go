@@ -0,0 +1,29 @@
+package service
+
+import (
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+)
+
+// legacyTableNames : tables created by old versions with camelCase names, the
+// new tables use snake_case
+var legacyTableNames = []struct {
+	old   string
+	table string
+}{
+	{"canaryRollout", model.CanaryRollout{}.TableName()},
+	{"lbConfigVersion", model.LbConfigVersion{}.TableName()},
+	{"taskHealth", model.TaskHealth{}.TableName()},
+}
+
+// RenameLegacyTables : renames the tables of old versions to their snake_case
+// names, before the AutoMigrate creates them empty
+func RenameLegacyTables() error {
+	for _, t := range legacyTableNames {
+		if err := repository.RenameLegacyTable(t.old, t.table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
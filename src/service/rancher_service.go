@@ -1,22 +1,100 @@
 package service
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/slack-bot-4all/slack-bot/src/config"
 	"github.com/slack-bot-4all/slack-bot/src/model"
 	"github.com/slack-bot-4all/slack-bot/src/repository"
 )
 
 // AddRancher : have a business rules to add a Rancher to db
 func AddRancher(r *model.Rancher) error {
-	var err error
-
-	if r.Name != "" && r.URL != "" && r.AccessKey != "" && r.SecretKey != "" {
-		err = repository.AddRancher(r)
+	if err := validateRancher(r); err != nil {
+		return err
 	}
 
+	return repository.AddRancher(r)
+}
+
+// FindRancher : the Rancher with the ID
+func FindRancher(ID uint) (model.Rancher, error) {
+	var rancher model.Rancher
+
+	err := repository.FindRancherByID(&rancher, ID)
+
+	return rancher, err
+}
+
+// UpdateRancher : changes a Rancher, keeping the secret key when it is not sent
+func UpdateRancher(ID uint, r *model.Rancher) error {
+	current, err := FindRancher(ID)
 	if err != nil {
 		return err
 	}
 
+	if r.SecretKey == "" {
+		r.SecretKey = current.SecretKey
+	}
+	r.Model = current.Model
+
+	if err := validateRancher(r); err != nil {
+		return err
+	}
+
+	return repository.SaveRancher(r)
+}
+
+// DeleteRancher : removes a Rancher that has no tasks nor canary rollouts running
+func DeleteRancher(ID uint) (model.Rancher, error) {
+	rancher, err := FindRancher(ID)
+	if err != nil {
+		return rancher, err
+	}
+
+	tasks, err := repository.CountTasksByRancher(ID)
+	if err != nil {
+		return rancher, err
+	}
+	if tasks > 0 {
+		return rancher, fmt.Errorf("Rancher %s has %d tasks, delete them first", rancher.Name, tasks)
+	}
+
+	var rollouts []model.CanaryRollout
+	if err := repository.ListCanaryRolloutsByStatus(&rollouts, model.RolloutRunning); err != nil {
+		return rancher, err
+	}
+	for _, rollout := range rollouts {
+		if rollout.RancherID == ID {
+			return rancher, fmt.Errorf("Rancher %s has the canary rollout %d running", rancher.Name, rollout.ID)
+		}
+	}
+
+	return rancher, repository.DeleteRancher(&rancher)
+}
+
+func validateRancher(r *model.Rancher) error {
+	if r.Type == "" {
+		r.Type = model.RancherTypeRancher
+	}
+
+	if r.Type != model.RancherTypeRancher && r.Type != model.RancherTypeKubernetes {
+		return fmt.Errorf("invalid type `%s`, use `%s` or `%s`", r.Type, model.RancherTypeRancher, model.RancherTypeKubernetes)
+	}
+
+	if r.MaxConcurrency <= 0 {
+		r.MaxConcurrency = model.DefaultMaxConcurrency
+	}
+
+	// Kubernetes API servers may use only a bearer token (on SecretKey)
+	hasCredentials := r.SecretKey != "" && (r.AccessKey != "" || r.Type == model.RancherTypeKubernetes)
+
+	if r.Name == "" || r.URL == "" || !hasCredentials {
+		return fmt.Errorf("name, url, accessKey and secretKey are required (Kubernetes may have only the token on secretKey)")
+	}
+
 	return nil
 }
 
@@ -31,3 +109,181 @@ func ListRancher() (ranchersList []model.Rancher, err error) {
 
 	return ranchers, nil
 }
+
+// EncryptRancherSecrets : encrypts the secret keys saved before the encryption
+func EncryptRancherSecrets() (int, error) {
+	return reencryptRancherSecrets(func(secretKey string) bool {
+		return !config.IsEncrypted(secretKey)
+	})
+}
+
+// RotateRancherSecrets : encrypts again with the current key the secret keys
+// encrypted with a previous key, that can be removed after it
+func RotateRancherSecrets() (int, error) {
+	return reencryptRancherSecrets(func(secretKey string) bool {
+		return !config.Secrets.UsesCurrentKey(secretKey)
+	})
+}
+
+func reencryptRancherSecrets(needs func(secretKey string) bool) (int, error) {
+	var ranchers []model.Rancher
+	if err := repository.ListRancherSecrets(&ranchers); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, rancher := range ranchers {
+		if !needs(rancher.SecretKey) {
+			continue
+		}
+
+		secretKey, err := config.Secrets.Decrypt(rancher.SecretKey)
+		if err != nil {
+			return count, fmt.Errorf("error on decrypt the secret key of Rancher %s: %s", rancher.Name, err)
+		}
+
+		encrypted, err := config.Secrets.Encrypt(secretKey)
+		if err != nil {
+			return count, err
+		}
+
+		if err := repository.UpdateRancherSecret(rancher.ID, encrypted); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// legacyRancherTables : tables that copied the Rancher on each row, and if
+// they copied the keys too. The tables with keys go first, because they
+// register the Ranchers that the others reference by URL
+var legacyRancherTables = []struct {
+	name     string
+	withKeys bool
+}{
+	{model.Task{}.TableName(), true},
+	{model.CanaryRollout{}.TableName(), true},
+	{model.Silence{}.TableName(), false},
+	{model.Incident{}.TableName(), false},
+	{model.LbConfigVersion{}.TableName(), false},
+}
+
+// MigrateRancherRefs : points the rows that copied the URL and the keys of a
+// Rancher to the registered Rancher with the same URL and access key, or 0
+// for the Rancher of the environment variables, registering the Ranchers that
+// are missing. Rows without keys whose URL matches no Rancher are pointed to
+// UnresolvedRancherID and returned, and the copied columns of their table are
+// kept so they are migrated again once the Rancher is registered
+func MigrateRancherRefs(defaultURL string, defaultAccessKey string) (int, []string, error) {
+	count := 0
+	var unresolved []string
+
+	for _, table := range legacyRancherTables {
+		if !repository.HasLegacyRancherRefs(table.name) {
+			continue
+		}
+
+		var refs []repository.LegacyRancherRef
+		if err := repository.ListLegacyRancherRefs(table.name, table.withKeys, &refs); err != nil {
+			return count, unresolved, err
+		}
+
+		resolved := true
+		for _, ref := range refs {
+			rancherID, found, err := legacyRancherID(ref, table.withKeys, defaultURL, defaultAccessKey)
+			if err != nil {
+				return count, unresolved, fmt.Errorf("error on migrate row %d of %s: %s", ref.ID, table.name, err)
+			}
+
+			if !found {
+				rancherID = model.UnresolvedRancherID
+				resolved = false
+				unresolved = append(unresolved, fmt.Sprintf("row %d of %s references the Rancher `%s`", ref.ID, table.name, ref.RancherURL))
+			}
+
+			if err := repository.SetRancherID(table.name, ref.ID, rancherID); err != nil {
+				return count, unresolved, err
+			}
+			count++
+		}
+
+		if !resolved {
+			continue
+		}
+
+		if err := repository.DropLegacyRancherRefs(table.name, table.withKeys); err != nil {
+			return count, unresolved, err
+		}
+	}
+
+	return count, unresolved, nil
+}
+
+// legacyRancherID : the Rancher of a legacy row, registering it when the row has
+// the keys. Without the keys an unknown Rancher can't be registered, so it is
+// not found
+func legacyRancherID(ref repository.LegacyRancherRef, withKeys bool, defaultURL string, defaultAccessKey string) (uint, bool, error) {
+	URL := strings.TrimRight(ref.RancherURL, "/")
+
+	if URL == strings.TrimRight(defaultURL, "/") && (!withKeys || ref.RancherAccessKey == defaultAccessKey) {
+		return 0, true, nil
+	}
+
+	ranchers, err := ListRancher()
+	if err != nil {
+		return 0, false, err
+	}
+
+	for _, rancher := range ranchers {
+		if strings.TrimRight(rancher.URL, "/") == URL && (!withKeys || rancher.AccessKey == ref.RancherAccessKey) {
+			return rancher.ID, true, nil
+		}
+	}
+
+	if !withKeys || URL == "" {
+		return 0, false, nil
+	}
+
+	rancher := model.Rancher{
+		Name:      migratedRancherName(URL, ranchers),
+		Type:      model.RancherTypeRancher,
+		URL:       ref.RancherURL,
+		AccessKey: ref.RancherAccessKey,
+		SecretKey: ref.RancherSecretKey,
+	}
+
+	if err := AddRancher(&rancher); err != nil {
+		return 0, false, err
+	}
+
+	return rancher.ID, true, nil
+}
+
+// migratedRancherName : name of a Rancher registered by the migration, from the
+// host of the URL (migrated-rancher.example.com), with a number when it is taken
+func migratedRancherName(URL string, ranchers []model.Rancher) string {
+	host := URL
+	if u, err := url.Parse(URL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	// the name has at most 50 characters, with room for the number
+	base := "migrated-" + host
+	if len(base) > 45 {
+		base = base[:45]
+	}
+
+	taken := map[string]bool{}
+	for _, rancher := range ranchers {
+		taken[rancher.Name] = true
+	}
+
+	name := base
+	for i := 2; taken[name]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	return name
+}
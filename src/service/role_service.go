@@ -0,0 +1,243 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+)
+
+// AddRole : have a business rules to add a Role to db
+func AddRole(r *model.Role) error {
+	if err := validateRole(r); err != nil {
+		return err
+	}
+
+	return repository.AddRole(r)
+}
+
+// UpdateRole : changes the name, description and commands of a Role
+func UpdateRole(ID uint, r *model.Role) error {
+	var role model.Role
+	if err := repository.FindRoleByID(&role, ID); err != nil {
+		return err
+	}
+
+	if err := validateRole(r); err != nil {
+		return err
+	}
+
+	role.Name = r.Name
+	role.Description = r.Description
+	role.Commands = r.Commands
+
+	if err := repository.SaveRole(&role); err != nil {
+		return err
+	}
+
+	*r = role
+
+	return nil
+}
+
+// validateRole : checks the name and normalizes the list of commands
+func validateRole(r *model.Role) error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("the name of the role is required")
+	}
+
+	commands := RoleCommands(*r)
+	if len(commands) == 0 {
+		return fmt.Errorf("the role needs at least one command, or `%s` for all", model.RoleAllCommands)
+	}
+	r.Commands = strings.Join(commands, ",")
+
+	return nil
+}
+
+// ListRoles : list all roles
+func ListRoles() ([]model.Role, error) {
+	var roles []model.Role
+
+	if err := repository.ListRole(&roles); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// FindRole : a role by ID
+func FindRole(ID uint) (model.Role, error) {
+	var role model.Role
+
+	err := repository.FindRoleByID(&role, ID)
+
+	return role, err
+}
+
+// DeleteRole : removes a role and its bindings
+func DeleteRole(ID uint) (model.Role, error) {
+	var role model.Role
+
+	if err := repository.FindRoleByID(&role, ID); err != nil {
+		return role, err
+	}
+
+	return role, repository.DeleteRole(&role)
+}
+
+// RoleCommands : commands of the role, without spaces and empty items
+func RoleCommands(r model.Role) []string {
+	var commands []string
+
+	for _, command := range strings.Split(r.Commands, ",") {
+		if command = strings.TrimSpace(command); command != "" {
+			commands = append(commands, command)
+		}
+	}
+
+	return commands
+}
+
+// RoleAllows : checks if the command is one of the commands of the role
+func RoleAllows(r model.Role, command string) bool {
+	for _, allowed := range RoleCommands(r) {
+		if allowed == model.RoleAllCommands || allowed == command {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddRoleBinding : have a business rules to add a RoleBinding to db
+func AddRoleBinding(b *model.RoleBinding) error {
+	if err := validateRoleBinding(b); err != nil {
+		return err
+	}
+
+	return repository.AddRoleBinding(b)
+}
+
+// FindRoleBinding : the role binding with the ID
+func FindRoleBinding(ID uint) (model.RoleBinding, error) {
+	var binding model.RoleBinding
+
+	err := repository.FindRoleBindingByID(&binding, ID)
+
+	return binding, err
+}
+
+// UpdateRoleBinding : changes the role, subject and scope of a role binding
+func UpdateRoleBinding(ID uint, b *model.RoleBinding) error {
+	current, err := FindRoleBinding(ID)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRoleBinding(b); err != nil {
+		return err
+	}
+	b.Model = current.Model
+
+	return repository.SaveRoleBinding(b)
+}
+
+func validateRoleBinding(b *model.RoleBinding) error {
+	if _, err := FindRole(b.RoleID); err != nil {
+		return fmt.Errorf("role `%d` not found", b.RoleID)
+	}
+
+	switch b.SubjectType {
+	case model.RoleSubjectUser, model.RoleSubjectGroup:
+	default:
+		return fmt.Errorf("invalid subject type `%s`, use `%s` or `%s`", b.SubjectType, model.RoleSubjectUser, model.RoleSubjectGroup)
+	}
+
+	b.Subject = strings.TrimSpace(b.Subject)
+	if b.Subject == "" {
+		return fmt.Errorf("the Slack ID of the user or user group is required")
+	}
+
+	if b.AllRanchers && b.RancherID != 0 {
+		return fmt.Errorf("a binding on all Ranchers can't have a rancherId")
+	}
+
+	return nil
+}
+
+// ListRoleBindings : list all role bindings
+func ListRoleBindings() ([]model.RoleBinding, error) {
+	var bindings []model.RoleBinding
+
+	if err := repository.ListRoleBinding(&bindings); err != nil {
+		return nil, err
+	}
+
+	return bindings, nil
+}
+
+// MigrateRoleBindingScope : before AllRanchers, the bindings with RancherID 0
+// were on every Rancher. Called only when the column is created, as 0 is now
+// the Rancher of the environment variables
+func MigrateRoleBindingScope() (int, error) {
+	return repository.SetAllRanchersOnLegacyRoleBindings()
+}
+
+// DeleteRoleBinding : removes a role binding
+func DeleteRoleBinding(ID uint) (model.RoleBinding, error) {
+	var binding model.RoleBinding
+
+	if err := repository.FindRoleBindingByID(&binding, ID); err != nil {
+		return binding, err
+	}
+
+	return binding, repository.DeleteRoleBinding(&binding)
+}
+
+// CommandAllowed : checks if the user, directly or by one of the user groups, has
+// a role with the command on the Rancher and environment
+func CommandAllowed(command string, user string, groups []string, rancherID uint, projectID string) (bool, error) {
+	return bindingAllows(command, user, groups, func(binding model.RoleBinding) bool {
+		if !binding.AllRanchers && binding.RancherID != rancherID {
+			return false
+		}
+
+		return binding.ProjectID == "" || binding.ProjectID == projectID
+	})
+}
+
+// GlobalCommandAllowed : checks if the user, directly or by one of the user groups,
+// has a role with the command on all Ranchers and environments
+func GlobalCommandAllowed(command string, user string, groups []string) (bool, error) {
+	return bindingAllows(command, user, groups, func(binding model.RoleBinding) bool {
+		return binding.AllRanchers && binding.ProjectID == ""
+	})
+}
+
+// bindingAllows : checks if one of the bindings of the user in the scope has a role with the command
+func bindingAllows(command string, user string, groups []string, inScope func(model.RoleBinding) bool) (bool, error) {
+	var bindings []model.RoleBinding
+	if err := repository.ListRoleBindingBySubjects(&bindings, user, groups); err != nil {
+		return false, err
+	}
+
+	for _, binding := range bindings {
+		if !inScope(binding) {
+			continue
+		}
+
+		role, err := FindRole(binding.RoleID)
+		if err != nil {
+			continue
+		}
+
+		if RoleAllows(role, command) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
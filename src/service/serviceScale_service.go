@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+)
+
+// AddScaleLimit : have a business rules to add a ScaleLimit to db
+func AddScaleLimit(l *model.ScaleLimit) error {
+	if err := validateScaleLimit(l); err != nil {
+		return err
+	}
+
+	var existing model.ScaleLimit
+	err := repository.FindScaleLimitByEnvironment(&existing, l.RancherID, l.ProjectID)
+	if err == nil {
+		return fmt.Errorf("environment `%s` already has a scale limit", l.ProjectID)
+	}
+	if err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	return repository.AddScaleLimit(l)
+}
+
+// UpdateScaleLimit : changes the minimum and maximum of a ScaleLimit, the
+// environment can't be changed
+func UpdateScaleLimit(ID uint, l *model.ScaleLimit) error {
+	var limit model.ScaleLimit
+	if err := repository.FindScaleLimitByID(&limit, ID); err != nil {
+		return err
+	}
+
+	l.RancherID = limit.RancherID
+	l.ProjectID = limit.ProjectID
+	if err := validateScaleLimit(l); err != nil {
+		return err
+	}
+
+	limit.Min = l.Min
+	limit.Max = l.Max
+
+	if err := repository.SaveScaleLimit(&limit); err != nil {
+		return err
+	}
+
+	*l = limit
+
+	return nil
+}
+
+// validateScaleLimit : checks the environment and the bounds
+func validateScaleLimit(l *model.ScaleLimit) error {
+	l.ProjectID = strings.TrimSpace(l.ProjectID)
+	if l.ProjectID == "" {
+		return fmt.Errorf("the project ID of the environment is required")
+	}
+
+	if l.Min < 0 {
+		return fmt.Errorf("the minimum scale can't be negative")
+	}
+
+	if l.Max < 1 || l.Max < l.Min {
+		return fmt.Errorf("the maximum scale must be at least 1 and not less than the minimum")
+	}
+
+	return nil
+}
+
+// ListScaleLimits : list all scale limits
+func ListScaleLimits() ([]model.ScaleLimit, error) {
+	var limits []model.ScaleLimit
+
+	if err := repository.ListScaleLimit(&limits); err != nil {
+		return nil, err
+	}
+
+	return limits, nil
+}
+
+// FindScaleLimit : a scale limit by ID
+func FindScaleLimit(ID uint) (model.ScaleLimit, error) {
+	var limit model.ScaleLimit
+
+	err := repository.FindScaleLimitByID(&limit, ID)
+
+	return limit, err
+}
+
+// DeleteScaleLimit : the environment goes back to the default limits
+func DeleteScaleLimit(ID uint) (model.ScaleLimit, error) {
+	var limit model.ScaleLimit
+
+	if err := repository.FindScaleLimitByID(&limit, ID); err != nil {
+		return limit, err
+	}
+
+	return limit, repository.DeleteScaleLimit(&limit)
+}
+
+// EnvironmentScaleLimit : the scale limit of the environment of the Rancher,
+// ScaleDefaultMin and ScaleDefaultMax when it has none
+func EnvironmentScaleLimit(rancherID uint, projectID string) (model.ScaleLimit, error) {
+	limit := model.ScaleLimit{RancherID: rancherID, ProjectID: projectID, Min: model.ScaleDefaultMin, Max: model.ScaleDefaultMax}
+
+	if projectID == "" {
+		return limit, nil
+	}
+
+	err := repository.FindScaleLimitByEnvironment(&limit, rancherID, projectID)
+	if err == gorm.ErrRecordNotFound {
+		return limit, nil
+	}
+
+	return limit, err
+}
+
+// AddScaleRevert : schedules the revert of a timed scale
+func AddScaleRevert(r *model.ScaleRevert) error {
+	r.Status = model.ScaleRevertPending
+
+	return repository.AddScaleRevert(r)
+}
+
+// FindPendingScaleRevert : the timed scale of the service waiting to be
+// reverted, found is false when there is none
+func FindPendingScaleRevert(rancherID uint, serviceID string) (revert model.ScaleRevert, found bool, err error) {
+	err = repository.FindPendingScaleRevert(&revert, rancherID, serviceID)
+	if err == gorm.ErrRecordNotFound {
+		return revert, false, nil
+	}
+
+	return revert, err == nil, err
+}
+
+// ListDueScaleReverts : the timed scales that must be reverted now
+func ListDueScaleReverts(now time.Time) ([]model.ScaleRevert, error) {
+	var reverts []model.ScaleRevert
+
+	if err := repository.ListDueScaleReverts(&reverts, now); err != nil {
+		return nil, err
+	}
+
+	return reverts, nil
+}
+
+// FinishScaleRevert : saves the final status of a timed scale
+func FinishScaleRevert(r *model.ScaleRevert, status string, detail string) error {
+	r.Status = status
+	r.Detail = detail
+
+	return repository.SaveScaleRevert(r)
+}
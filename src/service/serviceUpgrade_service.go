@@ -0,0 +1,62 @@
+package service
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+)
+
+// TrackServiceUpgrade : starts tracking an upgrade. A previous upgrade of the
+// same service still tracked is untracked, Rancher only accepts a new upgrade
+// after it was finished or rolled back
+func TrackServiceUpgrade(u *model.ServiceUpgrade) error {
+	previous, found, err := FindTrackedServiceUpgrade(u.RancherID, u.ServiceID)
+	if err != nil {
+		return err
+	}
+
+	if found {
+		if err := FinishServiceUpgrade(&previous, model.UpgradeUntracked, "replaced by a new upgrade"); err != nil {
+			return err
+		}
+	}
+
+	u.Status = model.UpgradeUpgrading
+
+	return repository.AddServiceUpgrade(u)
+}
+
+// FindTrackedServiceUpgrade : the tracked upgrade of the service, found is
+// false when there is none
+func FindTrackedServiceUpgrade(rancherID uint, serviceID string) (upgrade model.ServiceUpgrade, found bool, err error) {
+	err = repository.FindTrackedServiceUpgrade(&upgrade, rancherID, serviceID)
+	if err == gorm.ErrRecordNotFound {
+		return upgrade, false, nil
+	}
+
+	return upgrade, err == nil, err
+}
+
+// ListTrackedServiceUpgrades : upgrades still upgrading or waiting for finish or rollback
+func ListTrackedServiceUpgrades() ([]model.ServiceUpgrade, error) {
+	var upgrades []model.ServiceUpgrade
+
+	if err := repository.ListTrackedServiceUpgrades(&upgrades); err != nil {
+		return nil, err
+	}
+
+	return upgrades, nil
+}
+
+// SaveServiceUpgrade : saves the progress of a tracked upgrade
+func SaveServiceUpgrade(u *model.ServiceUpgrade) error {
+	return repository.SaveServiceUpgrade(u)
+}
+
+// FinishServiceUpgrade : saves the final status of an upgrade, that stops being tracked
+func FinishServiceUpgrade(u *model.ServiceUpgrade, status string, detail string) error {
+	u.Status = status
+	u.Detail = detail
+
+	return repository.SaveServiceUpgrade(u)
+}
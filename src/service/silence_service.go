@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/slack-bot-4all/slack-bot/src/cron"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+)
+
+// AddSilence : have a business rules to add a Silence to db
+func AddSilence(s *model.Silence) error {
+	if s.TaskID == 0 && s.Service == "" {
+		return fmt.Errorf("a task ID or a service (stack/service) is required")
+	}
+
+	if s.TaskID == 0 && s.ProjectID == "" {
+		return fmt.Errorf("select an environment to silence a service")
+	}
+
+	switch s.Kind {
+	case model.SilenceKindSilence, model.SilenceKindMaintenance:
+	default:
+		return fmt.Errorf("invalid kind `%s`", s.Kind)
+	}
+
+	if s.Cron != "" {
+		if s.DurationMinutes <= 0 {
+			return fmt.Errorf("recurring maintenance windows need a duration")
+		}
+
+		schedule, err := cron.Parse(s.Cron)
+		if err != nil {
+			return err
+		}
+
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("cron `%s` never runs", s.Cron)
+		}
+	} else if !s.EndsAt.After(s.StartsAt) {
+		return fmt.Errorf("the end must be after the start")
+	} else if !s.EndsAt.After(time.Now()) {
+		return fmt.Errorf("the window already ended")
+	}
+
+	return repository.AddSilence(s)
+}
+
+// ListSilences : silences and maintenance windows that are active or will be
+func ListSilences(now time.Time) ([]model.Silence, error) {
+	var all []model.Silence
+	if err := repository.ListSilence(&all); err != nil {
+		return nil, err
+	}
+
+	var silences []model.Silence
+	for _, silence := range all {
+		if silence.Cron != "" || silence.EndsAt.After(now) {
+			silences = append(silences, silence)
+		}
+	}
+
+	return silences, nil
+}
+
+// FindSilence : a silence or maintenance window by ID
+func FindSilence(ID uint) (model.Silence, error) {
+	var silence model.Silence
+
+	err := repository.FindSilenceByID(&silence, ID)
+
+	return silence, err
+}
+
+// DeleteSilence : removes a silence or maintenance window before it ends
+func DeleteSilence(ID uint) (model.Silence, error) {
+	var silence model.Silence
+
+	if err := repository.FindSilenceByID(&silence, ID); err != nil {
+		return silence, err
+	}
+
+	return silence, repository.DeleteSilence(&silence)
+}
+
+// SilenceActive : checks if the silence is active at the time, returning when it ends
+func SilenceActive(s model.Silence, now time.Time) (bool, time.Time) {
+	if s.Cron == "" {
+		return !now.Before(s.StartsAt) && now.Before(s.EndsAt), s.EndsAt
+	}
+
+	schedule, err := cron.Parse(s.Cron)
+	if err != nil {
+		return false, time.Time{}
+	}
+
+	// the last start of the window is the first one after now - duration
+	duration := time.Duration(s.DurationMinutes) * time.Minute
+	start := schedule.Next(now.Add(-duration).Add(-time.Minute))
+	if start.IsZero() || start.After(now) {
+		return false, time.Time{}
+	}
+
+	end := start.Add(duration)
+
+	return now.Before(end), end
+}
+
+// SilenceMatches : checks if the silence targets the task
+func SilenceMatches(s model.Silence, t model.Task) bool {
+	if s.TaskID != 0 {
+		return s.TaskID == t.ID
+	}
+
+	if s.RancherID != t.RancherID || s.ProjectID != t.RancherProjectID {
+		return false
+	}
+
+	return s.Service == model.SilenceAllTasks || s.Service == t.Service
+}
+
+// FindTaskSilence : the active silence of the task, if any
+func FindTaskSilence(t model.Task, now time.Time) (*model.Silence, error) {
+	silences, err := ListSilences(now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, silence := range silences {
+		if !SilenceMatches(silence, t) {
+			continue
+		}
+
+		if active, _ := SilenceActive(silence, now); active {
+			return &silence, nil
+		}
+	}
+
+	return nil, nil
+}
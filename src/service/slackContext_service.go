@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+)
+
+// FindSlackContext : the context of the user in the channel or, without one,
+// in every channel. The bool is false when the user never selected a Rancher
+func FindSlackContext(user string, channel string) (model.SlackContext, bool, error) {
+	var context model.SlackContext
+
+	err := repository.FindSlackContext(&context, user, channel)
+	if err == gorm.ErrRecordNotFound && channel != "" {
+		err = repository.FindSlackContext(&context, user, "")
+	}
+
+	if err == gorm.ErrRecordNotFound {
+		return context, false, nil
+	}
+
+	return context, err == nil, err
+}
+
+// SetSlackContext : saves the Rancher and environment selected by the user, in
+// the channel or, with channel empty, in every channel
+func SetSlackContext(c *model.SlackContext) error {
+	if c.User == "" {
+		return fmt.Errorf("the context needs the Slack user")
+	}
+
+	var current model.SlackContext
+	err := repository.FindSlackContext(&current, c.User, c.Channel)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	// keeps the ID to update the existing row
+	c.Model = current.Model
+
+	return repository.SaveSlackContext(c)
+}
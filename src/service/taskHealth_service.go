@@ -0,0 +1,181 @@
+package service
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/slack-bot-4all/slack-bot/src/model"
+	"github.com/slack-bot-4all/slack-bot/src/repository"
+)
+
+// HealthTransition : result of a check of a task, with what has to be sent to Slack
+type HealthTransition struct {
+	From string
+	To   string
+
+	// Alert : the task started failing, the alert must be sent
+	Alert bool
+
+	// Recovered : the task is healthy again after an alert
+	Recovered bool
+
+	// FlappingStarted : the task failed FlapThreshold times inside the window,
+	// the next alerts are suppressed
+	FlappingStarted bool
+
+	// FlappingStopped : the task stayed healthy for the whole window
+	FlappingStopped bool
+}
+
+// GetTaskHealth : the health of the task, healthy when it was never checked
+func GetTaskHealth(taskID uint) (model.TaskHealth, error) {
+	health := model.TaskHealth{TaskID: taskID, State: model.HealthHealthy}
+
+	err := repository.FindTaskHealthByTaskID(&health, taskID)
+	if err == gorm.ErrRecordNotFound {
+		return health, nil
+	}
+
+	return health, err
+}
+
+// ListTaskHealth : the health of all tasks by task ID
+func ListTaskHealth() (map[uint]model.TaskHealth, error) {
+	var list []model.TaskHealth
+	if err := repository.ListTaskHealth(&list); err != nil {
+		return nil, err
+	}
+
+	health := map[uint]model.TaskHealth{}
+	for _, h := range list {
+		health[h.TaskID] = h
+	}
+
+	return health, nil
+}
+
+// SaveTaskHealth :
+func SaveTaskHealth(h *model.TaskHealth) error {
+	return repository.SaveTaskHealth(h)
+}
+
+// EvaluateTaskHealth : moves the state machine of the task with the result of
+// a check (healthy -> degraded -> failing -> recovering -> healthy)
+func EvaluateTaskHealth(t model.Task, h *model.TaskHealth, unhealthy bool, now time.Time) HealthTransition {
+	failingAfter, recoveredAfter, flapThreshold, flapWindow := taskThresholds(t)
+
+	if h.State == "" {
+		h.State = model.HealthHealthy
+	}
+
+	transition := HealthTransition{From: h.State}
+
+	if unhealthy {
+		h.FailedChecks++
+		h.HealthyChecks = 0
+	} else {
+		h.HealthyChecks++
+		h.FailedChecks = 0
+	}
+
+	next := h.State
+	switch h.State {
+	case model.HealthHealthy, model.HealthDegraded:
+		if !unhealthy {
+			next = model.HealthHealthy
+		} else if h.FailedChecks >= failingAfter {
+			next = model.HealthFailing
+		} else {
+			next = model.HealthDegraded
+		}
+	case model.HealthFailing, model.HealthRecovering:
+		if unhealthy {
+			next = model.HealthFailing
+		} else if h.HealthyChecks >= recoveredAfter {
+			next = model.HealthHealthy
+		} else {
+			next = model.HealthRecovering
+		}
+	}
+
+	if next == model.HealthFailing && h.State != model.HealthFailing {
+		if now.Sub(h.FlapWindowStart) > flapWindow {
+			h.FlapWindowStart = now
+			h.FlapCount = 0
+		}
+		h.FlapCount++
+
+		if h.FlapCount >= flapThreshold && !h.Flapping {
+			h.Flapping = true
+			transition.FlappingStarted = true
+		}
+
+		if !h.Alerted && !h.Flapping {
+			h.Alerted = true
+			transition.Alert = true
+		}
+	}
+
+	// a task that keeps failing for the whole window is not flapping anymore,
+	// it is down and the alert can't stay suppressed
+	if next == model.HealthFailing && h.State == model.HealthFailing && h.Flapping && now.Sub(h.LastChangeAt) > flapWindow {
+		h.Flapping = false
+		h.FlapCount = 0
+		transition.FlappingStopped = true
+
+		if !h.Alerted {
+			h.Alerted = true
+			transition.Alert = true
+		}
+	}
+
+	if next == model.HealthFailing {
+		h.LastFailingAt = now
+	}
+
+	if next == model.HealthHealthy && h.State != model.HealthHealthy && h.State != model.HealthDegraded {
+		if h.Alerted && !h.Flapping {
+			transition.Recovered = true
+		}
+		h.Alerted = false
+		h.RecoveryAttempts = 0
+	}
+
+	if next == model.HealthHealthy && h.Flapping && now.Sub(h.LastFailingAt) > flapWindow {
+		h.Flapping = false
+		h.Alerted = false
+		h.FlapCount = 0
+		transition.FlappingStopped = true
+	}
+
+	if next != h.State {
+		h.LastChangeAt = now
+	}
+
+	h.State = next
+	transition.To = next
+
+	return transition
+}
+
+// taskThresholds : thresholds of the task, using the defaults for tasks
+// created before they were configurable
+func taskThresholds(t model.Task) (failingAfter int, recoveredAfter int, flapThreshold int, flapWindow time.Duration) {
+	failingAfter, recoveredAfter, flapThreshold = t.FailingAfter, t.RecoveredAfter, t.FlapThreshold
+	flapWindowMinutes := t.FlapWindowMinutes
+
+	if failingAfter <= 0 {
+		failingAfter = model.DefaultFailingAfter
+	}
+	if recoveredAfter <= 0 {
+		recoveredAfter = model.DefaultRecoveredAfter
+	}
+	if flapThreshold <= 0 {
+		flapThreshold = model.DefaultFlapThreshold
+	}
+	if flapWindowMinutes <= 0 {
+		flapWindowMinutes = model.DefaultFlapWindowMinutes
+	}
+
+	return failingAfter, recoveredAfter, flapThreshold, time.Duration(flapWindowMinutes) * time.Minute
+}
@@ -1,23 +1,81 @@
 package service
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/slack-bot-4all/slack-bot/src/cron"
 	"github.com/slack-bot-4all/slack-bot/src/model"
 	"github.com/slack-bot-4all/slack-bot/src/repository"
 )
 
 // AddTask : have a business rules to add a Task to db
 func AddTask(t *model.Task) error {
-	var err error
+	if t.FailingAfter <= 0 {
+		t.FailingAfter = model.DefaultFailingAfter
+	}
+	if t.RecoveredAfter <= 0 {
+		t.RecoveredAfter = model.DefaultRecoveredAfter
+	}
+	if t.FlapThreshold <= 0 {
+		t.FlapThreshold = model.DefaultFlapThreshold
+	}
+	if t.FlapWindowMinutes <= 0 {
+		t.FlapWindowMinutes = model.DefaultFlapWindowMinutes
+	}
+
+	if t.Cron != "" && t.IntervalSeconds > 0 {
+		return fmt.Errorf("use --every or --cron, not both")
+	}
+
+	if t.IntervalSeconds != 0 && t.IntervalSeconds < model.MinTaskIntervalSeconds {
+		return fmt.Errorf("interval must be at least %ds", model.MinTaskIntervalSeconds)
+	}
+
+	if t.Cron != "" {
+		schedule, err := cron.Parse(t.Cron)
+		if err != nil {
+			return err
+		}
+
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("cron `%s` never runs", t.Cron)
+		}
+	}
+
+	if t.RancherID != 0 {
+		var rancher model.Rancher
+		if err := repository.FindRancherByID(&rancher, t.RancherID); err != nil {
+			return fmt.Errorf("Rancher %d not found", t.RancherID)
+		}
+	}
 
-	if t.RancherURL != "" && t.RancherAccessKey != "" && t.RancherSecretKey != "" && t.Service != "" {
-		err = repository.AddTask(t)
+	if t.Service == "" || t.RancherProjectID == "" {
+		return fmt.Errorf("service (stackName/serviceName) and environment are required")
 	}
 
+	return repository.AddTask(t)
+}
+
+// FindTask : the task with the ID
+func FindTask(ID uint) (model.Task, error) {
+	var task model.Task
+
+	err := repository.FindTaskByID(&task, ID)
+
+	return task, err
+}
+
+// PauseTask : pauses or resumes the checks of a task
+func PauseTask(ID uint, paused bool) (model.Task, error) {
+	task, err := FindTask(ID)
 	if err != nil {
-		return err
+		return task, err
 	}
 
-	return nil
+	task.Paused = paused
+
+	return task, repository.SaveTask(&task)
 }
 
 // ListTask : list all ranchers
@@ -38,5 +96,9 @@ func DeleteTask(t model.Task) error {
 		return err
 	}
 
+	if err := repository.DeleteTaskHealthByTaskID(t.ID); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -1,21 +1,177 @@
 package service
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-bot-4all/slack-bot/src/config"
 	"github.com/slack-bot-4all/slack-bot/src/model"
 	"github.com/slack-bot-4all/slack-bot/src/repository"
 )
 
+// MinPasswordLength : shortest password accepted for the users
+const MinPasswordLength = 8
+
+// bootstrapMutex : two requests can't create the first user at the same time
+var bootstrapMutex sync.Mutex
+
 // AddUser : have a business rules to add a User to db
 func AddUser(u *model.User) error {
-	var err error
+	u.Username = strings.TrimSpace(u.Username)
+	if u.Username == "" || u.Password == "" {
+		return fmt.Errorf("username and password are required")
+	}
+
+	if err := validatePassword(u.Password); err != nil {
+		return err
+	}
+
+	existing := model.User{Username: u.Username}
+	if err := repository.FindUserByUsername(&existing); err == nil {
+		return fmt.Errorf("user %s already exists", u.Username)
+	}
+
+	if err := repository.AddUser(u); err != nil {
+		return err
+	}
+	u.Password = ""
+
+	return nil
+}
+
+// ListUsers : list all users, without the passwords
+func ListUsers() ([]model.User, error) {
+	var users []model.User
+
+	if err := repository.ListUser(&users); err != nil {
+		return nil, err
+	}
 
-	if u.Username != "" && u.Password != "" {
-		err = repository.AddUser(u)
+	for i := range users {
+		users[i].Password = ""
 	}
 
+	return users, nil
+}
+
+// FindUser : the user with the ID, without the password
+func FindUser(ID uint) (model.User, error) {
+	var user model.User
+
+	err := repository.FindUserByID(&user, ID)
+	user.Password = ""
+
+	return user, err
+}
+
+// DeleteUser : removes a user, except the last one, revoking its tokens
+func DeleteUser(ID uint) (model.User, error) {
+	user, err := FindUser(ID)
+	if err != nil {
+		return user, err
+	}
+
+	count, err := repository.CountUsers()
+	if err != nil {
+		return user, err
+	}
+	if count <= 1 {
+		return user, fmt.Errorf("user %s is the last one and can't be deleted", user.Username)
+	}
+
+	return user, repository.DeleteUser(&user, time.Now())
+}
+
+// BootstrapUser : creates the first user, only while there is no user
+func BootstrapUser(u *model.User) error {
+	bootstrapMutex.Lock()
+	defer bootstrapMutex.Unlock()
+
+	count, err := repository.CountUsers()
 	if err != nil {
 		return err
 	}
+	if count > 0 {
+		return fmt.Errorf("the API was already bootstrapped, log in with an existing user")
+	}
+
+	return AddUser(u)
+}
+
+// NeedsBootstrap : checks if there is no user yet
+func NeedsBootstrap() (bool, error) {
+	count, err := repository.CountUsers()
+
+	return count == 0, err
+}
+
+// ChangePassword : changes the password of the user, checking the current one
+func ChangePassword(username string, current string, password string) error {
+	user := model.User{Username: username}
+	if err := repository.FindUserByUsername(&user); err != nil {
+		return err
+	}
+
+	var hash config.Hash
+	if err := hash.Compare(user.Password, current); err != nil {
+		return fmt.Errorf("current password is wrong")
+	}
+
+	return setPassword(&user, password)
+}
+
+// SetPassword : replaces the password of a user, like when it was forgotten
+func SetPassword(ID uint, password string) error {
+	var user model.User
+	if err := repository.FindUserByID(&user, ID); err != nil {
+		return err
+	}
+
+	return setPassword(&user, password)
+}
+
+// PasswordIs : checks if the password of the user is the one informed, like
+// the default admin/admin of the old versions
+func PasswordIs(username string, password string) bool {
+	user := model.User{Username: username}
+	if err := repository.FindUserByUsername(&user); err != nil {
+		return false
+	}
+
+	var hash config.Hash
+	return hash.Compare(user.Password, password) == nil
+}
+
+// RemoveDefaultAdmin : removes the user admin while it has the default password
+// admin/admin of the old versions, revoking its tokens. Without other users the
+// API goes back to the bootstrap
+func RemoveDefaultAdmin() (bool, error) {
+	if !PasswordIs("admin", "admin") {
+		return false, nil
+	}
+
+	user := model.User{Username: "admin"}
+	if err := repository.FindUserByUsername(&user); err != nil {
+		return false, err
+	}
+
+	return true, repository.DeleteUser(&user, time.Now())
+}
+
+func setPassword(u *model.User, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+
+	return repository.UpdateUserPassword(u, password)
+}
+
+func validatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("password must have at least %d characters", MinPasswordLength)
+	}
 
 	return nil
 }